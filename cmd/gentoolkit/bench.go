@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"golang.org/x/tools/imports"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:  "bench",
+		usage: "benchmark the generation pipeline on synthetic packages",
+		run:   runBench,
+	})
+}
+
+// benchFields are the fields of every synthetic struct.
+var benchFields = [][2]string{
+	{"ID", "int64"},
+	{"Name", "string"},
+	{"Email", "string"},
+	{"Tags", "[]string"},
+	{"Attrs", "map[string]string"},
+	{"CreatedAt", "time.Time"},
+	{"Score", "float64"},
+	{"Active", "bool"},
+}
+
+// phase is a benchmarked step of the pipeline.
+type phase struct {
+	name string
+	run  func(b *testing.B)
+}
+
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	sizes := fs.String("sizes", "100,1000,10000", "comma-separated numbers of structs in the synthetic packages")
+	budget := fs.Duration("budget", 0, "fail if a full run over 1000 structs takes longer than this; 0 disables the check")
+	keep := fs.Bool("keep", false, "keep the synthetic packages and print their location")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of gentoolkit bench:\n")
+		fmt.Fprintf(os.Stderr, "\tgentoolkit bench [flags]\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	root, err := ioutil.TempDir("", "gentoolkit-bench")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *keep {
+		fmt.Fprintf(os.Stderr, "synthetic packages in %s\n", root)
+	} else {
+		defer os.RemoveAll(root)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "structs\tphase\ttime/op\tallocs/op\tbytes/op\t\n")
+	overBudget := false
+	for _, s := range strings.Split(*sizes, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n <= 0 {
+			log.Fatalf("invalid size %q", s)
+		}
+		dir := filepath.Join(root, fmt.Sprintf("p%d", n))
+		if err := writeSynthetic(dir, n); err != nil {
+			log.Fatal(err)
+		}
+
+		var total time.Duration
+		for _, p := range benchPhases(dir, n) {
+			r := testing.Benchmark(p.run)
+			if r.N == 0 {
+				log.Fatalf("%d structs: %s failed", n, p.name)
+			}
+			perOp := time.Duration(r.NsPerOp())
+			total += perOp
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t\n", n, p.name, perOp, r.AllocsPerOp(), r.AllocedBytesPerOp())
+		}
+		fmt.Fprintf(w, "%d\ttotal\t%s\t\t\t\n", n, total)
+		if *budget > 0 && total*1000/time.Duration(n) > *budget {
+			overBudget = true
+		}
+	}
+	w.Flush()
+	if overBudget {
+		log.Printf("over budget: a run over 1000 structs takes longer than %s", *budget)
+		os.Exit(1)
+	}
+}
+
+// writeSynthetic writes a module with a package of n structs to dir.
+func writeSynthetic(dir string, n int) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module bench\n\ngo 1.17\n"), 0644); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package bench\n\nimport \"time\"\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "\ntype S%d struct {\n", i)
+		for _, f := range benchFields {
+			fmt.Fprintf(&buf, "\t%s %s `json:%q`\n", f[0], f[1], strings.ToLower(f[0]))
+		}
+		fmt.Fprintf(&buf, "}\n")
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "types.go"), buf.Bytes(), 0644)
+}
+
+// benchPhases returns the pipeline steps for the synthetic package of n
+// structs in dir: loading, emitting getters, formatting and writing.
+func benchPhases(dir string, n int) []phase {
+	generated := func() []byte {
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "// Code generated by \"gentoolkit bench\"; DO NOT EDIT.\n\npackage bench\n")
+		for i := 0; i < n; i++ {
+			for _, f := range benchFields {
+				buf.WriteString("\n")
+				structutil.GetterSnippet.Execute(&buf, structutil.Snippet{
+					Receiver: "s",
+					Struct:   fmt.Sprintf("S%d", i),
+					Field:    f[0],
+					Method:   structutil.Naming().MethodName("Get", f[0]),
+					Type:     f[1],
+				})
+				buf.WriteString("\n")
+			}
+		}
+		return buf.Bytes()
+	}
+	src := generated()
+	outputName := filepath.Join(dir, "bench_getter.go")
+	formatted, err := imports.Process(outputName, src, nil)
+	if err != nil {
+		log.Fatalf("formatting output: %s", err)
+	}
+
+	return []phase{
+		{"parse", func(b *testing.B) {
+			// The synthetic module is only visible from inside it.
+			wd, err := os.Getwd()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := os.Chdir(dir); err != nil {
+				b.Fatal(err)
+			}
+			defer os.Chdir(wd)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := structutil.LoadPackage([]string{"."}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}},
+		{"generate", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				generated()
+			}
+		}},
+		{"format", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := imports.Process(outputName, src, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}},
+		{"write", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := ioutil.WriteFile(outputName, formatted, 0644); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}},
+	}
+}
@@ -0,0 +1,535 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"golang.org/x/tools/go/packages"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:  "encapsulate",
+		usage: "unexport a struct field, add accessors and rewrite its uses to them",
+		run:   runEncapsulate,
+	})
+}
+
+func runEncapsulate(args []string) {
+	fs := flag.NewFlagSet("encapsulate", flag.ExitOnError)
+	typeName := fs.String("type", "", "struct type declaring the field, as T or importpath.T; must be set")
+	fieldName := fs.String("field", "", "exported field to encapsulate; must be set")
+	dryRun := fs.Bool("n", false, "print the files that would change but do not write them")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of gentoolkit encapsulate:\n")
+		fmt.Fprintf(os.Stderr, "\tgentoolkit encapsulate [flags] -type T -field F [packages]\n")
+		fmt.Fprintf(os.Stderr, "Uses are rewritten in the given packages, default ./...\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *typeName == "" || *fieldName == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	cfg := &packages.Config{
+		Mode:  packages.LoadSyntax,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		os.Exit(1)
+	}
+
+	e, err := newEncapsulation(pkgs, *typeName, *fieldName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	files, err := e.rewrite(pkgs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if *dryRun {
+			fmt.Println(name)
+			continue
+		}
+		if err := ioutil.WriteFile(name, files[name], 0644); err != nil {
+			log.Fatalf("writing %s: %s", name, err)
+		}
+	}
+}
+
+// encapsulation is the field being encapsulated.
+type encapsulation struct {
+	fset    *token.FileSet
+	pkgPath string // Package declaring the struct.
+	named   *types.Named
+	field   *types.Var
+	pos     token.Position // Declaration of the field.
+	spec    *ast.TypeSpec
+	decl    *ast.GenDecl
+	typ     ast.Expr // Type of the field in its declaration.
+
+	name   string // New, unexported name of the field.
+	getter string
+	setter string
+}
+
+// newEncapsulation finds the field to encapsulate in pkgs.
+func newEncapsulation(pkgs []*packages.Package, typeName, fieldName string) (*encapsulation, error) {
+	pkgPath := ""
+	if i := strings.LastIndex(typeName, "."); i >= 0 {
+		pkgPath, typeName = typeName[:i], typeName[i+1:]
+	}
+
+	var candidates []*packages.Package
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.Types == nil || (pkgPath != "" && pkg.PkgPath != pkgPath) || seen[pkg.PkgPath] {
+			continue
+		}
+		if obj, ok := pkg.Types.Scope().Lookup(typeName).(*types.TypeName); ok {
+			if _, ok := obj.Type().Underlying().(*types.Struct); ok {
+				seen[pkg.PkgPath] = true
+				candidates = append(candidates, pkg)
+			}
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("struct type %s not found", typeName)
+	case 1:
+	default:
+		var paths []string
+		for _, pkg := range candidates {
+			paths = append(paths, pkg.PkgPath+"."+typeName)
+		}
+		return nil, fmt.Errorf("type %s is declared in several packages; use one of %s", typeName, strings.Join(paths, ", "))
+	}
+	pkg := candidates[0]
+
+	named := pkg.Types.Scope().Lookup(typeName).Type().(*types.Named)
+	st := named.Underlying().(*types.Struct)
+	var field *types.Var
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i).Name() == fieldName {
+			field = st.Field(i)
+		}
+	}
+	switch {
+	case field == nil:
+		return nil, fmt.Errorf("type %s has no field %s", typeName, fieldName)
+	case field.Embedded():
+		return nil, fmt.Errorf("%s.%s is an embedded field", typeName, fieldName)
+	case !field.Exported():
+		return nil, fmt.Errorf("%s.%s is not exported", typeName, fieldName)
+	}
+
+	e := &encapsulation{
+		fset:    pkg.Fset,
+		pkgPath: pkg.PkgPath,
+		named:   named,
+		field:   field,
+		pos:     pkg.Fset.Position(field.Pos()),
+		name:    unexport(fieldName),
+		getter:  structutil.Naming().MethodName("Get", fieldName),
+		setter:  structutil.Naming().MethodName("Set", fieldName),
+	}
+	for _, name := range []string{e.name, e.getter, e.setter} {
+		if obj, _, _ := types.LookupFieldOrMethod(named, true, pkg.Types, name); obj != nil {
+			return nil, fmt.Errorf("type %s already has a field or method %s", typeName, name)
+		}
+	}
+	if !e.findDecl(pkg) {
+		return nil, fmt.Errorf("declaration of %s not found", typeName)
+	}
+	return e, nil
+}
+
+// findDecl finds the declaration of the struct and the field in the syntax
+// of pkg.
+func (e *encapsulation) findDecl(pkg *packages.Package) bool {
+	for _, f := range pkg.Syntax {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if pkg.TypesInfo.Defs[ts.Name] != e.named.Obj() {
+					continue
+				}
+				for _, field := range ts.Type.(*ast.StructType).Fields.List {
+					for _, name := range field.Names {
+						if pkg.TypesInfo.Defs[name] == e.field {
+							e.decl, e.spec, e.typ = gd, ts, field.Type
+							return true
+						}
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// is reports whether obj is the field, in any variant of its package.
+func (e *encapsulation) is(obj types.Object) bool {
+	if obj == nil {
+		return false
+	}
+	v, ok := obj.(*types.Var)
+	return ok && v.IsField() && e.fset.Position(obj.Pos()) == e.pos
+}
+
+// edit replaces the source between the offsets start and end.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// fileEdits collects the edits of one file.
+type fileEdits struct {
+	src   []byte
+	reads []edit // Field reads rewritten to getter calls.
+	stmts []edit // Statements rewritten to setter calls.
+}
+
+// rewrite returns the new contents of the files changed by the
+// encapsulation. It fails without changes if a use cannot be rewritten.
+func (e *encapsulation) rewrite(pkgs []*packages.Package) (map[string][]byte, error) {
+	edits := make(map[string]*fileEdits)
+	var problems []string
+	done := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Syntax {
+			name := e.fset.File(f.Pos()).Name()
+			if done[name] {
+				// Test variants of a package share its files.
+				continue
+			}
+			done[name] = true
+			fe, err := e.rewriteFile(pkg, f, name)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			if fe != nil {
+				edits[name] = fe
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("cannot encapsulate %s.%s:\n\t%s", e.named.Obj().Name(), e.field.Name(), strings.Join(problems, "\n\t"))
+	}
+
+	files := make(map[string][]byte)
+	for name, fe := range edits {
+		src, err := format.Source(fe.apply())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		files[name] = src
+	}
+	return files, nil
+}
+
+// rewriteFile collects the edits of the file f of pkg, or nil if it does not
+// use the field.
+func (e *encapsulation) rewriteFile(pkg *packages.Package, f *ast.File, name string) (*fileEdits, error) {
+	src, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	fe := &fileEdits{src: src}
+	internal := pkg.PkgPath == e.pkgPath
+	offset := func(pos token.Pos) int { return e.fset.Position(pos).Offset }
+	var (
+		problems []string
+		sets     []setUse
+	)
+	problem := func(pos token.Pos, format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%s: %s", e.fset.Position(pos), fmt.Sprintf(format, args...)))
+	}
+
+	var stack []ast.Node
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		parent := ast.Node(nil)
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+		stack = append(stack, n)
+
+		id, ok := n.(*ast.Ident)
+		if !ok || !(e.is(pkg.TypesInfo.Uses[id]) || e.is(pkg.TypesInfo.Defs[id])) {
+			return true
+		}
+		if internal {
+			// The declaring package keeps direct access to the field.
+			fe.reads = append(fe.reads, edit{offset(id.Pos()), offset(id.End()), e.name})
+			return true
+		}
+		sel, ok := parent.(*ast.SelectorExpr)
+		if !ok || sel.Sel != id {
+			problem(id.Pos(), "field set in a composite literal")
+			return true
+		}
+		switch use, stmt := e.classify(stack[:len(stack)-2], sel); use {
+		case "read":
+			fe.reads = append(fe.reads, edit{offset(id.Pos()), offset(id.End()), e.getter + "()"})
+		case "set":
+			sets = append(sets, setUse{stmt, sel})
+		default:
+			problem(id.Pos(), "%s", use)
+		}
+		return true
+	})
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "\n\t"))
+	}
+	if len(fe.reads) == 0 && len(sets) == 0 {
+		return nil, nil
+	}
+	// Statements are rewritten innermost first, so that the rewrite of an
+	// enclosing one includes theirs.
+	sort.SliceStable(sets, func(i, j int) bool {
+		return sets[i].stmt.End()-sets[i].stmt.Pos() < sets[j].stmt.End()-sets[j].stmt.Pos()
+	})
+	for _, su := range sets {
+		fe.stmts = append(fe.stmts, e.setterEdit(fe, su.stmt, su.sel))
+	}
+	if e.fset.Position(e.spec.Pos()).Filename == name {
+		e.addAccessors(fe)
+	}
+	return fe, nil
+}
+
+// setUse is a statement setting the field selected by sel.
+type setUse struct {
+	stmt ast.Stmt
+	sel  *ast.SelectorExpr
+}
+
+// classify reports how the selection sel of the field is used given its
+// ancestors: "read", "set" with the assignment or increment statement
+// setting it, or a description of a use that cannot be rewritten.
+func (e *encapsulation) classify(ancestors []ast.Node, sel *ast.SelectorExpr) (string, ast.Stmt) {
+	var child ast.Node = sel
+	inPlace := false // Whether sel is the base of a larger operand.
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		switch p := ancestors[i].(type) {
+		case *ast.ParenExpr:
+		case *ast.SelectorExpr:
+			if p.X != child {
+				return "read", nil
+			}
+			inPlace = true
+		case *ast.IndexExpr:
+			if p.X != child {
+				return "read", nil
+			}
+			inPlace = true
+		case *ast.UnaryExpr:
+			if p.Op == token.AND {
+				return "address of the field taken", nil
+			}
+			return "read", nil
+		case *ast.AssignStmt:
+			for _, lhs := range p.Lhs {
+				if lhs != child {
+					continue
+				}
+				switch {
+				case inPlace && e.valueType():
+					return "field modified in place", nil
+				case inPlace:
+					return "read", nil
+				case len(p.Lhs) != 1:
+					return "field set in a multiple assignment", nil
+				}
+				return "set", p
+			}
+			return "read", nil
+		case *ast.IncDecStmt:
+			switch {
+			case inPlace && e.valueType():
+				return "field modified in place", nil
+			case inPlace:
+				return "read", nil
+			}
+			return "set", p
+		case *ast.RangeStmt:
+			if p.Key == child || p.Value == child {
+				return "field set by a range clause", nil
+			}
+			return "read", nil
+		default:
+			return "read", nil
+		}
+		child = ancestors[i]
+	}
+	return "read", nil
+}
+
+// valueType reports whether the field holds a struct or array, whose
+// elements are copied by the getter.
+func (e *encapsulation) valueType() bool {
+	switch e.field.Type().Underlying().(type) {
+	case *types.Struct, *types.Array:
+		return true
+	}
+	return false
+}
+
+// setterEdit rewrites stmt, an assignment or increment of the field
+// selected by sel, to a setter call.
+func (e *encapsulation) setterEdit(fe *fileEdits, stmt ast.Stmt, sel *ast.SelectorExpr) edit {
+	offset := func(pos token.Pos) int { return e.fset.Position(pos).Offset }
+	recv := fe.text(offset(sel.X.Pos()), offset(sel.X.End()))
+	get := recv + "." + e.getter + "()"
+	var value string
+	switch s := stmt.(type) {
+	case *ast.AssignStmt:
+		rhs := fe.text(offset(s.Rhs[0].Pos()), offset(s.Rhs[0].End()))
+		if s.Tok == token.ASSIGN {
+			value = rhs
+			break
+		}
+		if _, ok := s.Rhs[0].(*ast.BinaryExpr); ok {
+			rhs = "(" + rhs + ")"
+		}
+		op := strings.TrimSuffix(s.Tok.String(), "=")
+		value = fmt.Sprintf("%s %s %s", get, op, rhs)
+	case *ast.IncDecStmt:
+		op := "+"
+		if s.Tok == token.DEC {
+			op = "-"
+		}
+		value = fmt.Sprintf("%s %s 1", get, op)
+	}
+	return edit{offset(stmt.Pos()), offset(stmt.End()), fmt.Sprintf("%s.%s(%s)", recv, e.setter, value)}
+}
+
+// text returns the source between the offsets start and end with the
+// edits inside it applied.
+func (fe *fileEdits) text(start, end int) string {
+	return string(applyEdits(fe.src[start:end], fe.outermost(start, end), start))
+}
+
+// outermost returns the edits between the offsets start and end that are
+// not part of another edit there. Insertions are never part of another edit.
+func (fe *fileEdits) outermost(start, end int) []edit {
+	all := append(append([]edit(nil), fe.stmts...), fe.reads...)
+	var edits []edit
+	for i, ed := range all {
+		if ed.start < start || ed.end > end {
+			continue
+		}
+		contained := false
+		for j, outer := range all {
+			if j != i && outer.start != outer.end && outer.start >= start && outer.end <= end &&
+				outer.start <= ed.start && ed.end <= outer.end && (outer.end-outer.start > ed.end-ed.start || j < i) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			edits = append(edits, ed)
+		}
+	}
+	return edits
+}
+
+// addAccessors inserts the getter and setter after the struct declaration.
+func (e *encapsulation) addAccessors(fe *fileEdits) {
+	offset := func(pos token.Pos) int { return e.fset.Position(pos).Offset }
+	typ := string(fe.src[offset(e.typ.Pos()):offset(e.typ.End())])
+	typeName := e.named.Obj().Name()
+	recv := receiver(e.named)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "\n\n// %s returns the %s field.\n", e.getter, e.name)
+	fmt.Fprintf(&buf, "func (%s *%s) %s() %s {\n\treturn %s.%s\n}\n", recv, typeName, e.getter, typ, recv, e.name)
+	fmt.Fprintf(&buf, "\n// %s sets the %s field.\n", e.setter, e.name)
+	fmt.Fprintf(&buf, "func (%s *%s) %s(v %s) {\n\t%s.%s = v\n}", recv, typeName, e.setter, typ, recv, e.name)
+	end := offset(e.decl.End())
+	fe.stmts = append(fe.stmts, edit{end, end, buf.String()})
+}
+
+// apply returns the source of the file with its edits applied.
+func (fe *fileEdits) apply() []byte {
+	return applyEdits(fe.src, fe.outermost(0, len(fe.src)), 0)
+}
+
+// applyEdits applies non-overlapping edits, whose offsets are relative to
+// base, to src.
+func applyEdits(src []byte, edits []edit, base int) []byte {
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var out []byte
+	last := 0
+	for _, ed := range edits {
+		out = append(out, src[last:ed.start-base]...)
+		out = append(out, ed.text...)
+		last = ed.end - base
+	}
+	return append(out, src[last:]...)
+}
+
+// receiver returns the receiver name used by the methods of named, or its
+// lower-cased initial.
+func receiver(named *types.Named) string {
+	for i := 0; i < named.NumMethods(); i++ {
+		sig := named.Method(i).Type().(*types.Signature)
+		if name := sig.Recv().Name(); name != "" && name != "_" {
+			return name
+		}
+	}
+	return strings.ToLower(named.Obj().Name()[:1])
+}
+
+// unexport returns name with its leading upper-case run lowered, keeping
+// the last letter of an initialism before a lower-case letter upper case:
+// URL becomes url and HTTPClient becomes httpClient.
+func unexport(name string) string {
+	r := []rune(name)
+	for i := 0; i < len(r) && unicode.IsUpper(r[i]); i++ {
+		if i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+			break
+		}
+		r[i] = unicode.ToLower(r[i])
+	}
+	return string(r)
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:  "extract",
+		usage: "print the minimal interface covering how a package uses a concrete type",
+		run:   runExtract,
+	})
+}
+
+func runExtract(args []string) {
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	typeName := fs.String("type", "", "concrete type, as importpath.T or T for a type of the package; must be set")
+	name := fs.String("name", "", "name of the interface; default the name of the type")
+	output := fs.String("output", "", "write the interface to this file instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of gentoolkit extract:\n")
+		fmt.Fprintf(os.Stderr, "\tgentoolkit extract [flags] -type T [package]\n")
+		fmt.Fprintf(os.Stderr, "Declarations of the package that should accept the interface are listed on standard error.\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *typeName == "" || fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	pattern := "."
+	if fs.NArg() == 1 {
+		pattern = fs.Arg(0)
+	}
+	cfg := &packages.Config{Mode: packages.LoadSyntax}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(pkgs) != 1 {
+		log.Fatalf("error: %d packages found", len(pkgs))
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		os.Exit(1)
+	}
+	pkg := pkgs[0]
+
+	x := &extraction{pkg: pkg, typePath: pkg.PkgPath, typeName: *typeName}
+	if i := strings.LastIndex(*typeName, "."); i >= 0 {
+		x.typePath, x.typeName = (*typeName)[:i], (*typeName)[i+1:]
+	}
+	x.name = *name
+	if x.name == "" {
+		x.name = x.typeName
+	}
+	if pkg.Types.Scope().Lookup(x.name) != nil {
+		log.Fatalf("package %s already declares %s; set -name", pkg.Name, x.name)
+	}
+
+	methods, problems := x.usedMethods()
+	for _, p := range problems {
+		log.Print(p)
+	}
+	if len(methods) == 0 {
+		log.Fatalf("package %s calls no methods of %s", pkg.Name, *typeName)
+	}
+	src, err := x.source(methods)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *output == "" {
+		os.Stdout.Write(src)
+	} else if err := ioutil.WriteFile(*output, src, 0644); err != nil {
+		log.Fatalf("writing output: %s", err)
+	}
+	for _, s := range x.suggestions() {
+		fmt.Fprintln(os.Stderr, s)
+	}
+}
+
+// extraction is an interface extracted from the uses of a concrete type in
+// a package.
+type extraction struct {
+	pkg      *packages.Package
+	typePath string
+	typeName string
+	name     string // Name of the interface.
+
+	imports map[string]string // Packages referred to by the methods, by path.
+}
+
+// isType reports whether t is the concrete type or a pointer to it.
+func (x *extraction) isType(t types.Type) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	return named.Obj().Pkg().Path() == x.typePath && named.Obj().Name() == x.typeName
+}
+
+// usedMethods returns the methods of the type selected in the package,
+// sorted by name, and the uses that an interface cannot cover.
+func (x *extraction) usedMethods() ([]*types.Func, []string) {
+	var (
+		methods  []*types.Func
+		seen     = make(map[string]bool)
+		problems []string
+	)
+	for _, f := range x.pkg.Syntax {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if fn, ok := n.(*ast.FuncDecl); ok && fn.Recv != nil && x.isType(x.pkg.TypesInfo.TypeOf(fn.Recv.List[0].Type)) {
+				// The methods of the type itself use its internals.
+				return false
+			}
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			s := x.pkg.TypesInfo.Selections[sel]
+			if s == nil || !x.isType(s.Recv()) {
+				return true
+			}
+			pos := x.pkg.Fset.Position(sel.Sel.Pos())
+			switch s.Kind() {
+			case types.FieldVal:
+				problems = append(problems, fmt.Sprintf("%s: field %s cannot be part of an interface", pos, sel.Sel.Name))
+			case types.MethodVal:
+				fn := s.Obj().(*types.Func)
+				if !fn.Exported() && fn.Pkg() != x.pkg.Types {
+					problems = append(problems, fmt.Sprintf("%s: method %s is not exported", pos, fn.Name()))
+					break
+				}
+				if !seen[fn.Name()] {
+					seen[fn.Name()] = true
+					methods = append(methods, fn)
+				}
+			}
+			return true
+		})
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name() < methods[j].Name() })
+	return methods, problems
+}
+
+// qualifier names the packages referred to by the methods and records
+// them for the imports.
+func (x *extraction) qualifier(p *types.Package) string {
+	if p == x.pkg.Types {
+		return ""
+	}
+	if x.imports == nil {
+		x.imports = make(map[string]string)
+	}
+	x.imports[p.Path()] = p.Name()
+	return p.Name()
+}
+
+// source returns the file declaring the interface.
+func (x *extraction) source(methods []*types.Func) ([]byte, error) {
+	var decl bytes.Buffer
+	fmt.Fprintf(&decl, "// %s is the subset of %s used by package %s.\n", x.name, x.qualified(), x.pkg.Name)
+	fmt.Fprintf(&decl, "type %s interface {\n", x.name)
+	for _, fn := range methods {
+		sig := types.TypeString(fn.Type(), x.qualifier)
+		fmt.Fprintf(&decl, "\t%s%s\n", fn.Name(), strings.TrimPrefix(sig, "func"))
+	}
+	fmt.Fprintf(&decl, "}\n")
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n\n", x.pkg.Name)
+	paths := make([]string, 0, len(x.imports))
+	for path := range x.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Fprintf(&buf, "import %q\n", path)
+	}
+	buf.WriteString("\n")
+	buf.Write(decl.Bytes())
+
+	name := filepath.Join(filepath.Dir(x.pkg.GoFiles[0]), strings.ToLower(x.name)+".go")
+	return imports.Process(name, buf.Bytes(), nil)
+}
+
+// qualified returns the name of the type as written in the package.
+func (x *extraction) qualified() string {
+	if x.typePath == x.pkg.PkgPath {
+		return x.typeName
+	}
+	return filepath.Base(x.typePath) + "." + x.typeName
+}
+
+// suggestions lists the parameters, fields and variables of the package
+// holding the concrete type that could hold the interface instead.
+func (x *extraction) suggestions() []string {
+	var out []string
+	suggest := func(pos token.Pos, what string, t types.Type) {
+		out = append(out, fmt.Sprintf("%s: %s has type %s; use %s instead",
+			x.pkg.Fset.Position(pos), what, types.TypeString(t, structutil.Qualifier(x.pkg.PkgPath)), x.name))
+	}
+	for _, f := range x.pkg.Syntax {
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Recv != nil && x.isType(x.pkg.TypesInfo.TypeOf(d.Recv.List[0].Type)) {
+					continue
+				}
+				for _, field := range d.Type.Params.List {
+					t := x.pkg.TypesInfo.TypeOf(field.Type)
+					if !x.isType(t) {
+						continue
+					}
+					for _, name := range field.Names {
+						suggest(name.Pos(), fmt.Sprintf("parameter %s of %s", name.Name, d.Name.Name), t)
+					}
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						st, ok := s.Type.(*ast.StructType)
+						if !ok {
+							continue
+						}
+						for _, field := range st.Fields.List {
+							t := x.pkg.TypesInfo.TypeOf(field.Type)
+							if !x.isType(t) {
+								continue
+							}
+							for _, name := range field.Names {
+								suggest(name.Pos(), fmt.Sprintf("field %s.%s", s.Name.Name, name.Name), t)
+							}
+						}
+					case *ast.ValueSpec:
+						for _, name := range s.Names {
+							if obj := x.pkg.TypesInfo.Defs[name]; obj != nil && x.isType(obj.Type()) {
+								suggest(name.Pos(), "variable "+name.Name, obj.Type())
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+const indexFile = "zz_generated_index.go"
+
+func init() {
+	commands = append(commands, &command{
+		name:  "index",
+		usage: "write " + indexFile + " listing the generated methods of each type",
+		run:   runIndex,
+	})
+}
+
+// indexEntry is the generated code of one type.
+type indexEntry struct {
+	Type    string
+	Tools   []string
+	Methods []string
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`
+// GeneratedIndexEntry describes the code generated for a type.
+type GeneratedIndexEntry struct {
+	Tools   []string // Generators that declared methods on the type.
+	Methods []string // Generated methods, sorted.
+}
+
+// Has reports whether method was generated for the type.
+func (e GeneratedIndexEntry) Has(method string) bool {
+	i := sort.SearchStrings(e.Methods, method)
+	return i < len(e.Methods) && e.Methods[i] == method
+}
+
+// GeneratedIndex maps the types of package {{.Package}} to the code
+// generated for them.
+var GeneratedIndex = map[string]GeneratedIndexEntry{
+{{- range .Entries}}
+	{{printf "%q" .Type}}: {
+		Tools:   []string{ {{- range $i, $t := .Tools}}{{if $i}}, {{end}}{{printf "%q" $t}}{{end -}} },
+		Methods: []string{ {{- range $i, $m := .Methods}}{{if $i}}, {{end}}{{printf "%q" $m}}{{end -}} },
+	},
+{{- end}}
+}
+`))
+
+func runIndex(args []string) {
+	fs := flag.NewFlagSet("index", flag.ExitOnError)
+	check := fs.Bool("check", false, "report stale or missing index files but do not write them")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of gentoolkit index:\n")
+		fmt.Fprintf(os.Stderr, "\tgentoolkit index [flags] [packages]\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	dirs, err := expandPatterns(patterns)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stale := false
+	for _, dir := range dirs {
+		src, err := buildIndex(dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if src == nil {
+			continue
+		}
+		outputName := filepath.Join(dir, indexFile)
+		if *check {
+			// The stamp hashes the invocation, so only the content is compared.
+			if old, err := ioutil.ReadFile(outputName); err != nil || !bytes.Equal(unstamped(old), unstamped(src)) {
+				fmt.Println(outputName)
+				stale = true
+			}
+			continue
+		}
+		if err := ioutil.WriteFile(outputName, src, 0644); err != nil {
+			log.Fatalf("writing output: %s", err)
+		}
+	}
+	if stale {
+		os.Exit(1)
+	}
+}
+
+// buildIndex returns the index file for the toolkit-generated files in dir,
+// or nil if there are none.
+func buildIndex(dir string) ([]byte, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		pkgName string
+		inputs  []string
+		entries = make(map[string]*indexEntry)
+		fset    = token.NewFileSet()
+	)
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || filepath.Base(file) == indexFile {
+			continue
+		}
+		h, ok, err := structutil.ReadHeader(file)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || h.Version == "" {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		pkgName = f.Name.Name
+		inputs = append(inputs, file)
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || !fn.Name.IsExported() {
+				continue
+			}
+			typeName := receiverName(fn.Recv.List[0].Type)
+			e, ok := entries[typeName]
+			if !ok {
+				e = &indexEntry{Type: typeName}
+				entries[typeName] = e
+			}
+			e.Tools = appendUnique(e.Tools, h.Tool)
+			e.Methods = appendUnique(e.Methods, fn.Name.Name)
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+
+	list := make([]*indexEntry, 0, len(entries))
+	for _, e := range entries {
+		sort.Strings(e.Tools)
+		sort.Strings(e.Methods)
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Type < list[j].Type })
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"gentoolkit index\"; DO NOT EDIT.\n")
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkgName)
+	err = indexTemplate.Execute(&buf, map[string]interface{}{
+		"Package": pkgName,
+		"Entries": list,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generating output: %w", err)
+	}
+	return structutil.FormatGenerated(filepath.Join(dir, indexFile), buf.Bytes(), inputs...)
+}
+
+// receiverName returns the type name of a method receiver, without pointer
+// and type parameters.
+func receiverName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// unstamped returns src without its toolkit stamp line.
+func unstamped(src []byte) []byte {
+	var out []byte
+	for _, line := range bytes.SplitAfter(src, []byte("\n")) {
+		if !bytes.HasPrefix(line, []byte("// gentoolkit:stamp ")) {
+			out = append(out, line...)
+		}
+	}
+	return out
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
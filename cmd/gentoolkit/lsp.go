@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:  "lsp",
+		usage: "offer generators as code actions to editors over the language server protocol",
+		run:   runLSP,
+	})
+}
+
+// lspCommand is a generator offered as a code action on struct types.
+type lspCommand struct {
+	Name  string // workspace/executeCommand name.
+	Title string
+	Tool  string
+	Args  []string
+}
+
+var lspCommands = []lspCommand{
+	{Name: "gentoolkit.generateGetters", Title: "Generate getters", Tool: "go-gen-getter"},
+	{Name: "gentoolkit.generateSetters", Title: "Generate setters", Tool: "go-gen-setter"},
+	{Name: "gentoolkit.generateBuilder", Title: "Generate builder (With methods)", Tool: "go-gen-wither"},
+	{Name: "gentoolkit.generateMerge", Title: "Generate Merge", Tool: "go-gen-merge"},
+	{Name: "gentoolkit.generateDefaults", Title: "Generate defaults", Tool: "go-gen-defaults"},
+}
+
+// commandArgs is the single argument of the executeCommand requests of the
+// code actions: the struct type and the document declaring it.
+type commandArgs struct {
+	URI  string `json:"uri"`
+	Type string `json:"type"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type codeActionParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Range struct {
+		Start lspPosition `json:"start"`
+		End   lspPosition `json:"end"`
+	} `json:"range"`
+}
+
+type executeCommandParams struct {
+	Command   string            `json:"command"`
+	Arguments []json.RawMessage `json:"arguments"`
+}
+
+type codeAction struct {
+	Title   string         `json:"title"`
+	Kind    string         `json:"kind"`
+	Command *commandInvoke `json:"command"`
+}
+
+// commandInvoke is the LSP Command a code action runs.
+type commandInvoke struct {
+	Title     string        `json:"title"`
+	Command   string        `json:"command"`
+	Arguments []commandArgs `json:"arguments"`
+}
+
+func runLSP(args []string) {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of gentoolkit lsp:\n")
+		fmt.Fprintf(os.Stderr, "\tgentoolkit lsp\n")
+		fmt.Fprintf(os.Stderr, "Speaks the language server protocol on stdin and stdout. Commands:\n")
+		for _, c := range lspCommands {
+			fmt.Fprintf(os.Stderr, "\t%-32s %s\n", c.Name, c.Tool)
+		}
+	}
+	fs.Parse(args)
+
+	s := &lspServer{
+		server: &server{workers: make(map[string]*worker)},
+		w:      bufio.NewWriter(os.Stdout),
+	}
+	err := s.serve(bufio.NewReader(os.Stdin))
+	s.server.close()
+	if err != nil && err != io.EOF {
+		log.Fatal(err)
+	}
+	if !s.shutdown {
+		os.Exit(1)
+	}
+}
+
+// lspServer answers the language server protocol requests of one editor.
+type lspServer struct {
+	*server
+
+	mu       sync.Mutex // Guards w.
+	w        *bufio.Writer
+	shutdown bool
+}
+
+// serve reads requests from r until the editor sends exit.
+func (s *lspServer) serve(r *bufio.Reader) error {
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			return err
+		}
+		var req rpcRequest
+		if err := json.Unmarshal(msg, &req); err != nil {
+			s.reply(&rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: err.Error()}})
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		result, err := s.lspCall(req.Method, req.Params)
+		if req.ID == nil {
+			// Notifications are not answered.
+			continue
+		}
+		resp := &rpcResponse{JSONRPC: "2.0", ID: req.ID}
+		switch {
+		case err != nil:
+			resp.Error = newRPCError(req.Method, err)
+		case result == nil:
+			resp.Result = json.RawMessage("null")
+		default:
+			resp.Result = result
+		}
+		s.reply(resp)
+	}
+}
+
+func (s *lspServer) lspCall(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "initialize":
+		names := make([]string, len(lspCommands))
+		for i, c := range lspCommands {
+			names[i] = c.Name
+		}
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"codeActionProvider": map[string]interface{}{
+					"codeActionKinds": []string{"source"},
+				},
+				"executeCommandProvider": map[string]interface{}{
+					"commands": names,
+				},
+			},
+			"serverInfo": map[string]interface{}{"name": "gentoolkit"},
+		}, nil
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/codeAction":
+		var p codeActionParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return codeActions(&p)
+	case "workspace/executeCommand":
+		var p executeCommandParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.executeCommand(&p)
+	}
+	if strings.HasPrefix(method, "textDocument/did") || strings.HasPrefix(method, "workspace/did") {
+		return nil, nil
+	}
+	return nil, errMethodNotFound
+}
+
+// codeActions offers the generators for the struct type declared at the
+// start of the range.
+func codeActions(p *codeActionParams) ([]codeAction, error) {
+	file, err := uriPath(p.TextDocument.URI)
+	if err != nil {
+		return nil, &paramsError{err}
+	}
+	actions := []codeAction{}
+	if !strings.HasSuffix(file, ".go") {
+		return actions, nil
+	}
+	typeName, err := structAt(file, p.Range.Start.Line+1)
+	if err != nil || typeName == "" {
+		// Files that do not parse get no actions.
+		return actions, nil
+	}
+	for _, c := range lspCommands {
+		title := fmt.Sprintf("%s for %s", c.Title, typeName)
+		actions = append(actions, codeAction{
+			Title: title,
+			Kind:  "source",
+			Command: &commandInvoke{
+				Title:     title,
+				Command:   c.Name,
+				Arguments: []commandArgs{{URI: p.TextDocument.URI, Type: typeName}},
+			},
+		})
+	}
+	return actions, nil
+}
+
+// structAt returns the name of the struct type whose declaration spans the
+// 1-based line of file, or "".
+func structAt(file string, line int) (string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return "", err
+	}
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if _, ok := ts.Type.(*ast.StructType); !ok {
+				continue
+			}
+			start, end := ts.Pos(), ts.End()
+			if len(gd.Specs) == 1 {
+				start = gd.Pos()
+			}
+			if fset.Position(start).Line <= line && line <= fset.Position(end).Line {
+				return ts.Name.Name, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// executeCommand runs the generator of a code action.
+func (s *lspServer) executeCommand(p *executeCommandParams) (interface{}, error) {
+	var cmd *lspCommand
+	for i := range lspCommands {
+		if lspCommands[i].Name == p.Command {
+			cmd = &lspCommands[i]
+		}
+	}
+	if cmd == nil {
+		return nil, &paramsError{fmt.Errorf("unknown command %q", p.Command)}
+	}
+	if len(p.Arguments) != 1 {
+		return nil, &paramsError{errors.New("expected one argument")}
+	}
+	var a commandArgs
+	if err := json.Unmarshal(p.Arguments[0], &a); err != nil {
+		return nil, &paramsError{err}
+	}
+	file, err := uriPath(a.URI)
+	if err != nil {
+		return nil, &paramsError{err}
+	}
+	return s.generate(&generateParams{
+		Tool:  cmd.Tool,
+		Args:  cmd.Args,
+		Dir:   filepath.Dir(file),
+		Types: []string{a.Type},
+	})
+}
+
+// uriPath returns the file path of a file:// URI.
+func uriPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("%s: not a file URI", uri)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+// reply writes resp with its base protocol header.
+func (s *lspServer) reply(resp *rpcResponse) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("encoding response: %s", err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.w, "Content-Length: %d\r\n\r\n", len(data))
+	s.w.Write(data)
+	s.w.Flush()
+}
+
+// readMessage reads the content of one base protocol message.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid header %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(line[:i]), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(line[i+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid header %q", line)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("missing Content-Length header")
+	}
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string)
+}
+
+var commands []*command
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage of gentoolkit:\n")
+	fmt.Fprintf(w, "\tgentoolkit <command> [flags] [packages]\n")
+	fmt.Fprintf(w, "Commands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "\t%-10s %s\n", c.name, c.usage)
+	}
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gentoolkit: ")
+	flag.Usage = func() { usage(os.Stderr) }
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == flag.Arg(0) {
+			c.run(flag.Args()[1:])
+			return
+		}
+	}
+	log.Printf("unknown command %q", flag.Arg(0))
+	flag.Usage()
+	os.Exit(2)
+}
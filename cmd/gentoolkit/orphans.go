@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// orphanResolver handles the generated files of regen whose recorded type
+// no longer exists, typically because it was renamed.
+type orphanResolver struct {
+	mode   string // prompt, delete or keep.
+	dryRun bool
+	in     *bufio.Reader
+}
+
+// resolve remaps or deletes the orphaned files of invocations and marks
+// the invocations that can no longer run as skipped.
+func (r *orphanResolver) resolve(invocations []*invocation) error {
+	declared := make(map[string]map[string]bool)
+	recorded := make(map[string]map[string]bool)
+	for _, inv := range invocations {
+		if recorded[inv.dir] == nil {
+			recorded[inv.dir] = make(map[string]bool)
+		}
+		for _, id := range inv.types {
+			if id != "" {
+				recorded[inv.dir][typeIDName(id)] = true
+			}
+		}
+	}
+
+	for _, inv := range invocations {
+		if declared[inv.dir] == nil {
+			types, err := declaredTypes(inv.dir)
+			if err != nil {
+				return err
+			}
+			declared[inv.dir] = types
+		}
+		for i, file := range inv.files {
+			if inv.types[i] == "" {
+				continue
+			}
+			old := typeIDName(inv.types[i])
+			if declared[inv.dir][old] {
+				continue
+			}
+			if err := r.resolveFile(inv, file, old, candidates(declared[inv.dir], recorded[inv.dir])); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveFile handles file, generated by inv for the missing type old.
+func (r *orphanResolver) resolveFile(inv *invocation, file, old string, candidates []string) error {
+	action := r.mode
+	var renamed string
+	if action == "prompt" {
+		fmt.Fprintf(os.Stderr, "%s was generated for %s, which no longer exists.\n", file, old)
+		if len(candidates) > 0 {
+			fmt.Fprintf(os.Stderr, "Types without generated code: %s\n", strings.Join(candidates, ", "))
+		}
+		fmt.Fprintf(os.Stderr, "New type name (empty deletes the file, - keeps it): ")
+		line, err := r.in.ReadString('\n')
+		if err != nil && line == "" {
+			// Without an answer, for example from /dev/null, keep it.
+			fmt.Fprintln(os.Stderr)
+			line = "-"
+		}
+		switch answer := strings.TrimSpace(line); answer {
+		case "":
+			action = "delete"
+		case "-":
+			action = "keep"
+		default:
+			action, renamed = "rename", answer
+		}
+	}
+
+	switch action {
+	case "keep":
+		log.Printf("%s: type %s no longer exists; not regenerating %s", file, old, inv.header.Tool)
+		inv.skip = true
+		return nil
+	case "rename":
+		if !inv.editTypes(func(types []string) []string {
+			for i, t := range types {
+				if t == old {
+					types[i] = renamed
+				}
+			}
+			return types
+		}) {
+			log.Printf("%s: no -type argument naming %s; not regenerating %s", file, old, inv.header.Tool)
+			inv.skip = true
+			return nil
+		}
+	case "delete":
+		keep := inv.editTypes(func(types []string) []string {
+			var out []string
+			for _, t := range types {
+				if t != old {
+					out = append(out, t)
+				}
+			}
+			return out
+		})
+		if !keep {
+			inv.skip = true
+		}
+	}
+	return r.remove(file)
+}
+
+// remove deletes an orphaned file and its source map.
+func (r *orphanResolver) remove(file string) error {
+	for _, name := range []string{file, file + ".map.json"} {
+		if _, err := os.Stat(name); err != nil {
+			continue
+		}
+		if r.dryRun {
+			fmt.Printf("rm %s\n", name)
+			continue
+		}
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// editTypes rewrites the list of the -type argument of the invocation with
+// edit. It reports false if there is no such argument or no type is left.
+func (inv *invocation) editTypes(edit func(types []string) []string) bool {
+	args := append([]string(nil), inv.header.Args...)
+	for i, arg := range args {
+		// The value is either the next argument or follows "=".
+		j, prefix := i+1, ""
+		switch name := strings.TrimLeft(arg, "-"); {
+		case !strings.HasPrefix(arg, "-"):
+			continue
+		case name == "type" && j < len(args):
+		case strings.HasPrefix(name, "type="):
+			j, prefix = i, arg[:strings.Index(arg, "=")+1]
+		default:
+			continue
+		}
+		types := edit(strings.Split(strings.TrimPrefix(args[j], prefix), ","))
+		if len(types) == 0 {
+			return false
+		}
+		args[j] = prefix + strings.Join(types, ",")
+		inv.header.Args = args
+		return true
+	}
+	return false
+}
+
+// typeIDName returns the type name of a recorded type identity.
+func typeIDName(id string) string {
+	return id[strings.LastIndex(id, ".")+1:]
+}
+
+// declaredTypes returns the names of the types declared in the
+// hand-written files in dir.
+func declaredTypes(dir string) (map[string]bool, error) {
+	files, err := sourceFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]bool)
+	fset := token.NewFileSet()
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+				for _, spec := range gd.Specs {
+					types[spec.(*ast.TypeSpec).Name.Name] = true
+				}
+			}
+		}
+	}
+	return types, nil
+}
+
+// candidates returns the declared types without any generated file, the
+// likely new names of renamed types.
+func candidates(declared, recorded map[string]bool) []string {
+	var names []string
+	for name := range declared {
+		if !recorded[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
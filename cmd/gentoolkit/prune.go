@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:  "prune",
+		usage: "delete toolkit-generated files whose type or go:generate directive is gone",
+		run:   runPrune,
+	})
+}
+
+func runPrune(args []string) {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "print the files but do not delete them")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of gentoolkit prune:\n")
+		fmt.Fprintf(os.Stderr, "\tgentoolkit prune [flags] [packages]\n")
+		fmt.Fprintf(os.Stderr, "A generated file is pruned if the type it records no longer exists, or if\n")
+		fmt.Fprintf(os.Stderr, "its package has go:generate directives but none of them produces it anymore.\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	invocations, err := findInvocations(patterns)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r := &orphanResolver{dryRun: *dryRun}
+	declared := make(map[string]map[string]bool)
+	directives := make(map[string][]generateDirective)
+	for _, inv := range invocations {
+		if declared[inv.dir] == nil {
+			if declared[inv.dir], err = declaredTypes(inv.dir); err != nil {
+				log.Fatal(err)
+			}
+			if directives[inv.dir], err = generateDirectives(inv.dir); err != nil {
+				log.Fatal(err)
+			}
+		}
+		for i, file := range inv.files {
+			name := ""
+			if inv.types[i] != "" {
+				name = typeIDName(inv.types[i])
+			}
+			reason := ""
+			switch {
+			case name != "" && !declared[inv.dir][name]:
+				reason = fmt.Sprintf("type %s no longer exists", name)
+			case len(directives[inv.dir]) > 0 && !generatedBy(directives[inv.dir], inv.header.Tool, name):
+				reason = fmt.Sprintf("no go:generate directive runs %s", inv.header.Tool)
+				if name != "" {
+					reason += " for " + name
+				}
+			default:
+				continue
+			}
+			if !*dryRun {
+				fmt.Printf("rm %s: %s\n", file, reason)
+			}
+			if err := r.remove(file); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
+
+// generateDirective is a go:generate line of a package.
+type generateDirective struct {
+	tool string
+	args []string
+}
+
+// generateDirectives returns the go:generate directives of the hand-written
+// files in dir.
+func generateDirectives(dir string) ([]generateDirective, error) {
+	files, err := sourceFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	var directives []generateDirective
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			line := strings.TrimSpace(s.Text())
+			if !strings.HasPrefix(line, "//go:generate ") {
+				continue
+			}
+			if d, ok := parseGenerate(strings.Fields(strings.TrimPrefix(line, "//go:generate "))); ok {
+				directives = append(directives, d)
+			}
+		}
+		err = s.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return directives, nil
+}
+
+// parseGenerate splits the words of a go:generate directive into the tool
+// and its arguments, looking through "go run" of a package path.
+func parseGenerate(words []string) (generateDirective, bool) {
+	if len(words) >= 3 && words[0] == "go" && words[1] == "run" {
+		words = words[2:]
+		for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+			words = words[1:]
+		}
+	}
+	if len(words) == 0 {
+		return generateDirective{}, false
+	}
+	tool := words[0]
+	if i := strings.LastIndex(tool, "@"); i >= 0 {
+		tool = tool[:i]
+	}
+	if i := strings.LastIndex(tool, "/"); i >= 0 {
+		tool = tool[i+1:]
+	}
+	return generateDirective{tool: tool, args: words[1:]}, true
+}
+
+// generatedBy reports whether a directive runs tool and, for a file of a
+// single type, whether the directive's -type argument, if any, names it.
+func generatedBy(directives []generateDirective, tool, typeName string) bool {
+	for _, d := range directives {
+		if d.tool != tool {
+			continue
+		}
+		types, ok := typeArg(d.args)
+		if typeName == "" || !ok {
+			return true
+		}
+		for _, t := range types {
+			if t == typeName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeArg returns the list of the -type argument of args.
+func typeArg(args []string) ([]string, bool) {
+	for i, arg := range args {
+		switch name := strings.TrimLeft(arg, "-"); {
+		case !strings.HasPrefix(arg, "-"):
+		case name == "type" && i+1 < len(args):
+			return strings.Split(strings.Trim(args[i+1], `"`), ","), true
+		case strings.HasPrefix(name, "type="):
+			return strings.Split(strings.Trim(strings.TrimPrefix(name, "type="), `"`), ","), true
+		}
+	}
+	return nil, false
+}
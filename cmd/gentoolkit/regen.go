@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+const toolkitModule = "github.com/jakoblorz/go-gentoolkit"
+
+func init() {
+	commands = append(commands, &command{
+		name:  "regen",
+		usage: "re-run the generators recorded in toolkit-generated files",
+		run:   runRegen,
+	})
+}
+
+// invocation is a generator run reconstructed from a generated file header.
+type invocation struct {
+	dir    string
+	header *structutil.Header
+	files  []string
+	types  []string // Type identity recorded by each file; empty if none.
+	skip   bool     // Whether the run would fail for a missing type.
+}
+
+func (i *invocation) key() string {
+	return i.dir + "\x00" + i.header.Tool + "\x00" + strings.Join(i.header.Args, "\x00")
+}
+
+func (i *invocation) command() *exec.Cmd {
+	cmd := toolCommand(i.header.Tool, i.header.Args...)
+	cmd.Dir = i.dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+// toolCommand returns the command running tool with args. The toolkit's own
+// generators are run from the module so that they need not be installed.
+func toolCommand(tool string, args ...string) *exec.Cmd {
+	if strings.HasPrefix(tool, "go-gen-") || tool == "gentoolkit" {
+		return exec.Command("go", append([]string{"run", toolkitModule + "/cmd/" + tool}, args...)...)
+	}
+	return exec.Command(tool, args...)
+}
+
+func runRegen(args []string) {
+	fs := flag.NewFlagSet("regen", flag.ExitOnError)
+	dryRun := fs.Bool("n", false, "print the commands but do not run them")
+	verbose := fs.Bool("v", false, "print the commands as they are run")
+	orphans := fs.String("orphans", "", "handle files generated for types that no longer exist: prompt, delete or keep; default prompt on a terminal and keep otherwise")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of gentoolkit regen:\n")
+		fmt.Fprintf(os.Stderr, "\tgentoolkit regen [flags] [packages]\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	invocations, err := findInvocations(patterns)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mode := *orphans
+	if mode == "" {
+		mode = "keep"
+		if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+			mode = "prompt"
+		}
+	}
+	switch mode {
+	case "prompt", "delete", "keep":
+	default:
+		log.Fatalf("invalid -orphans %q", mode)
+	}
+	r := &orphanResolver{mode: mode, dryRun: *dryRun, in: bufio.NewReader(os.Stdin)}
+	if err := r.resolve(invocations); err != nil {
+		log.Fatal(err)
+	}
+
+	failed := false
+	for _, inv := range invocations {
+		if inv.skip {
+			continue
+		}
+		cmd := inv.command()
+		if *dryRun || *verbose {
+			fmt.Printf("cd %s && %s\n", inv.dir, structutil.JoinArgs(cmd.Args))
+		}
+		if *dryRun {
+			continue
+		}
+		if err := cmd.Run(); err != nil {
+			log.Printf("regenerating %s: %s", strings.Join(inv.files, ", "), err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// findInvocations returns the distinct generator invocations recorded in the
+// toolkit-generated files matched by patterns, of any of the extensions the
+// generators stamp.
+func findInvocations(patterns []string) ([]*invocation, error) {
+	dirs, err := expandPatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		invocations []*invocation
+		seen        = make(map[string]*invocation)
+	)
+	for _, dir := range dirs {
+		var files []string
+		for _, ext := range structutil.StampedExtensions {
+			matches, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+		for _, file := range files {
+			h, ok, err := structutil.ReadHeader(file)
+			if err != nil {
+				return nil, err
+			}
+			// Only files stamped by the toolkit are considered; other
+			// generators share the same header convention.
+			if !ok || h.Version == "" {
+				continue
+			}
+			inv := &invocation{dir: dir, header: h}
+			if prev, ok := seen[inv.key()]; ok {
+				prev.files = append(prev.files, file)
+				prev.types = append(prev.types, h.Type)
+				continue
+			}
+			inv.files = []string{file}
+			inv.types = []string{h.Type}
+			seen[inv.key()] = inv
+			invocations = append(invocations, inv)
+		}
+	}
+	return invocations, nil
+}
+
+// expandPatterns resolves directory patterns, where a trailing "/..." matches
+// the directory and all its subdirectories.
+func expandPatterns(patterns []string) ([]string, error) {
+	var (
+		dirs []string
+		seen = make(map[string]bool)
+	)
+	add := func(dir string) {
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, pattern := range patterns {
+		root := strings.TrimSuffix(pattern, "...")
+		if root == pattern {
+			add(filepath.Clean(pattern))
+			continue
+		}
+		root = filepath.Clean(root)
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				return nil
+			}
+			if path != root && structutil.IgnoredDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			add(path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
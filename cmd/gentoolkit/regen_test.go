@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindInvocations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a_getter.go": "// Code generated by \"go-gen-getter -type=A \"-then=go-gen-x -type=A\"\"; DO NOT EDIT.\n" +
+			"// gentoolkit:stamp version=v0.1.0 input=sha256:00 type=example.com/p.A\n\npackage p\n",
+		"p_seed.sql": "-- Code generated by \"go-gen-seed -format=sql\"; DO NOT EDIT.\n" +
+			"-- gentoolkit:stamp version=v0.1.0 input=sha256:00\n\nSELECT 1;\n",
+		"p_features.md": "<!-- Code generated by \"go-gen-features\"; DO NOT EDIT. -->\n" +
+			"<!-- gentoolkit:stamp version=v0.1.0 input=sha256:00 -->\n\n## F\n",
+		// Unstamped outputs belong to other generators.
+		"p_string.go": "// Code generated by \"stringer -type=P\"; DO NOT EDIT.\n\npackage p\n",
+		"p.go":        "package p\n",
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	invocations, err := findInvocations([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string][]string)
+	for _, inv := range invocations {
+		got[inv.header.Tool] = inv.header.Args
+	}
+	want := map[string][]string{
+		"go-gen-getter":   {"-type=A", "-then=go-gen-x -type=A"},
+		"go-gen-seed":     {"-format=sql"},
+		"go-gen-features": {},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findInvocations found %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,485 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"golang.org/x/mod/semver"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:  "serve",
+		usage: "answer generation requests from editors over a unix socket",
+		run:   runServe,
+	})
+}
+
+// rpcRequest is a JSON-RPC 2.0 request. The daemon reads one per line.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC 2.0 error codes.
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeFailed         = -32000
+)
+
+// errMethodNotFound is returned by server.call for unknown methods.
+var errMethodNotFound = errors.New("method not found")
+
+// generateParams are the parameters of generate-for-type. Args are passed
+// to the generator, which is started once per tool, args and module and
+// then kept running.
+type generateParams struct {
+	Tool     string   `json:"tool"`
+	Args     []string `json:"args,omitempty"`
+	Dir      string   `json:"dir"`
+	Types    []string `json:"types,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+	DryRun   bool     `json:"dryRun,omitempty"`
+	// Overlay holds the unsaved contents of files by path.
+	Overlay map[string]string `json:"overlay,omitempty"`
+}
+
+type listTypesParams struct {
+	Dir string `json:"dir"`
+}
+
+type listTypesResult struct {
+	Package string   `json:"package"`
+	Types   []string `json:"types"`
+}
+
+type checkStaleParams struct {
+	Patterns []string `json:"patterns"`
+}
+
+type staleFile struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+}
+
+type checkStaleResult struct {
+	Stale []staleFile `json:"stale"`
+}
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	socket := fs.String("socket", filepath.Join(os.TempDir(), "gentoolkit.sock"), "path of the unix socket to listen on")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of gentoolkit serve:\n")
+		fmt.Fprintf(os.Stderr, "\tgentoolkit serve [flags]\n")
+		fmt.Fprintf(os.Stderr, "Methods, as JSON-RPC 2.0 requests one per line:\n")
+		fmt.Fprintf(os.Stderr, "\tgenerate-for-type {tool, args, dir, types, patterns, dryRun, overlay}\n")
+		fmt.Fprintf(os.Stderr, "\tlist-types        {dir}\n")
+		fmt.Fprintf(os.Stderr, "\tcheck-stale       {patterns}\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	l, err := listenUnix(*socket)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := &server{workers: make(map[string]*worker)}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		l.Close()
+	}()
+
+	log.Printf("listening on %s", *socket)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			break
+		}
+		go s.serveConn(conn)
+	}
+	s.close()
+}
+
+// listenUnix listens on the unix socket at path, replacing a socket left
+// behind by a daemon that is no longer running.
+func listenUnix(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s: a daemon is already listening", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// server dispatches requests and keeps the generator workers.
+type server struct {
+	mu      sync.Mutex
+	workers map[string]*worker
+}
+
+// serveConn answers the requests on conn in order until it is closed.
+func (s *server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			if err := enc.Encode(s.handle(line)); err != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handle answers a single encoded request.
+func (s *server) handle(line []byte) *rpcResponse {
+	resp := &rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null")}
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		resp.Error = &rpcError{Code: codeParseError, Message: err.Error()}
+		return resp
+	}
+	if req.ID != nil {
+		resp.ID = req.ID
+	}
+	result, err := s.call(req.Method, req.Params)
+	if err != nil {
+		resp.Error = newRPCError(req.Method, err)
+	} else {
+		resp.Result = result
+	}
+	return resp
+}
+
+// newRPCError returns the error response for the failure of method.
+func newRPCError(method string, err error) *rpcError {
+	var perr *paramsError
+	switch {
+	case err == errMethodNotFound:
+		return &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", method)}
+	case errors.As(err, &perr):
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return &rpcError{Code: codeFailed, Message: err.Error()}
+}
+
+// paramsError reports parameters that could not be decoded.
+type paramsError struct {
+	err error
+}
+
+func (e *paramsError) Error() string {
+	return "invalid params: " + e.err.Error()
+}
+
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return &paramsError{errors.New("missing")}
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &paramsError{err}
+	}
+	return nil
+}
+
+// call runs method with params.
+func (s *server) call(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "generate-for-type":
+		var p generateParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.generate(&p)
+	case "list-types":
+		var p listTypesParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return listTypes(p.Dir)
+	case "check-stale":
+		var p checkStaleParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return checkStale(p.Patterns)
+	}
+	return nil, errMethodNotFound
+}
+
+// generate runs the generator named by p in its worker.
+func (s *server) generate(p *generateParams) (*structutil.ServeResponse, error) {
+	if p.Tool == "" || p.Dir == "" {
+		return nil, &paramsError{errors.New("tool and dir must be set")}
+	}
+	dir, err := filepath.Abs(p.Dir)
+	if err != nil {
+		return nil, err
+	}
+	root := moduleRoot(dir)
+	key := strings.Join(append([]string{root, p.Tool}, p.Args...), "\x00")
+
+	s.mu.Lock()
+	w, ok := s.workers[key]
+	if !ok {
+		w, err = startWorker(root, p.Tool, p.Args)
+		if err != nil {
+			s.mu.Unlock()
+			return nil, err
+		}
+		s.workers[key] = w
+	}
+	s.mu.Unlock()
+
+	resp, err := w.run(&structutil.ServeRequest{
+		Dir:      dir,
+		Patterns: p.Patterns,
+		Types:    p.Types,
+		DryRun:   p.DryRun,
+		Overlay:  p.Overlay,
+	})
+	if err != nil {
+		// The worker is unusable; the next request starts a new one.
+		s.mu.Lock()
+		if s.workers[key] == w {
+			delete(s.workers, key)
+		}
+		s.mu.Unlock()
+		w.stop()
+		return nil, fmt.Errorf("%s: %s", p.Tool, err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// close stops all workers.
+func (s *server) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, w := range s.workers {
+		w.stop()
+		delete(s.workers, key)
+	}
+}
+
+// worker is a generator running with -serve, answering one request at a
+// time.
+type worker struct {
+	mu    sync.Mutex
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	enc   *json.Encoder
+	dec   *json.Decoder
+}
+
+// startWorker starts tool with args in dir.
+func startWorker(dir, tool string, args []string) (*worker, error) {
+	cmd := toolCommand(tool, append([]string{"-serve"}, args...)...)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %s", tool, err)
+	}
+	return &worker{
+		cmd:   cmd,
+		stdin: stdin,
+		enc:   json.NewEncoder(stdin),
+		dec:   json.NewDecoder(stdout),
+	}, nil
+}
+
+func (w *worker) run(req *structutil.ServeRequest) (*structutil.ServeResponse, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.enc.Encode(req); err != nil {
+		return nil, err
+	}
+	var resp structutil.ServeResponse
+	if err := w.dec.Decode(&resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// stop closes the input of the worker, which makes it exit, and waits for
+// it.
+func (w *worker) stop() {
+	w.stdin.Close()
+	w.cmd.Wait()
+}
+
+// moduleRoot returns the nearest directory at or above dir containing a
+// go.mod file, or dir if there is none.
+func moduleRoot(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// listTypes returns the struct types declared in the hand-written files of
+// the package in dir, in source order.
+func listTypes(dir string) (*listTypesResult, error) {
+	files, err := sourceFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	res := &listTypesResult{Types: []string{}}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		res.Package = f.Name.Name
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					if _, ok := ts.Type.(*ast.StructType); ok {
+						res.Types = append(res.Types, ts.Name.Name)
+					}
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// sourceFiles returns the non-test Go files in dir without a
+// generated-code header, sorted.
+func sourceFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var sources []string
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		_, generated, err := structutil.ReadHeader(file)
+		if err != nil {
+			return nil, err
+		}
+		if !generated {
+			sources = append(sources, file)
+		}
+	}
+	sort.Strings(sources)
+	return sources, nil
+}
+
+// checkStale returns the toolkit-generated files matched by patterns that
+// were stamped by an older toolkit version or are older than a
+// hand-written file of their package.
+func checkStale(patterns []string) (*checkStaleResult, error) {
+	invocations, err := findInvocations(patterns)
+	if err != nil {
+		return nil, err
+	}
+	res := &checkStaleResult{Stale: []staleFile{}}
+	newest := make(map[string]time.Time)
+	for _, inv := range invocations {
+		if _, ok := newest[inv.dir]; !ok {
+			sources, err := sourceFiles(inv.dir)
+			if err != nil {
+				return nil, err
+			}
+			var t time.Time
+			for _, source := range sources {
+				if fi, err := os.Stat(source); err == nil && fi.ModTime().After(t) {
+					t = fi.ModTime()
+				}
+			}
+			newest[inv.dir] = t
+		}
+		for _, file := range inv.files {
+			if reason := staleReason(file, inv.header, newest[inv.dir]); reason != "" {
+				res.Stale = append(res.Stale, staleFile{File: file, Reason: reason})
+			}
+		}
+	}
+	return res, nil
+}
+
+func staleReason(file string, h *structutil.Header, newest time.Time) string {
+	if semver.Compare(h.Version, structutil.Version) < 0 {
+		return fmt.Sprintf("generated by toolkit %s; current version is %s", h.Version, structutil.Version)
+	}
+	fi, err := os.Stat(file)
+	if err != nil {
+		return err.Error()
+	}
+	if newest.After(fi.ModTime()) {
+		return "sources changed since it was generated"
+	}
+	return ""
+}
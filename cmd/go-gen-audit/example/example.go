@@ -0,0 +1,22 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-audit -type=Customer,Order
+
+type Customer struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	Email     string            `json:"email" pii:"export,erase"`
+	Password  string            `json:"-" sensitive:"true"`
+	Country   string            `json:"country" sensitive:"false"`
+	Tags      []string          `json:"tags"`
+	Notes     map[string]string `json:"notes" audit:"mask"`
+	UpdatedAt time.Time         `json:"updated_at" audit:"-"`
+}
+
+type Order struct {
+	ID     string    `json:"id"`
+	Total  int64     `json:"total"`
+	Placed time.Time `json:"placed"`
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"go/types"
+	"os"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+var mask = flag.String("mask", "****", "replacement of the values of sensitive fields in audit records")
+
+type auditField struct {
+	Name    string
+	Key     string
+	Changed string // Test for a change of the field between old and new.
+	Masked  bool
+}
+
+type auditStruct struct {
+	Name   string
+	Fields []auditField
+}
+
+var auditTemplate = template.Must(template.New("audit").Parse(`
+// AuditEntry records the field-level changes between two versions of a
+// value.
+type AuditEntry struct {
+	Type    string        ` + "`" + `json:"type"` + "`" + `
+	Changes []AuditChange ` + "`" + `json:"changes"` + "`" + `
+}
+
+// AuditChange is the change of a single field. The values of fields tagged
+// sensitive or pii are replaced by {{printf "%q" .Mask}} and marked Redacted.
+type AuditChange struct {
+	Field    string      ` + "`" + `json:"field"` + "`" + `
+	Old      interface{} ` + "`" + `json:"old"` + "`" + `
+	New      interface{} ` + "`" + `json:"new"` + "`" + `
+	Redacted bool        ` + "`" + `json:"redacted,omitempty"` + "`" + `
+}
+{{range .Structs}}
+// {{.Name}}AuditRecord returns the changes from old to new, with sensitive
+// values masked.
+func {{.Name}}AuditRecord(old, new {{.Name}}) AuditEntry {
+	entry := AuditEntry{Type: {{printf "%q" .Name}}}
+{{- range .Fields}}
+	if {{.Changed}} {
+	{{- if .Masked}}
+		entry.Changes = append(entry.Changes, AuditChange{Field: {{printf "%q" .Key}}, Old: {{printf "%q" $.Mask}}, New: {{printf "%q" $.Mask}}, Redacted: true})
+	{{- else}}
+		entry.Changes = append(entry.Changes, AuditChange{Field: {{printf "%q" .Key}}, Old: old.{{.Name}}, New: new.{{.Name}}})
+	{{- end}}
+	}
+{{- end}}
+	return entry
+}
+{{end}}`))
+
+// changedTest returns a Go expression reporting whether the field name, of
+// type t, differs between old and new.
+func changedTest(name string, t types.Type) string {
+	if types.Comparable(t) {
+		if hasEqual(t) {
+			return "!old." + name + ".Equal(new." + name + ")"
+		}
+		return "old." + name + " != new." + name
+	}
+	return "!reflect.DeepEqual(old." + name + ", new." + name + ")"
+}
+
+// hasEqual reports whether t has a method Equal(t) bool, as time.Time does.
+func hasEqual(t types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(t, false, nil, "Equal")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 1 || sig.Results().Len() != 1 || !types.Identical(sig.Params().At(0).Type(), t) {
+		return false
+	}
+	b, ok := sig.Results().At(0).Type().(*types.Basic)
+	return ok && b.Kind() == types.Bool
+}
+
+// masked reports whether the values of field must not appear in audit
+// records: it is tagged sensitive, as for go-gen-scrub, or pii, as for
+// go-gen-pii, unless tagged audit:"plain", or it is tagged audit:"mask".
+func masked(field structutil.StructFieldInfo) bool {
+	switch field.Tag("audit") {
+	case "plain":
+		return false
+	case "mask":
+		return true
+	}
+	if mode := field.Tag("sensitive"); mode != "" && mode != "false" {
+		return true
+	}
+	return field.Tag("pii") != ""
+}
+
+func generateAudit(pkg *structutil.Package, infos []*structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-audit %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", pkg.GetName())
+
+	var structs []auditStruct
+	for _, info := range infos {
+		s := auditStruct{Name: info.Name}
+		for _, field := range info.Fields {
+			if field.Name == "_" || field.Tag("audit") == "-" {
+				continue
+			}
+			if field.GoType == nil {
+				p.Fatalf("%s: no type information for %s", field.Pos, field.Name)
+			}
+			key, ok := info.EffectiveName(field, "json")
+			if !ok {
+				key = field.Name
+			}
+			s.Fields = append(s.Fields, auditField{
+				Name:    field.Name,
+				Key:     key,
+				Changed: changedTest(field.Name, field.GoType),
+				Masked:  masked(field),
+			})
+		}
+		structs = append(structs, s)
+	}
+
+	auditTemplate.Execute(p, map[string]interface{}{
+		"Structs": structs,
+		"Mask":    *mask,
+	})
+}
+
+var generator = structutil.NewForPackageGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-audit",
+	FileSuffix:  "audit",
+	GoFmtOutput: true,
+}, generateAudit)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
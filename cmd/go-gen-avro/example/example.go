@@ -0,0 +1,30 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-avro -type=Order
+
+type Order struct {
+	ID        string `avro:"id"`
+	Reference UUID   `genmap:"avro=string"`
+	Customer  Customer
+	Items     []LineItem
+	Discount  *float64
+	Metadata  map[string]string
+	CreatedAt time.Time
+	internal  int
+}
+
+// UUID is stored as its canonical string in Avro.
+type UUID [16]byte
+
+type Customer struct {
+	Name  string
+	Email string `avro:"email"`
+}
+
+type LineItem struct {
+	SKU      string
+	Quantity int32
+	Price    float64
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/typemap"
+)
+
+var namespace = flag.String("namespace", "", "namespace of the records; default the package name")
+
+type record struct {
+	Type      string  `json:"type"`
+	Name      string  `json:"name"`
+	Namespace string  `json:"namespace,omitempty"`
+	Fields    []field `json:"fields"`
+}
+
+type field struct {
+	Name    string          `json:"name"`
+	Type    interface{}     `json:"type"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+type array struct {
+	Type  string      `json:"type"`
+	Items interface{} `json:"items"`
+}
+
+type avroMap struct {
+	Type   string      `json:"type"`
+	Values interface{} `json:"values"`
+}
+
+type logical struct {
+	Type        string `json:"type"`
+	LogicalType string `json:"logicalType"`
+}
+
+// schema builds the Avro schema of a struct, defining each named record
+// once and referring to it by name afterwards.
+type schema struct {
+	defined map[string]bool
+}
+
+// avroType returns the Avro type of Go values of type t, unless overrides
+// set it. Nullable types are unions with null; overrides look through them
+// but not into other types.
+func (s *schema) avroType(t types.Type, overrides typemap.Overrides) (interface{}, error) {
+	if strategy, elem := structutil.Nullability(t); strategy != structutil.NotNull {
+		elem, err := s.avroType(elem, overrides)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"null", elem}, nil
+	}
+	if m, ok := overrides.Lookup(t, typemap.Avro); ok {
+		var typ interface{} = m.Type
+		if m.Format != "" {
+			typ = logical{Type: m.Type, LogicalType: m.Format}
+		}
+		if m.Null {
+			return []interface{}{"null", typ}, nil
+		}
+		return typ, nil
+	}
+	if named, ok := t.(*types.Named); ok {
+		if st, ok := named.Underlying().(*types.Struct); ok {
+			return s.record(named.Obj().Name(), st)
+		}
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		items, err := s.avroType(u.Elem(), nil)
+		if err != nil {
+			return nil, err
+		}
+		return array{Type: "array", Items: items}, nil
+	case *types.Map:
+		if b, ok := u.Key().Underlying().(*types.Basic); !ok || b.Info()&types.IsString == 0 {
+			return nil, fmt.Errorf("Avro maps have string keys, not %s", u.Key())
+		}
+		values, err := s.avroType(u.Elem(), nil)
+		if err != nil {
+			return nil, err
+		}
+		return avroMap{Type: "map", Values: values}, nil
+	}
+	return nil, fmt.Errorf("type %s has no Avro type; tag the field avro:\"-\" or genmap:\"avro=...\"", t)
+}
+
+// record returns the record of a nested struct, or its name if it is
+// already defined.
+func (s *schema) record(name string, st *types.Struct) (interface{}, error) {
+	if s.defined[name] {
+		return name, nil
+	}
+	s.defined[name] = true
+	r := record{Type: "record", Name: name}
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if !f.Exported() {
+			continue
+		}
+		tags := reflect.StructTag(st.Tag(i))
+		fieldName := f.Name()
+		if tag := tags.Get("avro"); tag != "" {
+			fieldName = strings.Split(tag, ",")[0]
+		}
+		if fieldName == "-" {
+			continue
+		}
+		af, err := s.field(fieldName, f.Type(), tags.Get(typemap.TagKey))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", name, f.Name(), err)
+		}
+		r.Fields = append(r.Fields, af)
+	}
+	return r, nil
+}
+
+// field returns the record field of a Go field with the given genmap tag;
+// optional fields default to null.
+func (s *schema) field(name string, t types.Type, genmap string) (field, error) {
+	overrides, err := typemap.ParseOverrides(genmap)
+	if err != nil {
+		return field{}, err
+	}
+	typ, err := s.avroType(t, overrides)
+	if err != nil {
+		return field{}, err
+	}
+	f := field{Name: name, Type: typ}
+	if union, ok := typ.([]interface{}); ok && union[0] == "null" {
+		f.Default = json.RawMessage("null")
+	}
+	return f, nil
+}
+
+var avroTemplate = template.Must(template.New("avro").Parse(`
+// AvroSchema returns the Avro schema of {{.Struct}}.
+func ({{.Struct}}) AvroSchema() string {
+	return ` + "`" + `{{.Schema}}` + "`" + `
+}
+`))
+
+func generateAvro(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-avro %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+
+	s := &schema{defined: map[string]bool{info.Name: true}}
+	r := record{Type: "record", Name: info.Name, Namespace: *namespace}
+	if r.Namespace == "" {
+		r.Namespace = info.Package.GetName()
+	}
+	for _, f := range info.Fields {
+		name, ok := info.EffectiveName(f, "avro")
+		if !ok || f.Embedded || !ast.IsExported(f.Name) {
+			continue
+		}
+		if f.GoType == nil {
+			p.Fatalf("%s: no type information for %s", f.Pos, f.Name)
+		}
+		af, err := s.field(name, f.GoType, f.Tag(typemap.TagKey))
+		if err != nil {
+			p.Fatalf("%s: %s: %s", f.Pos, f.Name, err)
+		}
+		r.Fields = append(r.Fields, af)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		p.Fatalf("encoding schema of %s: %s", info.Name, err)
+	}
+
+	avroTemplate.Execute(p, map[string]interface{}{
+		"Struct": info.Name,
+		"Schema": string(data),
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-avro",
+	FileSuffix:  "avro",
+	GoFmtOutput: true,
+	SkipEmbedFS: true,
+}, generateAvro)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
@@ -0,0 +1,21 @@
+package example
+
+import "context"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-cache -iface=Catalog
+
+type Product struct {
+	ID    int64
+	Name  string
+	Price int64
+}
+
+type Catalog interface {
+	//gentoolkit:cache key=arg0 ttl=1m
+	Product(ctx context.Context, id int64) (*Product, error)
+
+	//gentoolkit:cache key=category,page ttl=30s
+	List(ctx context.Context, category string, page int) ([]*Product, error)
+
+	Update(ctx context.Context, p *Product) error
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jakoblorz/go-gentoolkit/interfaceutil"
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var (
+	ifaceName = flag.String("iface", "", "interface name; must be set")
+	output    = flag.String("output", "", "output file name; default srcdir/<iface>_cache.go")
+	ctxFirst  = flag.Bool("ctx", false, "require every method of the interface to take a context.Context first")
+)
+
+type cachedMethod struct {
+	*interfaceutil.Method
+	Recv     string
+	Cached   bool
+	TTL      string
+	Type     string                 // Unexported name prefix of the method's cache types.
+	KeyType  string                 // Type of the cache key.
+	Key      string                 // Key expression built from the parameters.
+	KeyParam []*interfaceutil.Param // Parameters forming the key.
+	Value    *interfaceutil.Param
+	Locals   map[string]string
+}
+
+var cacheTemplate = template.Must(template.New("cache").Parse(`
+// Cached{{.Iface}} caches the results of {{.Iface}} methods declared with
+// //gentoolkit:cache. Concurrent calls with the same key share a single call
+// to the wrapped {{.Iface}}, made with the context of the first caller.
+// Errors are not cached.
+type Cached{{.Iface}} struct {
+	next {{.Iface}}
+
+	// OnHit, OnMiss and OnShared, if set, are called with the method name
+	// when a call is answered from the cache, calls the wrapped {{.Iface}},
+	// or waits for a concurrent call with the same key.
+	OnHit    func(method string)
+	OnMiss   func(method string)
+	OnShared func(method string)
+{{range .Cached}}
+	cache{{.Name}} {{.Type}}Cache
+{{- end}}
+}
+{{range .Cached}}
+{{- if gt (len .KeyParam) 1}}
+type {{.KeyType}} struct {
+{{- range .KeyParam}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+{{end}}
+type {{.Type}}Cache struct {
+	mu       sync.Mutex
+	entries  map[{{.KeyType}}]{{.Type}}Entry
+	inflight map[{{.KeyType}}]*{{.Type}}Call
+}
+
+type {{.Type}}Entry struct {
+	value   {{.Value.Type}}
+	expires time.Time
+}
+
+type {{.Type}}Call struct {
+	wg    sync.WaitGroup
+	value {{.Value.Type}}
+	err   error
+}
+{{end}}
+// NewCached{{.Iface}} returns next wrapped with a cache.
+func NewCached{{.Iface}}(next {{.Iface}}) *Cached{{.Iface}} {
+	c := &Cached{{.Iface}}{next: next}
+	c.Purge()
+	return c
+}
+
+// Purge empties the cache. Calls in flight still store their results.
+func (c *Cached{{.Iface}}) Purge() {
+{{- range .Cached}}
+	c.cache{{.Name}}.mu.Lock()
+	c.cache{{.Name}}.entries = make(map[{{.KeyType}}]{{.Type}}Entry)
+	if c.cache{{.Name}}.inflight == nil {
+		c.cache{{.Name}}.inflight = make(map[{{.KeyType}}]*{{.Type}}Call)
+	}
+	c.cache{{.Name}}.mu.Unlock()
+{{- end}}
+}
+
+func (c *Cached{{.Iface}}) hook(fn func(string), method string) {
+	if fn != nil {
+		fn(method)
+	}
+}
+{{range .Methods}}{{$c := .Recv}}
+{{- if .Cached}}
+// Invalidate{{.Name}} removes the cached result of {{.Name}} for the given key.
+func ({{$c}} *Cached{{$.Iface}}) Invalidate{{.Name}}({{range $i, $p := .KeyParam}}{{if $i}}, {{end}}{{$p.Name}} {{$p.Type}}{{end}}) {
+	{{$c}}.cache{{.Name}}.mu.Lock()
+	delete({{$c}}.cache{{.Name}}.entries, {{.Key}})
+	{{$c}}.cache{{.Name}}.mu.Unlock()
+}
+
+func ({{$c}} *Cached{{$.Iface}}) {{.Name}}{{.Signature}} {
+	{{.Locals.key}} := {{.Key}}
+	{{$c}}.cache{{.Name}}.mu.Lock()
+	if {{.Locals.entry}}, {{.Locals.ok}} := {{$c}}.cache{{.Name}}.entries[{{.Locals.key}}]; {{.Locals.ok}} && time.Now().Before({{.Locals.entry}}.expires) {
+		{{$c}}.cache{{.Name}}.mu.Unlock()
+		{{$c}}.hook({{$c}}.OnHit, {{printf "%q" .Name}})
+		return {{.Locals.entry}}.value, nil
+	}
+	if {{.Locals.call}}, {{.Locals.ok}} := {{$c}}.cache{{.Name}}.inflight[{{.Locals.key}}]; {{.Locals.ok}} {
+		{{$c}}.cache{{.Name}}.mu.Unlock()
+		{{$c}}.hook({{$c}}.OnShared, {{printf "%q" .Name}})
+		{{.Locals.call}}.wg.Wait()
+		return {{.Locals.call}}.value, {{.Locals.call}}.err
+	}
+	{{.Locals.call}} := new({{.Type}}Call)
+	{{.Locals.call}}.wg.Add(1)
+	{{$c}}.cache{{.Name}}.inflight[{{.Locals.key}}] = {{.Locals.call}}
+	{{$c}}.cache{{.Name}}.mu.Unlock()
+	{{$c}}.hook({{$c}}.OnMiss, {{printf "%q" .Name}})
+
+	{{.Locals.call}}.value, {{.Locals.call}}.err = {{$c}}.next.{{.Name}}({{.Args}})
+	{{$c}}.cache{{.Name}}.mu.Lock()
+	delete({{$c}}.cache{{.Name}}.inflight, {{.Locals.key}})
+	if {{.Locals.call}}.err == nil {
+		{{$c}}.cache{{.Name}}.entries[{{.Locals.key}}] = {{.Type}}Entry{value: {{.Locals.call}}.value, expires: time.Now().Add({{.TTL}})}
+	}
+	{{$c}}.cache{{.Name}}.mu.Unlock()
+	{{.Locals.call}}.wg.Done()
+	return {{.Locals.call}}.value, {{.Locals.call}}.err
+}
+{{- else}}
+func ({{$c}} *Cached{{$.Iface}}) {{.Name}}{{.Signature}} {
+	{{if .Results}}return {{end}}{{$c}}.next.{{.Name}}({{.Args}})
+}
+{{- end}}
+{{end}}`))
+
+// keyParams resolves the key=... argument of a cache directive. Parameters
+// are named, or numbered arg0, arg1, ... not counting a leading context.
+func keyParams(m *interfaceutil.Method, key string) ([]*interfaceutil.Param, error) {
+	params := m.Params
+	if m.HasContext() {
+		params = params[1:]
+	}
+	var keys []*interfaceutil.Param
+	for _, name := range strings.Split(key, ",") {
+		var p *interfaceutil.Param
+		if strings.HasPrefix(name, "arg") {
+			if i, err := strconv.Atoi(name[3:]); err == nil && i >= 0 && i < len(params) {
+				p = params[i]
+			}
+		}
+		for _, q := range params {
+			if q.Name == name {
+				p = q
+			}
+		}
+		if p == nil {
+			return nil, fmt.Errorf("%s has no parameter %s", m.Name, name)
+		}
+		if !types.Comparable(p.GoType) {
+			return nil, fmt.Errorf("parameter %s of %s is not comparable", p.Name, m.Name)
+		}
+		keys = append(keys, p)
+	}
+	return keys, nil
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-cache:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-cache [flags] -iface I [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-cache [flags] -iface I files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Methods returning (T, error) are cached with\n")
+	fmt.Fprintf(os.Stderr, "\t//gentoolkit:cache key=arg0[,arg1...] ttl=1m\n")
+	fmt.Fprintf(os.Stderr, "where argN is the Nth parameter after the context, or a parameter name.\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-cache: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *ifaceName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := interfaceutil.ParsePackage(args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	iface := pkg.Lookup(*ifaceName)
+	if iface == nil {
+		log.Fatalf("interface %s not found", *ifaceName)
+	}
+	if *ctxFirst {
+		if err := iface.CheckContext(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	prefix := strings.ToLower(iface.Name[:1]) + iface.Name[1:]
+	var methods, cached []*cachedMethod
+	for _, m := range iface.Methods {
+		cm := &cachedMethod{Method: m, Recv: m.Local("c")}
+		methods = append(methods, cm)
+		d := m.Directive("cache")
+		if d == nil {
+			continue
+		}
+		if len(m.Results) != 2 || !m.ReturnsError() {
+			log.Fatalf("%s: cached method %s must return a value and an error", m.Pos, m.Name)
+		}
+		ttl, err := time.ParseDuration(d.Get("ttl"))
+		if err != nil || ttl <= 0 {
+			log.Fatalf("%s: %s: invalid ttl %q", m.Pos, m.Name, d.Get("ttl"))
+		}
+		keys, err := keyParams(m, d.Get("key"))
+		if err != nil {
+			log.Fatalf("%s: %s", m.Pos, err)
+		}
+
+		cm.Cached = true
+		cm.TTL = structutil.DurationLiteral(ttl)
+		cm.Type = prefix + m.Name
+		cm.KeyParam = keys
+		cm.Value = m.Results[0]
+		if len(keys) == 1 {
+			cm.KeyType = keys[0].Type
+			cm.Key = keys[0].Name
+		} else {
+			cm.KeyType = cm.Type + "Key"
+			var fields []string
+			for _, k := range keys {
+				fields = append(fields, k.Name+": "+k.Name)
+			}
+			cm.Key = cm.KeyType + "{" + strings.Join(fields, ", ") + "}"
+		}
+		cm.Locals = make(map[string]string)
+		for _, name := range []string{"key", "entry", "ok", "call"} {
+			cm.Locals[name] = m.Local(name)
+		}
+		cached = append(cached, cm)
+	}
+	if len(cached) == 0 {
+		log.Fatalf("%s: no method of %s has a //gentoolkit:cache directive", iface.Pos, iface.Name)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-cache %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	err = cacheTemplate.Execute(&buf, map[string]interface{}{
+		"Iface":   iface.Name,
+		"Methods": methods,
+		"Cached":  cached,
+	})
+	if err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, tagutil.Snake.Apply(iface.Name)+"_cache.go")
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), iface.Pos.Filename); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,25 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-clone -type=Deployment,Container
+
+type Deployment struct {
+	Name       string
+	Replicas   *int32
+	Containers []Container
+	Labels     map[string]string
+	Updated    time.Time
+	Strategy   struct {
+		MaxSurge *int32
+	}
+	Owner *Deployment
+	Hook  func() `clone:"shallow"`
+}
+
+type Container struct {
+	Image string
+	Args  []string
+	Env   map[string][]string
+	Ports [2]*int32
+}
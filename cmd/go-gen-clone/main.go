@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/types"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+var k8s = flag.Bool("k8s", false, "emit Kubernetes-style DeepCopy and DeepCopyInto, and DeepCopyObject for types embedding TypeMeta, instead of Clone and CloneInto")
+
+var cloneTemplate = template.Must(template.New("clone").Parse(`
+{{- if .Object}}
+
+import "k8s.io/apimachinery/pkg/runtime"
+{{- end}}
+
+// {{.Into}} copies the receiver into out, sharing no memory with it. in
+// must be non-nil.
+func (in *{{.Struct}}) {{.Into}}(out *{{.Struct}}) {
+	*out = *in
+{{.Body -}}
+}
+
+// {{.Copy}} returns a deep copy of the receiver, or nil if it is nil.
+func (in *{{.Struct}}) {{.Copy}}() *{{.Struct}} {
+	if in == nil {
+		return nil
+	}
+	out := new({{.Struct}})
+	in.{{.Into}}(out)
+	return out
+}
+{{- if .Object}}
+
+// DeepCopyObject implements runtime.Object.
+func (in *{{.Struct}}) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+{{- end}}
+`))
+
+// copier writes the statements that turn a shallow copy into a deep one.
+type copier struct {
+	buf     bytes.Buffer
+	pkgPath string
+	into    string // Name of the copy-into methods.
+	indent  int
+}
+
+func (c *copier) line(format string, args ...interface{}) {
+	c.buf.WriteString(strings.Repeat("\t", c.indent))
+	fmt.Fprintf(&c.buf, format, args...)
+	c.buf.WriteString("\n")
+}
+
+func (c *copier) typeString(t types.Type) string {
+	return types.TypeString(t, structutil.Qualifier(c.pkgPath))
+}
+
+// hasInto reports whether *t has a copy-into method, or gets one by being a
+// struct of the package, which is expected to be generated for as well.
+func (c *copier) hasInto(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	if _, ok := named.Underlying().(*types.Struct); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == c.pkgPath {
+		return true
+	}
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), true, named.Obj().Pkg(), c.into)
+	_, ok = obj.(*types.Func)
+	return ok
+}
+
+// shallow reports whether copying a value of type t by assignment shares no
+// memory. Functions and channels are shared by design, and time.Time is
+// immutable.
+func (c *copier) shallow(t types.Type) bool {
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time" {
+		return true
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Kind() != types.Invalid
+	case *types.Signature, *types.Chan:
+		return true
+	case *types.Array:
+		return c.shallow(u.Elem())
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if !c.shallow(u.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// deepen writes the statements that make out, which holds a shallow copy of
+// in, a deep copy of it.
+func (c *copier) deepen(in, out string, t types.Type) error {
+	if b, ok := t.Underlying().(*types.Basic); ok && b.Kind() == types.Invalid {
+		return errors.New("type cannot be resolved; fix the package before generating")
+	}
+	if c.shallow(t) {
+		return nil
+	}
+	if c.hasInto(t) {
+		c.line("%s.%s(&%s)", in, c.into, out)
+		return nil
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		c.line("if %s != nil {", in)
+		c.indent++
+		c.line("in, out := &%s, &%s", in, out)
+		c.line("*out = new(%s)", c.typeString(u.Elem()))
+		if c.hasInto(u.Elem()) && !c.shallow(u.Elem()) {
+			c.line("(*in).%s(*out)", c.into)
+		} else {
+			c.line("**out = **in")
+			if err := c.deepen("(**in)", "(**out)", u.Elem()); err != nil {
+				return err
+			}
+		}
+		c.indent--
+		c.line("}")
+	case *types.Slice:
+		c.line("if %s != nil {", in)
+		c.indent++
+		c.line("in, out := &%s, &%s", in, out)
+		c.line("*out = make(%s, len(*in))", c.typeString(t))
+		c.line("copy(*out, *in)")
+		if !c.shallow(u.Elem()) {
+			c.line("for i := range *in {")
+			c.indent++
+			if err := c.deepen("(*in)[i]", "(*out)[i]", u.Elem()); err != nil {
+				return err
+			}
+			c.indent--
+			c.line("}")
+		}
+		c.indent--
+		c.line("}")
+	case *types.Array:
+		c.line("{")
+		c.indent++
+		c.line("in, out := &%s, &%s", in, out)
+		c.line("for i := range *in {")
+		c.indent++
+		if err := c.deepen("(*in)[i]", "(*out)[i]", u.Elem()); err != nil {
+			return err
+		}
+		c.indent--
+		c.line("}")
+		c.indent--
+		c.line("}")
+	case *types.Map:
+		c.line("if %s != nil {", in)
+		c.indent++
+		c.line("in, out := &%s, &%s", in, out)
+		c.line("*out = make(%s, len(*in))", c.typeString(t))
+		if c.shallow(u.Elem()) {
+			c.line("for key, val := range *in {")
+			c.indent++
+			c.line("(*out)[key] = val")
+		} else {
+			c.line("for key, val := range *in {")
+			c.indent++
+			c.line("outVal := val")
+			if err := c.deepen("val", "outVal", u.Elem()); err != nil {
+				return err
+			}
+			c.line("(*out)[key] = outVal")
+		}
+		c.indent--
+		c.line("}")
+		c.indent--
+		c.line("}")
+	case *types.Struct:
+		if _, named := t.(*types.Named); named {
+			return fmt.Errorf("type %s has no %s method", c.typeString(t), c.into)
+		}
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			if err := c.deepen(in+"."+f.Name(), out+"."+f.Name(), f.Type()); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot copy %s; tag the field clone:\"shallow\" to share it", c.typeString(t))
+	}
+	return nil
+}
+
+func generateClone(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-clone %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+
+	c := &copier{pkgPath: info.Package.GetPath(), into: "CloneInto", indent: 1}
+	copyName := "Clone"
+	if *k8s {
+		c.into, copyName = "DeepCopyInto", "DeepCopy"
+	}
+	object := false
+	for _, field := range info.Fields {
+		if field.Embedded && field.Name == "TypeMeta" {
+			object = *k8s
+		}
+		switch field.Tag("clone") {
+		case "":
+		case "shallow":
+			continue
+		default:
+			p.Fatalf("%s: unknown clone tag %q", field.Pos, field.Tag("clone"))
+		}
+		if field.GoType == nil {
+			p.Fatalf("%s: no type information for %s", field.Pos, field.Name)
+		}
+		if err := c.deepen("in."+field.Name, "out."+field.Name, field.GoType); err != nil {
+			p.Fatalf("%s: %s: %s", field.Pos, field.Name, err)
+		}
+	}
+
+	cloneTemplate.Execute(p, map[string]interface{}{
+		"Struct": info.Name,
+		"Copy":   copyName,
+		"Into":   c.into,
+		"Body":   c.buf.String(),
+		"Object": object,
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-clone",
+	FileSuffix:  "clone",
+	GoFmtOutput: true,
+	SkipEmbedFS: true,
+	StrictTypes: true,
+}, generateClone)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
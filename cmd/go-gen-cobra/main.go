@@ -0,0 +1,210 @@
+package main
+
+import (
+	"flag"
+	"go/types"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+type cobraFlag struct {
+	Field    string
+	Name     string
+	Short    string
+	Usage    string
+	Func     string // pflag.FlagSet method binding the field.
+	Default  string
+	Required bool
+	Values   []string // Fixed completions.
+	Complete string   // "files" or "dirs".
+}
+
+var cobraTemplate = template.Must(template.New("cobra").Parse(`
+// BindFlags registers the fields of {{.Receiver}} as flags of fs, set to
+// their defaults.
+func ({{.Receiver}} *{{.Struct}}) BindFlags(fs *pflag.FlagSet) {
+{{- range .Flags}}
+	fs.{{.Func}}(&{{$.Receiver}}.{{.Field}}, {{printf "%q" .Name}}, {{printf "%q" .Short}}, {{.Default}}, {{printf "%q" .Usage}})
+{{- end}}
+}
+
+// New{{.Command}}Command returns the {{printf "%q" .Use}} command. run is called
+// with the parsed {{.Struct}}.
+func New{{.Command}}Command(run func(cmd *cobra.Command, args []string, params *{{.Struct}}) error) *cobra.Command {
+	params := new({{.Struct}})
+	cmd := &cobra.Command{
+		Use:   {{printf "%q" .Use}},
+		Short: {{printf "%q" .Short}},
+{{- if .Args}}
+		Args:  {{.Args}},
+{{- end}}
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd, args, params)
+		},
+	}
+	params.BindFlags(cmd.Flags())
+{{- range .Flags}}
+{{- if .Required}}
+	_ = cmd.MarkFlagRequired({{printf "%q" .Name}})
+{{- end}}
+{{- if eq .Complete "files"}}
+	_ = cmd.MarkFlagFilename({{printf "%q" .Name}})
+{{- else if eq .Complete "dirs"}}
+	_ = cmd.MarkFlagDirname({{printf "%q" .Name}})
+{{- else if .Values}}
+	_ = cmd.RegisterFlagCompletionFunc({{printf "%q" .Name}}, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{ {{- range $i, $v := .Values}}{{if $i}}, {{end}}{{printf "%q" $v}}{{end -}} }, cobra.ShellCompDirectiveNoFileComp
+	})
+{{- end}}
+{{- end}}
+	return cmd
+}
+`))
+
+// flagFuncs maps field types to pflag.FlagSet methods.
+var flagFuncs = map[string]string{
+	"string":            "StringVarP",
+	"bool":              "BoolVarP",
+	"int":               "IntVarP",
+	"int8":              "Int8VarP",
+	"int16":             "Int16VarP",
+	"int32":             "Int32VarP",
+	"int64":             "Int64VarP",
+	"uint":              "UintVarP",
+	"uint8":             "Uint8VarP",
+	"uint16":            "Uint16VarP",
+	"uint32":            "Uint32VarP",
+	"uint64":            "Uint64VarP",
+	"float32":           "Float32VarP",
+	"float64":           "Float64VarP",
+	"time.Duration":     "DurationVarP",
+	"[]string":          "StringSliceVarP",
+	"[]int":             "IntSliceVarP",
+	"[]int64":           "Int64SliceVarP",
+	"[]float64":         "Float64SliceVarP",
+	"[]bool":            "BoolSliceVarP",
+	"[]time.Duration":   "DurationSliceVarP",
+	"map[string]string": "StringToStringVarP",
+	"map[string]int":    "StringToIntVarP",
+}
+
+func generateCobra(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-cobra %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+	p.Printf("\n")
+	p.Printf("import (\n")
+	p.Printf("\t\"github.com/spf13/cobra\"\n")
+	p.Printf("\t\"github.com/spf13/pflag\"\n")
+	p.Printf(")\n")
+
+	command := info.Name
+	for _, suffix := range []string{"Params", "Options", "Flags", "Args"} {
+		if c := strings.TrimSuffix(info.Name, suffix); c != "" && c != info.Name {
+			command = c
+			break
+		}
+	}
+	config := info.Directive("cobra")
+	if config == nil {
+		config = &structutil.Directive{Name: "cobra"}
+	}
+	use := tagutil.Kebab.Apply(command)
+	if config.Has("use") {
+		use = config.Get("use")
+	}
+	short := config.Get("short")
+	if short == "" {
+		// "ServeParams configures the server." becomes "Configures the server."
+		short = strings.SplitN(strings.TrimSpace(info.Doc), "\n", 2)[0]
+		if rest := strings.TrimPrefix(short, info.Name+" "); rest != short && rest != "" {
+			short = strings.ToUpper(rest[:1]) + rest[1:]
+		}
+	}
+	args := ""
+	if config.Has("args") {
+		n, err := strconv.Atoi(config.Get("args"))
+		if err != nil {
+			p.Fatalf("%s: invalid args %q", info.Name, config.Get("args"))
+		}
+		args = "cobra.ExactArgs(" + strconv.Itoa(n) + ")"
+	}
+
+	var flags []cobraFlag
+	for _, field := range info.Fields {
+		name := field.Tag("flag")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = tagutil.Kebab.Apply(field.Name)
+		}
+		if field.GoType == nil {
+			p.Fatalf("%s: no type information for %s", field.Pos, field.Name)
+		}
+		typeName := types.TypeString(field.GoType, func(pkg *types.Package) string { return pkg.Name() })
+		fn, ok := flagFuncs[typeName]
+		if !ok {
+			p.Fatalf("%s: %s: no flag type for %s; exclude it with flag:\"-\"", field.Pos, field.Name, typeName)
+		}
+		f := cobraFlag{
+			Field:    field.Name,
+			Name:     name,
+			Short:    field.Tag("short"),
+			Usage:    field.Tag("usage"),
+			Func:     fn,
+			Default:  field.ZeroValue(),
+			Required: field.Tag("required") == "true",
+		}
+		if field.Tags != nil {
+			if tag, err := field.Tags.Get("default"); err == nil {
+				value, err := structutil.GoLiteral(tag.Value(), field.GoType, info.Package.GetPath())
+				if err != nil {
+					p.Fatalf("%s: default of %s: %s", field.Pos, field.Name, err)
+				}
+				f.Default = value
+			}
+			if tag, err := field.Tags.Get("complete"); err == nil {
+				switch v := tag.Value(); v {
+				case "files", "dirs":
+					f.Complete = v
+				default:
+					f.Values = strings.Split(v, ",")
+				}
+			}
+		}
+		flags = append(flags, f)
+	}
+
+	cobraTemplate.Execute(p, map[string]interface{}{
+		"Receiver": strings.ToLower(info.Name[0:1]),
+		"Struct":   info.Name,
+		"Command":  command,
+		"Use":      use,
+		"Short":    short,
+		"Args":     args,
+		"Flags":    flags,
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-cobra",
+	FileSuffix:  "cobra",
+	GoFmtOutput: true,
+}, generateCobra)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
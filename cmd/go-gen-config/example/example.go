@@ -0,0 +1,14 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-config -type=AppConfig
+
+type AppConfig struct {
+	Addr     string        `json:"addr" flag:"addr" env:"APP_ADDR" default:":8080" usage:"listen address"`
+	Timeout  time.Duration `json:"timeout" flag:"timeout" default:"30s" usage:"request timeout"`
+	Workers  int           `json:"workers" env:"APP_WORKERS" default:"4"`
+	Debug    bool          `json:"debug" flag:"debug" env:"APP_DEBUG"`
+	Origins  []string      `json:"origins" env:"APP_ORIGINS"`
+	Internal string        `json:"-"`
+}
@@ -0,0 +1,44 @@
+package example
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadFileAndFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{"timeout": "45s", "workers": 8}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l := &AppConfigLoader{
+		Path:      path,
+		Args:      []string{"-debug", "-addr=:9090"},
+		LookupEnv: func(string) (string, bool) { return "", false },
+	}
+	c, err := l.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Timeout != 45*time.Second || c.Workers != 8 || !c.Debug || c.Addr != ":9090" {
+		t.Errorf("Load() = %+v, want Timeout 45s, Workers 8, Debug and Addr :9090", *c)
+	}
+	want := map[string]string{"Addr": "flag", "Timeout": "file", "Workers": "file", "Debug": "flag"}
+	for name, source := range want {
+		if got := l.Source()[name]; got != source {
+			t.Errorf("Source()[%s] = %q, want %q", name, got, source)
+		}
+	}
+}
+
+func TestLoadInvalidFileDuration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{"timeout": "soon"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l := &AppConfigLoader{Path: path, LookupEnv: func(string) (string, bool) { return "", false }}
+	if c, err := l.Load(); err == nil {
+		t.Fatalf("Load() = %+v, want an error", *c)
+	}
+}
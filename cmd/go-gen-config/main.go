@@ -0,0 +1,225 @@
+package main
+
+import (
+	"flag"
+	"go/types"
+	"os"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+type configField struct {
+	Name    string
+	Default string // Go expression; empty if the field has no default tag.
+	JSON    string // Key in the configuration file; empty if ignored.
+	Flag    string
+	Usage   string
+	Env     string
+	Parse   string // Statements parsing s into c.<Name>.
+	Bool    bool   // Whether a bare -<Flag> sets the field to true.
+	Text    bool   // Whether a JSON string is parsed like the other sources.
+	FileErr string // Expression wrapping err from decoding the JSON key.
+	EnvErr  string // Expression wrapping err from parsing the environment.
+}
+
+var configTemplate = template.Must(template.New("config").Parse(`
+// {{.Struct}}Loader loads a {{.Struct}} from, in increasing order of
+// precedence, default tags, a JSON file, command-line flags and
+// environment variables, and records where each field came from.
+type {{.Struct}}Loader struct {
+	// Path of the JSON configuration file; no file is read if empty.
+	Path string
+	// Args are the command-line arguments, without the program name.
+	Args []string
+	// LookupEnv looks up environment variables; os.LookupEnv if nil.
+	LookupEnv func(key string) (string, bool)
+
+	sources map[string]string
+}
+
+// Load returns the loaded {{.Struct}}.
+{{- if .Context}}
+func (l *{{.Struct}}Loader) Load(ctx context.Context) (*{{.Struct}}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+{{- else}}
+func (l *{{.Struct}}Loader) Load() (*{{.Struct}}, error) {
+{{- end}}
+	c := new({{.Struct}})
+	l.sources = make(map[string]string)
+{{range .Fields}}{{if .Default}}
+	c.{{.Name}} = {{.Default}}
+	l.sources[{{printf "%q" .Name}}] = "default"
+{{- end}}{{end}}
+
+	if l.Path != "" {
+		data, err := ioutil.ReadFile(l.Path)
+		if err != nil {
+			return nil, err
+		}
+		var props map[string]json.RawMessage
+		if err := json.Unmarshal(data, &props); err != nil {
+			return nil, {{.FileErr}}
+		}
+{{- range .Fields}}{{if .JSON}}
+		if raw, ok := props[{{printf "%q" .JSON}}]; ok {
+{{- if .Text}}
+			var s string
+			if json.Unmarshal(raw, &s) == nil {
+				err = l.parse{{.Name}}(c, s)
+			} else {
+				err = json.Unmarshal(raw, &c.{{.Name}})
+			}
+			if err != nil {
+				return nil, {{.FileErr}}
+			}
+{{- else}}
+			if err := json.Unmarshal(raw, &c.{{.Name}}); err != nil {
+				return nil, {{.FileErr}}
+			}
+{{- end}}
+			l.sources[{{printf "%q" .Name}}] = "file"
+		}
+{{- end}}{{end}}
+	}
+
+	fs := flag.NewFlagSet({{printf "%q" .Struct}}, flag.ContinueOnError)
+{{- range .Fields}}{{if .Bool}}
+	fs.Var(boolFlag{{$.Struct}}(func(s string) error {
+		return l.parse{{.Name}}(c, s)
+	}), {{printf "%q" .Flag}}, {{printf "%q" .Usage}})
+{{- else if .Flag}}
+	fs.Func({{printf "%q" .Flag}}, {{printf "%q" .Usage}}, func(s string) error {
+		return l.parse{{.Name}}(c, s)
+	})
+{{- end}}{{end}}
+	if err := fs.Parse(l.Args); err != nil {
+		return nil, err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+{{- range .Fields}}{{if .Flag}}
+		case {{printf "%q" .Flag}}:
+			l.sources[{{printf "%q" .Name}}] = "flag"
+{{- end}}{{end}}
+		}
+	})
+
+	lookupEnv := l.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+{{- range .Fields}}{{if .Env}}
+	if s, ok := lookupEnv({{printf "%q" .Env}}); ok {
+		if err := l.parse{{.Name}}(c, s); err != nil {
+			return nil, {{.EnvErr}}
+		}
+		l.sources[{{printf "%q" .Name}}] = "env"
+	}
+{{- end}}{{end}}
+	return c, nil
+}
+
+// Source returns, per field name, where the last Load took its value from:
+// "default", "file", "flag" or "env". Fields left at their zero value are
+// missing.
+func (l *{{.Struct}}Loader) Source() map[string]string {
+	sources := make(map[string]string, len(l.sources))
+	for k, v := range l.sources {
+		sources[k] = v
+	}
+	return sources
+}
+{{range .Fields}}{{if .Parse}}
+func (l *{{$.Struct}}Loader) parse{{.Name}}(c *{{$.Struct}}, s string) error {
+	{{.Parse}}return nil
+}
+{{end}}{{end}}
+{{- if .BoolFlags}}
+// boolFlag{{.Struct}} is the flag.Value of a bool field of {{.Struct}}:
+// like flag.Bool, a bare -name sets it to true.
+type boolFlag{{.Struct}} func(s string) error
+
+func (f boolFlag{{.Struct}}) String() string   { return "" }
+func (f boolFlag{{.Struct}}) Set(s string) error { return f(s) }
+func (f boolFlag{{.Struct}}) IsBoolFlag() bool  { return true }
+{{end}}`))
+
+func generateConfig(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-config %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+
+	var (
+		fields    []configField
+		boolFlags bool
+	)
+	for _, field := range info.Fields {
+		if field.GoType == nil {
+			p.Fatalf("%s: no type information for %s", field.Pos, field.Name)
+		}
+		f := configField{
+			Name:  field.Name,
+			Flag:  field.Tag("flag"),
+			Usage: field.Tag("usage"),
+			Env:   field.Tag("env"),
+		}
+		f.JSON, _ = info.EffectiveName(field, "json")
+		f.FileErr = info.WrapError("err", "%s: "+f.JSON, "l.Path")
+		f.EnvErr = info.WrapError("err", f.Env)
+		if field.Tags != nil {
+			if tag, err := field.Tags.Get("default"); err == nil {
+				value, err := structutil.GoLiteral(tag.Value(), field.GoType, info.Package.GetPath())
+				if err != nil {
+					p.Fatalf("%s: default of %s: %s", field.Pos, field.Name, err)
+				}
+				f.Default = value
+			}
+		}
+		if f.Flag != "" || f.Env != "" {
+			parse, err := structutil.ParseCode("s", "c."+field.Name, field.GoType, info.Package.GetPath())
+			if err != nil {
+				p.Fatalf("%s: %s: %s", field.Pos, field.Name, err)
+			}
+			f.Parse = parse
+		} else if f.JSON != "" && structutil.IsDuration(field.GoType) {
+			// encoding/json decodes a time.Duration from nanoseconds only;
+			// parse "30s" as the flag and the environment would.
+			f.Parse, _ = structutil.ParseCode("s", "c."+field.Name, field.GoType, info.Package.GetPath())
+		}
+		if b, ok := field.GoType.Underlying().(*types.Basic); ok {
+			f.Bool = f.Flag != "" && b.Info()&types.IsBoolean != 0
+			f.Text = f.JSON != "" && f.Parse != "" && b.Info()&types.IsString == 0
+		}
+		boolFlags = boolFlags || f.Bool
+		fields = append(fields, f)
+	}
+
+	configTemplate.Execute(p, map[string]interface{}{
+		"Struct":    info.Name,
+		"Fields":    fields,
+		"Context":   info.ContextFirst(),
+		"BoolFlags": boolFlags,
+		"FileErr":   info.WrapError("err", "%s", "l.Path"),
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-config",
+	FileSuffix:  "config",
+	GoFmtOutput: true,
+	SkipEmbedFS: true,
+}, generateConfig)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
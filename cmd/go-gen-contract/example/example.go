@@ -0,0 +1,63 @@
+package example
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-contract -iface=UserStore
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrExists   = errors.New("already exists")
+)
+
+type Role string
+
+type User struct {
+	ID        int64 `db:"id,pk,autoincr"`
+	Email     string
+	Role      Role
+	Admin     bool
+	CreatedAt time.Time
+}
+
+type Session struct {
+	Token  string `id:"true"`
+	UserID int64
+}
+
+// UserStore stores users and their sessions. The SQL and in-memory
+// implementations share its contract.
+type UserStore interface {
+	// CreateUser stores u and returns its assigned ID.
+	//gentoolkit:contract roundtrip get=GetUser
+	//gentoolkit:contract unique err=ErrExists
+	CreateUser(ctx context.Context, u *User) (int64, error)
+
+	// GetUser returns the user with the ID id.
+	//gentoolkit:contract notfound err=ErrNotFound
+	GetUser(ctx context.Context, id int64) (*User, error)
+
+	// ListUsers returns all users.
+	//gentoolkit:contract lists create=CreateUser
+	ListUsers(ctx context.Context) ([]User, error)
+
+	// DeleteUser deletes the user with the ID id.
+	//gentoolkit:contract deletes create=CreateUser get=GetUser err=ErrNotFound
+	//gentoolkit:contract notfound err=ErrNotFound
+	DeleteUser(ctx context.Context, id int64) error
+
+	// SaveSession stores s.
+	//gentoolkit:contract roundtrip get=LookupSession
+	SaveSession(s Session)
+
+	// LookupSession returns the session of token, if there is one.
+	//gentoolkit:contract notfound
+	LookupSession(token string) (Session, bool)
+
+	// RevokeSession deletes the session of token.
+	//gentoolkit:contract deletes create=SaveSession get=LookupSession
+	RevokeSession(token string)
+}
@@ -0,0 +1,560 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/interfaceutil"
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var (
+	ifaceName = flag.String("iface", "", "interface name; must be set")
+	output    = flag.String("output", "", "output file name; default srcdir/<iface>_contract.go")
+)
+
+// contractCase is a subtest of the contract, checking one directive.
+type contractCase struct {
+	Name    string
+	Context bool
+	Body    string
+}
+
+// entity is a struct type stored and loaded by the methods of the
+// interface, keyed by one of its fields.
+type entity struct {
+	Name    string
+	Type    string
+	Key     string
+	KeyType types.Type
+	Fixture string
+	Fields  []string // Fields set by the fixture, as key: value.
+}
+
+var contractTemplate = template.Must(template.New("contract").Parse(`
+// Run{{.Iface}}Contract checks that the {{.Iface}} implementations that
+// factory returns, a new empty one per subtest, keep the invariants that
+// the //gentoolkit:contract directives of {{.Iface}} document. The tests of
+// every implementation call it.
+func Run{{.Iface}}Contract(t *testing.T, factory func(t *testing.T) {{.Iface}}) {
+{{- range .Cases}}
+	t.Run({{printf "%q" .Name}}, func(t *testing.T) {
+{{- if .Context}}
+		ctx := context.Background()
+{{- end}}
+		s := factory(t)
+{{- .Body}}
+	})
+{{- end}}
+}
+{{range .Entities}}
+// {{.Fixture}} returns the n-th {{.Name}} of the contract of {{$.Iface}}.
+func {{.Fixture}}(n int) {{.Type}} {
+	return {{.Type}}{
+{{- range .Fields}}
+		{{.}},
+{{- end}}
+	}
+}
+{{end}}`))
+
+// keyField returns the key field of the struct: the field tagged id:"true"
+// or db:",pk", else the field named ID.
+func keyField(s *types.Struct) (*types.Var, bool) {
+	var byName *types.Var
+	for i := 0; i < s.NumFields(); i++ {
+		field, tag := s.Field(i), reflect.StructTag(s.Tag(i))
+		if !field.Exported() {
+			continue
+		}
+		if tag.Get("id") == "true" {
+			return field, true
+		}
+		if db := strings.Split(tag.Get("db"), ","); len(db) > 1 {
+			for _, o := range db[1:] {
+				if o == "pk" {
+					return field, true
+				}
+			}
+		}
+		if field.Name() == "ID" {
+			byName = field
+		}
+	}
+	return byName, byName != nil
+}
+
+// fixtureValue returns an expression of type t, named by name, that
+// differs for every n, or "" if t is not a basic type or time.Time.
+func fixtureValue(name string, t types.Type, qualifier types.Qualifier) string {
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time" {
+		return "time.Date(2000, 1, 1, 0, 0, n, 0, time.UTC)"
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return ""
+	}
+	typ := types.TypeString(t, qualifier)
+	var v string
+	switch info := b.Info(); {
+	case info&types.IsString != 0:
+		v = fmt.Sprintf("fmt.Sprintf(\"%s-%%d\", n)", name)
+	case info&types.IsBoolean != 0:
+		v = "n%2 == 1"
+	case info&types.IsInteger != 0:
+		v = "n"
+	case info&types.IsFloat != 0:
+		return fmt.Sprintf("%s(n) + 0.5", typ)
+	default:
+		return ""
+	}
+	if typ == "int" || typ == "bool" || typ == "string" {
+		return v
+	}
+	return fmt.Sprintf("%s(%s)", typ, v)
+}
+
+type contractBuilder struct {
+	iface    *interfaceutil.Interface
+	pkgPath  string
+	entities []*entity
+	byObj    map[*types.TypeName]*entity
+}
+
+// entityOf returns the entity of t, a named struct with a key field or a
+// pointer to one, and whether t is a pointer.
+func (b *contractBuilder) entityOf(t types.Type) (*entity, bool, bool) {
+	ptr := false
+	if p, ok := t.(*types.Pointer); ok {
+		t, ptr = p.Elem(), true
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return nil, false, false
+	}
+	s, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil, false, false
+	}
+	if e, ok := b.byObj[named.Obj()]; ok {
+		return e, ptr, true
+	}
+	key, ok := keyField(s)
+	if !ok || !types.Comparable(key.Type()) {
+		return nil, false, false
+	}
+	qualifier := structutil.Qualifier(b.pkgPath)
+	iface := strings.ToLower(b.iface.Name[:1]) + b.iface.Name[1:]
+	e := &entity{
+		Name:    named.Obj().Name(),
+		Type:    types.TypeString(named, qualifier),
+		Key:     key.Name(),
+		KeyType: key.Type(),
+		Fixture: iface + "Contract" + named.Obj().Name(),
+	}
+	for i := 0; i < s.NumFields(); i++ {
+		field := s.Field(i)
+		if !field.Exported() || field.Embedded() {
+			continue
+		}
+		if v := fixtureValue(field.Name(), field.Type(), qualifier); v != "" {
+			e.Fields = append(e.Fields, field.Name()+": "+v)
+		}
+	}
+	b.entities = append(b.entities, e)
+	b.byObj[named.Obj()] = e
+	return e, ptr, true
+}
+
+// method returns the named method of the interface.
+func (b *contractBuilder) method(at *interfaceutil.Method, name string) (*interfaceutil.Method, error) {
+	if name == "" || name == "true" {
+		return nil, fmt.Errorf("%s: %s: contract needs a method", at.Pos, at.Name)
+	}
+	m := b.iface.Method(name)
+	if m == nil {
+		return nil, fmt.Errorf("%s: %s: %s has no method %s", at.Pos, at.Name, b.iface.Name, name)
+	}
+	return m, nil
+}
+
+// args returns the parameters of m after its context.
+func args(m *interfaceutil.Method) []*interfaceutil.Param {
+	if m.HasContext() {
+		return m.Params[1:]
+	}
+	return m.Params
+}
+
+// values returns the results of m before its error.
+func values(m *interfaceutil.Method) []*interfaceutil.Param {
+	if m.ReturnsError() {
+		return m.Results[:len(m.Results)-1]
+	}
+	return m.Results
+}
+
+// call returns the call of m with the arguments args, preceded by the
+// context if m takes one.
+func call(m *interfaceutil.Method, args ...string) string {
+	if m.HasContext() {
+		args = append([]string{"ctx"}, args...)
+	}
+	return fmt.Sprintf("s.%s(%s)", m.Name, strings.Join(args, ", "))
+}
+
+// lhs returns the left-hand side assigning the results of m: names for
+// the values before the error, then err if m returns one.
+func lhs(m *interfaceutil.Method, names ...string) string {
+	list := append([]string(nil), names...)
+	for len(list) < len(values(m)) {
+		list = append(list, "_")
+	}
+	if m.ReturnsError() {
+		list = append(list, "err")
+	}
+	return strings.Join(list, ", ")
+}
+
+// store returns the statements storing the fixture v with m, and the
+// expression of its key afterwards. m takes the entity or a pointer to it
+// and may return it, a pointer to it, or its key; an integer key is left
+// to m to assign if it returns one.
+func (b *contractBuilder) store(m *interfaceutil.Method, v string) (*entity, string, string, error) {
+	in, out := args(m), values(m)
+	if len(in) != 1 || len(out) > 1 {
+		return nil, "", "", fmt.Errorf("%s: %s must take and return one value to store", m.Pos, m.Name)
+	}
+	e, ptr, ok := b.entityOf(in[0].GoType)
+	if !ok {
+		return nil, "", "", fmt.Errorf("%s: %s: %s is not a struct with a key field", m.Pos, m.Name, in[0].Type)
+	}
+	arg := v
+	if ptr {
+		arg = "&" + v
+	}
+	var code strings.Builder
+	key := v + "." + e.Key
+	r := "k" + strings.TrimPrefix(v, "v")
+	if len(out) == 1 {
+		switch re, _, ok := b.entityOf(out[0].GoType); {
+		case ok && re == e:
+			key = r + "." + e.Key
+		case types.Identical(out[0].GoType, e.KeyType):
+			key = r
+			if b, ok := e.KeyType.Underlying().(*types.Basic); ok && b.Info()&types.IsInteger != 0 {
+				fmt.Fprintf(&code, "\n%s.%s = 0", v, e.Key)
+			}
+		default:
+			return nil, "", "", fmt.Errorf("%s: %s must return the stored value or its key", m.Pos, m.Name)
+		}
+		fmt.Fprintf(&code, "\n%s := %s", lhs(m, r), call(m, arg))
+		if m.ReturnsError() {
+			fmt.Fprintf(&code, "\nif err != nil {\nt.Fatalf(\"%s: %%v\", err)\n}", m.Name)
+		}
+	} else if m.ReturnsError() {
+		fmt.Fprintf(&code, "\nif err := %s; err != nil {\nt.Fatalf(\"%s: %%v\", err)\n}", call(m, arg), m.Name)
+	} else {
+		fmt.Fprintf(&code, "\n%s", call(m, arg))
+	}
+	return e, code.String(), key, nil
+}
+
+// get returns the statements loading the entity e of key with m into got,
+// failing if there is none.
+func (b *contractBuilder) get(m *interfaceutil.Method, e *entity, key string) (string, error) {
+	in, out := args(m), values(m)
+	ok := len(out) == 2 && types.Identical(out[1].GoType, types.Typ[types.Bool])
+	if len(in) != 1 || !types.Identical(in[0].GoType, e.KeyType) || len(out) != 1 && !ok {
+		return "", fmt.Errorf("%s: %s must take the key of %s and return it", m.Pos, m.Name, e.Name)
+	}
+	re, ptr, isEntity := b.entityOf(out[0].GoType)
+	if !isEntity || re != e {
+		return "", fmt.Errorf("%s: %s must return %s", m.Pos, m.Name, e.Name)
+	}
+	var code strings.Builder
+	names := []string{"got"}
+	if ok {
+		names = append(names, "ok")
+	}
+	fmt.Fprintf(&code, "\n%s := %s", lhs(m, names...), call(m, key))
+	if m.ReturnsError() {
+		fmt.Fprintf(&code, "\nif err != nil {\nt.Fatalf(\"%s: %%v\", err)\n}", m.Name)
+	}
+	if ok {
+		fmt.Fprintf(&code, "\nif !ok {\nt.Fatalf(\"%s: not found\")\n}", m.Name)
+	}
+	if ptr {
+		fmt.Fprintf(&code, "\nif got == nil {\nt.Fatalf(\"%s: nil\")\n}", m.Name)
+	}
+	return code.String(), nil
+}
+
+// missing returns the statements checking that m, taking a key, fails for
+// key: with an error matching err, any error if err is "", or by returning
+// false.
+func missing(m *interfaceutil.Method, key, err string) (string, error) {
+	out := values(m)
+	switch {
+	case m.ReturnsError():
+		return fails(m, call(m, key), err, m.Name), nil
+	case len(out) > 0 && types.Identical(out[len(out)-1].GoType, types.Typ[types.Bool]) && err == "":
+		names := make([]string, len(out))
+		for i := range names {
+			names[i] = "_"
+		}
+		names[len(names)-1] = "ok"
+		return fmt.Sprintf("\nif %s := %s; ok {\nt.Errorf(\"%s: found\")\n}", strings.Join(names, ", "), call(m, key), m.Name), nil
+	}
+	return "", fmt.Errorf("%s: %s must return an error or whether it found the value", m.Pos, m.Name)
+}
+
+// fails returns the statement checking that the call of m fails with an
+// error matching err, or any error if err is "". what names the call in
+// messages.
+func fails(m *interfaceutil.Method, call, err, what string) string {
+	if err == "" {
+		return fmt.Sprintf("\nif %s := %s; err == nil {\nt.Errorf(\"%s: no error\")\n}", lhs(m), call, what)
+	}
+	return fmt.Sprintf("\nif %s := %s; !errors.Is(err, %s) {\nt.Errorf(\"%s: error %%v, want %s\", err)\n}", lhs(m), call, err, what, err)
+}
+
+// check returns the statements comparing got, loaded by m, with want.
+func check(m *interfaceutil.Method) string {
+	return fmt.Sprintf("\nif !reflect.DeepEqual(%s, want) {\nt.Errorf(\"%s = %%+v, want %%+v\", %s, want)\n}", deref(m), m.Name, deref(m))
+}
+
+// deref returns got, dereferenced if m returns a pointer.
+func deref(m *interfaceutil.Method) string {
+	if _, ok := values(m)[0].GoType.(*types.Pointer); ok {
+		return "*got"
+	}
+	return "got"
+}
+
+// contractCase returns the subtest checking the contract directive d of
+// m.
+func (b *contractBuilder) contractCase(m *interfaceutil.Method, d *structutil.Directive) (contractCase, error) {
+	var (
+		c    = contractCase{Name: m.Name}
+		code strings.Builder
+	)
+	switch {
+	case d.Has("roundtrip"):
+		c.Name += "/roundtrip"
+		get, err := b.method(m, d.Get("get"))
+		if err != nil {
+			return c, err
+		}
+		e, stored, key, err := b.store(m, "v1")
+		if err != nil {
+			return c, err
+		}
+		loaded, err := b.get(get, e, key)
+		if err != nil {
+			return c, err
+		}
+		fmt.Fprintf(&code, "\nv1 := %s(1)%s\nwant := v1", e.Fixture, stored)
+		if key != "v1."+e.Key {
+			fmt.Fprintf(&code, "\nwant.%s = %s", e.Key, key)
+		}
+		fmt.Fprintf(&code, "%s%s", loaded, check(get))
+		c.Context = m.HasContext() || get.HasContext()
+	case d.Has("unique"):
+		c.Name += "/unique"
+		if !m.ReturnsError() {
+			return c, fmt.Errorf("%s: %s must return an error to be unique", m.Pos, m.Name)
+		}
+		e, stored, key, err := b.store(m, "v1")
+		if err != nil {
+			return c, err
+		}
+		arg := "v2"
+		if _, ptr, _ := b.entityOf(args(m)[0].GoType); ptr {
+			arg = "&v2"
+		}
+		fmt.Fprintf(&code, "\nv1 := %s(1)%s\nv2 := %s(2)\nv2.%s = %s%s", e.Fixture, stored, e.Fixture, e.Key, key, fails(m, call(m, arg), d.Get("err"), m.Name+" of a stored key"))
+		c.Context = m.HasContext()
+	case d.Has("notfound"):
+		c.Name += "/notfound"
+		in := args(m)
+		if len(in) != 1 {
+			return c, fmt.Errorf("%s: %s must take a key to be notfound", m.Pos, m.Name)
+		}
+		var e *entity
+		for _, candidate := range b.entities {
+			if types.Identical(candidate.KeyType, in[0].GoType) {
+				e = candidate
+			}
+		}
+		if e == nil {
+			return c, fmt.Errorf("%s: %s: %s is not the key of a stored struct", m.Pos, m.Name, in[0].Type)
+		}
+		check, err := missing(m, fmt.Sprintf("%s(1).%s", e.Fixture, e.Key), d.Get("err"))
+		if err != nil {
+			return c, err
+		}
+		code.WriteString(check)
+		c.Context = m.HasContext()
+	case d.Has("deletes"):
+		c.Name += "/deletes"
+		create, err := b.method(m, d.Get("create"))
+		if err != nil {
+			return c, err
+		}
+		get, err := b.method(m, d.Get("get"))
+		if err != nil {
+			return c, err
+		}
+		e, stored, key, err := b.store(create, "v1")
+		if err != nil {
+			return c, err
+		}
+		if in := args(m); len(in) != 1 || !types.Identical(in[0].GoType, e.KeyType) || len(values(m)) != 0 {
+			return c, fmt.Errorf("%s: %s must take the key of %s and return at most an error", m.Pos, m.Name, e.Name)
+		}
+		fmt.Fprintf(&code, "\nv1 := %s(1)%s", e.Fixture, stored)
+		if m.ReturnsError() {
+			fmt.Fprintf(&code, "\nif err := %s; err != nil {\nt.Fatalf(\"%s: %%v\", err)\n}", call(m, key), m.Name)
+		} else {
+			fmt.Fprintf(&code, "\n%s", call(m, key))
+		}
+		check, err := missing(get, key, d.Get("err"))
+		if err != nil {
+			return c, err
+		}
+		code.WriteString(check)
+		c.Context = m.HasContext() || create.HasContext() || get.HasContext()
+	case d.Has("lists"):
+		c.Name += "/lists"
+		create, err := b.method(m, d.Get("create"))
+		if err != nil {
+			return c, err
+		}
+		if len(args(m)) != 0 || len(values(m)) != 1 {
+			return c, fmt.Errorf("%s: %s must take no arguments and return a slice to list", m.Pos, m.Name)
+		}
+		slice, ok := values(m)[0].GoType.(*types.Slice)
+		if !ok {
+			return c, fmt.Errorf("%s: %s must return a slice to list", m.Pos, m.Name)
+		}
+		e1, stored1, key1, err := b.store(create, "v1")
+		if err != nil {
+			return c, err
+		}
+		_, stored2, key2, _ := b.store(create, "v2")
+		if e, _, ok := b.entityOf(slice.Elem()); !ok || e != e1 {
+			return c, fmt.Errorf("%s: %s must return a slice of %s", m.Pos, m.Name, e1.Name)
+		}
+		fmt.Fprintf(&code, "\nv1 := %s(1)%s\nv2 := %s(2)%s", e1.Fixture, stored1, e1.Fixture, stored2)
+		if m.ReturnsError() {
+			fmt.Fprintf(&code, "\nall, err := %s\nif err != nil {\nt.Fatalf(\"%s: %%v\", err)\n}", call(m), m.Name)
+		} else {
+			fmt.Fprintf(&code, "\nall := %s", call(m))
+		}
+		fmt.Fprintf(&code, "\nlisted := make(map[%s]bool)\nfor _, v := range all {\nlisted[v.%s] = true\n}", types.TypeString(e1.KeyType, structutil.Qualifier(b.pkgPath)), e1.Key)
+		fmt.Fprintf(&code, "\nif !listed[%s] || !listed[%s] {\nt.Errorf(\"%s = %%+v, want the stored values\", all)\n}", key1, key2, m.Name)
+		c.Context = m.HasContext() || create.HasContext()
+	default:
+		return c, fmt.Errorf("%s: %s: unknown contract %q", m.Pos, m.Name, d.String())
+	}
+	c.Body = code.String()
+	return c, nil
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-contract:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-contract [flags] -iface I [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-contract [flags] -iface I files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Writes Run<I>Contract(t, factory), checking the invariants that the\n")
+	fmt.Fprintf(os.Stderr, "methods of I declare, one per line:\n")
+	fmt.Fprintf(os.Stderr, "\t//gentoolkit:contract roundtrip get=Get      stored values load equal\n")
+	fmt.Fprintf(os.Stderr, "\t//gentoolkit:contract unique [err=ErrExists] storing a stored key fails\n")
+	fmt.Fprintf(os.Stderr, "\t//gentoolkit:contract notfound [err=ErrNotFound] an unknown key fails\n")
+	fmt.Fprintf(os.Stderr, "\t//gentoolkit:contract deletes create=Create get=Get [err=ErrNotFound]\n")
+	fmt.Fprintf(os.Stderr, "\t//gentoolkit:contract lists create=Create     stored values are listed\n")
+	fmt.Fprintf(os.Stderr, "Stored structs are keyed by their field tagged id:\"true\" or db:\",pk\", or ID.\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-contract: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *ifaceName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := interfaceutil.ParsePackage(args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	iface := pkg.Lookup(*ifaceName)
+	if iface == nil {
+		log.Fatalf("interface %s not found", *ifaceName)
+	}
+
+	b := &contractBuilder{iface: iface, pkgPath: pkg.Path, byObj: make(map[*types.TypeName]*entity)}
+	// notfound cases come last, once the cases storing values have found
+	// the structs keyed by their arguments.
+	var cases []contractCase
+	for _, notfound := range []bool{false, true} {
+		for _, m := range iface.Methods {
+			for _, d := range m.Directives {
+				if d.Name != "contract" || d.Has("notfound") != notfound {
+					continue
+				}
+				c, err := b.contractCase(m, d)
+				if err != nil {
+					log.Fatal(err)
+				}
+				cases = append(cases, c)
+			}
+		}
+	}
+	if len(cases) == 0 {
+		log.Fatalf("%s: no method of %s has a //gentoolkit:contract directive", iface.Pos, iface.Name)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-contract %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	err = contractTemplate.Execute(&buf, map[string]interface{}{
+		"Iface":    iface.Name,
+		"Cases":    cases,
+		"Entities": b.entities,
+	})
+	if err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, tagutil.Snake.Apply(iface.Name)+"_contract.go")
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), iface.Pos.Filename); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,14 @@
+package example
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-dataloader -type=User,Team
+
+type User struct {
+	ID     int64  `json:"id" id:"true"`
+	Name   string `json:"name"`
+	TeamID string `json:"team_id"`
+}
+
+type Team struct {
+	Slug string `json:"slug" id:"true"`
+	Name string `json:"name"`
+}
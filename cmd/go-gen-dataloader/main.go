@@ -0,0 +1,262 @@
+package main
+
+import (
+	"flag"
+	"go/types"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+type loader struct {
+	Name    string
+	Lower   string
+	KeyType string
+	Key     string
+}
+
+var loaderTemplate = template.Must(template.New("loader").Parse(`
+// DefaultLoaderWait is how long loaders collect keys before they call
+// their batch function.
+var DefaultLoaderWait = time.Millisecond
+{{range .Loaders}}
+// {{.Name}}BatchFunc loads the {{.Name}} values of keys in one call, such as
+// one query. Keys missing from the map load as nil.
+type {{.Name}}BatchFunc func(ctx context.Context, keys []{{.KeyType}}) (map[{{.KeyType}}]*{{.Name}}, error)
+
+// {{.Name}}Loader batches and caches the loads of {{.Name}} values by
+// {{.Key}}. It is scoped to a request: its cache is never invalidated
+// except by Clear, and a batch runs with the context of its first load.
+type {{.Name}}Loader struct {
+	// Wait is how long keys are collected before a batch runs.
+	Wait time.Duration
+	// MaxBatch, if positive, runs a batch as soon as it has this many keys.
+	MaxBatch int
+
+	batch   {{.Name}}BatchFunc
+	mu      sync.Mutex
+	cache   map[{{.KeyType}}]*{{.Lower}}Result
+	pending *{{.Lower}}Batch
+}
+
+type {{.Lower}}Result struct {
+	v    *{{.Name}}
+	err  error
+	done chan struct{}
+}
+
+type {{.Lower}}Batch struct {
+	keys    []{{.KeyType}}
+	results []*{{.Lower}}Result
+}
+
+// New{{.Name}}Loader returns a loader calling batch.
+func New{{.Name}}Loader(batch {{.Name}}BatchFunc) *{{.Name}}Loader {
+	return &{{.Name}}Loader{
+		Wait:  DefaultLoaderWait,
+		batch: batch,
+		cache: make(map[{{.KeyType}}]*{{.Lower}}Result),
+	}
+}
+
+// Load returns the {{.Name}} of key, or nil if there is none.
+func (l *{{.Name}}Loader) Load(ctx context.Context, key {{.KeyType}}) (*{{.Name}}, error) {
+	return l.wait(ctx, l.load(ctx, key))
+}
+
+// LoadMany returns the {{.Name}} values of keys, in the same order, loaded
+// in the same batches.
+func (l *{{.Name}}Loader) LoadMany(ctx context.Context, keys []{{.KeyType}}) ([]*{{.Name}}, error) {
+	results := make([]*{{.Lower}}Result, len(keys))
+	for i, key := range keys {
+		results[i] = l.load(ctx, key)
+	}
+	vs := make([]*{{.Name}}, len(keys))
+	for i, r := range results {
+		v, err := l.wait(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		vs[i] = v
+	}
+	return vs, nil
+}
+
+// Prime caches v as the {{.Name}} of its {{.Key}}, unless one is cached.
+func (l *{{.Name}}Loader) Prime(v *{{.Name}}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.cache[v.{{.Key}}]; !ok {
+		r := &{{.Lower}}Result{v: v, done: make(chan struct{})}
+		close(r.done)
+		l.cache[v.{{.Key}}] = r
+	}
+}
+
+// Clear removes the {{.Name}} of key from the cache, such as after it was
+// updated.
+func (l *{{.Name}}Loader) Clear(key {{.KeyType}}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, key)
+}
+
+func (l *{{.Name}}Loader) load(ctx context.Context, key {{.KeyType}}) *{{.Lower}}Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if r, ok := l.cache[key]; ok {
+		return r
+	}
+	r := &{{.Lower}}Result{done: make(chan struct{})}
+	l.cache[key] = r
+	b := l.pending
+	if b == nil {
+		b = &{{.Lower}}Batch{}
+		l.pending = b
+		time.AfterFunc(l.Wait, func() {
+			l.mu.Lock()
+			if l.pending != b {
+				l.mu.Unlock()
+				return
+			}
+			l.pending = nil
+			l.mu.Unlock()
+			l.run(ctx, b)
+		})
+	}
+	b.keys = append(b.keys, key)
+	b.results = append(b.results, r)
+	if l.MaxBatch > 0 && len(b.keys) >= l.MaxBatch {
+		l.pending = nil
+		go l.run(ctx, b)
+	}
+	return r
+}
+
+// run calls the batch function for b. Failed loads are not cached, so
+// that they are retried.
+func (l *{{.Name}}Loader) run(ctx context.Context, b *{{.Lower}}Batch) {
+	vs, err := l.batch(ctx, b.keys)
+	if err != nil {
+		l.mu.Lock()
+		for i, key := range b.keys {
+			if l.cache[key] == b.results[i] {
+				delete(l.cache, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+	for i, r := range b.results {
+		r.v, r.err = vs[b.keys[i]], err
+		close(r.done)
+	}
+}
+
+func (l *{{.Name}}Loader) wait(ctx context.Context, r *{{.Lower}}Result) (*{{.Name}}, error) {
+	select {
+	case <-r.done:
+		return r.v, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+{{end}}
+// Loaders holds the loaders of a request.
+type Loaders struct {
+{{- range .Loaders}}
+	{{.Name}} *{{.Name}}Loader
+{{- end}}
+}
+
+type loadersKey struct{}
+
+// WithLoaders returns a copy of ctx carrying l.
+func WithLoaders(ctx context.Context, l *Loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+// LoadersFromContext returns the loaders of ctx, or nil.
+func LoadersFromContext(ctx context.Context) *Loaders {
+	l, _ := ctx.Value(loadersKey{}).(*Loaders)
+	return l
+}
+
+// LoaderMiddleware returns middleware storing the loaders newLoaders makes
+// for each request in its context, so that no values are cached across
+// requests.
+func LoaderMiddleware(newLoaders func(r *http.Request) *Loaders) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithLoaders(r.Context(), newLoaders(r))))
+		})
+	}
+}
+`))
+
+// idField returns the field of info tagged id:"true", or nil.
+func idField(p structutil.PrinterWriter, info *structutil.StructInfo) *structutil.StructFieldInfo {
+	var id *structutil.StructFieldInfo
+	for i := range info.Fields {
+		field := &info.Fields[i]
+		if field.Tag("id") != "true" {
+			continue
+		}
+		if id != nil {
+			p.Fatalf("%s: %s: %s is already tagged id", field.Pos, field.Name, id.Name)
+		}
+		id = field
+	}
+	return id
+}
+
+func generateLoaders(pkg *structutil.Package, infos []*structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-dataloader %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", pkg.GetName())
+
+	var loaders []loader
+	for _, info := range infos {
+		field := idField(p, info)
+		if field == nil {
+			continue
+		}
+		if field.GoType == nil {
+			p.Fatalf("%s: no type information for %s", field.Pos, field.Name)
+		}
+		if !types.Comparable(field.GoType) {
+			p.Fatalf("%s: %s: an id field must be comparable", field.Pos, field.Name)
+		}
+		loaders = append(loaders, loader{
+			Name:    info.Name,
+			Lower:   strings.ToLower(info.Name[:1]) + info.Name[1:],
+			KeyType: field.Type,
+			Key:     field.Name,
+		})
+	}
+	if len(loaders) == 0 {
+		p.Fatalf("no struct has a field tagged id:\"true\"")
+	}
+
+	loaderTemplate.Execute(p, map[string]interface{}{
+		"Loaders": loaders,
+	})
+}
+
+var generator = structutil.NewForPackageGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-dataloader",
+	FileSuffix:  "loader",
+	GoFmtOutput: true,
+}, generateLoaders)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
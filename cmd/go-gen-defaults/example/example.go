@@ -0,0 +1,25 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-defaults -type=ServerConfig
+
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelError
+)
+
+type ServerConfig struct {
+	Addr         string          `default:"localhost:8080"`
+	ReadTimeout  time.Duration   `default:"1m30s"`
+	MaxBodyBytes int64           `default:"1048576"`
+	Ratio        float64         `default:"0.5"`
+	Compress     *bool           `default:"true"`
+	Level        LogLevel        `default:"LogLevelInfo"`
+	Origins      []string        `default:"localhost,example.com"`
+	Backoff      []time.Duration `default:"100ms,1s"`
+	Name         string
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"go/types"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+type defaultField struct {
+	Name  string
+	Zero  string
+	Value string
+	Elem  string // Element type of pointer fields, which are set to a new value.
+}
+
+var defaultsTemplate = template.Must(template.New("defaults").Parse(`
+// ApplyDefaults sets zero fields of {{.Receiver}} to their default:"..."
+// values and returns the names of the fields it set.
+func ({{.Receiver}} *{{.Struct}}) ApplyDefaults() []string {
+	var defaulted []string
+{{- range .Fields}}
+	if {{.Zero}} {
+{{- if .Elem}}
+		{{$.Receiver}}.{{.Name}} = new({{.Elem}})
+		*{{$.Receiver}}.{{.Name}} = {{.Value}}
+{{- else}}
+		{{$.Receiver}}.{{.Name}} = {{.Value}}
+{{- end}}
+		defaulted = append(defaulted, {{printf "%q" .Name}})
+	}
+{{- end}}
+	return defaulted
+}
+`))
+
+func generateDefaults(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-defaults %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+
+	receiver := strings.ToLower(info.Name[0:1])
+	var fields []defaultField
+	for _, field := range info.Fields {
+		if field.Tags == nil {
+			continue
+		}
+		tag, err := field.Tags.Get("default")
+		if err != nil {
+			continue
+		}
+		if field.GoType == nil {
+			p.Fatalf("%s: no type information for %s", field.Pos, field.Name)
+		}
+		f := defaultField{
+			Name: field.Name,
+			Zero: field.ZeroTest(receiver + "." + field.Name),
+		}
+		t := field.GoType
+		if ptr, ok := t.Underlying().(*types.Pointer); ok {
+			t = ptr.Elem()
+			f.Elem = types.TypeString(t, structutil.Qualifier(info.Package.GetPath()))
+		} else if b, ok := t.Underlying().(*types.Basic); ok && b.Info()&types.IsBoolean != 0 && tag.Value() != "false" {
+			// A false value would be indistinguishable from an unset one
+			// and be defaulted again.
+			p.Fatalf("%s: %s: a bool cannot default to %s; use *bool", field.Pos, field.Name, tag.Value())
+		}
+		f.Value, err = structutil.GoLiteral(tag.Value(), t, info.Package.GetPath())
+		if err != nil {
+			p.Fatalf("%s: default of %s: %s", field.Pos, field.Name, err)
+		}
+		fields = append(fields, f)
+	}
+
+	defaultsTemplate.Execute(p, map[string]interface{}{
+		"Receiver": receiver,
+		"Struct":   info.Name,
+		"Fields":   fields,
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-defaults",
+	FileSuffix:  "defaults",
+	GoFmtOutput: true,
+}, generateDefaults)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
@@ -0,0 +1,39 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-diagram
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-diagram -format=dot
+
+type Timestamps struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Customer struct {
+	Timestamps
+	ID       int64    `db:"id,pk"`
+	Name     string   `db:"name"`
+	Shipping *Address `db:"-"`
+	Billing  Address  `db:"-"`
+	Orders   []*Order `db:"-"`
+}
+
+type Address struct {
+	Street  string
+	City    string
+	Country string
+}
+
+type Order struct {
+	ID         int64             `db:"id,pk"`
+	CustomerID int64             `db:"customer_id" ref:"Customer"`
+	Items      []LineItem        `db:"-"`
+	Notes      map[string]string `db:"-"`
+}
+
+type LineItem struct {
+	SKU      string
+	Quantity int
+	Price    int64
+}
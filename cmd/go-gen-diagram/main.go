@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of type names; default all structs")
+	format    = flag.String("format", "mermaid", "diagram format: mermaid or dot")
+	output    = flag.String("output", "", "output file name; default srcdir/<package>_diagram.mmd, or .dot")
+)
+
+// cardinality is how many values of a struct a field holds.
+type cardinality int
+
+const (
+	one cardinality = iota
+	zeroOrOne
+	many
+)
+
+type attribute struct {
+	Name string
+	Type string
+	Key  string // PK, FK or "".
+}
+
+// edge is a field of From holding, embedding or referencing To.
+type edge struct {
+	From, To string
+	Field    string
+	Card     cardinality
+	Embedded bool
+	Ref      bool // Holds the primary key of To, by a ref:"<Type>" tag.
+}
+
+type entity struct {
+	Name       string
+	Attributes []attribute
+}
+
+// target returns the struct of names that t holds, through pointers,
+// slices, arrays and map values, and how many of it.
+func target(t types.Type, pkgPath string, names map[string]bool) (string, cardinality, bool) {
+	card := one
+	for {
+		switch u := t.(type) {
+		case *types.Pointer:
+			if card == one {
+				card = zeroOrOne
+			}
+			t = u.Elem()
+			continue
+		case *types.Slice:
+			card, t = many, u.Elem()
+			continue
+		case *types.Array:
+			card, t = many, u.Elem()
+			continue
+		case *types.Map:
+			card, t = many, u.Elem()
+			continue
+		case *types.Named:
+			if u.Obj().Pkg() != nil && u.Obj().Pkg().Path() == pkgPath && names[u.Obj().Name()] {
+				return u.Obj().Name(), card, true
+			}
+		}
+		return "", one, false
+	}
+}
+
+// typeName returns t without package qualifiers, as diagrams print it.
+func typeName(t types.Type) string {
+	return types.TypeString(t, func(*types.Package) string { return "" })
+}
+
+func diagram(pkg *structutil.Package, infos []*structutil.StructInfo) ([]entity, []edge) {
+	names := make(map[string]bool)
+	for _, info := range infos {
+		names[info.Name] = true
+	}
+	var (
+		entities []entity
+		edges    []edge
+	)
+	for _, info := range infos {
+		keys := make(map[string]bool)
+		if columns, err := info.Columns(); err == nil {
+			for _, c := range columns {
+				keys[c.Field.Name] = c.PrimaryKey
+			}
+		}
+		e := entity{Name: info.Name}
+		for _, field := range info.Fields {
+			if field.Name == "_" {
+				continue
+			}
+			if field.GoType == nil {
+				log.Fatalf("%s: no type information for %s", field.Pos, field.Name)
+			}
+			a := attribute{Name: field.Name, Type: typeName(field.GoType)}
+			if keys[field.Name] {
+				a.Key = "PK"
+			}
+			if ref := field.Tag("ref"); ref != "" {
+				if !names[ref] {
+					log.Fatalf("%s: %s: %s is not in the diagram", field.Pos, field.Name, ref)
+				}
+				a.Key = "FK"
+				card := one
+				if strategy, _ := structutil.Nullability(field.GoType); strategy != structutil.NotNull {
+					card = zeroOrOne
+				}
+				edges = append(edges, edge{From: info.Name, To: ref, Field: field.Name, Card: card, Ref: true})
+			}
+			if to, card, ok := target(field.GoType, pkg.GetPath(), names); ok {
+				edges = append(edges, edge{From: info.Name, To: to, Field: field.Name, Card: card, Embedded: field.Embedded})
+				if field.Embedded {
+					continue
+				}
+			}
+			e.Attributes = append(e.Attributes, a)
+		}
+		entities = append(entities, e)
+	}
+	return entities, edges
+}
+
+// mermaidType returns a type name Mermaid accepts for an attribute.
+func mermaidType(t string) string {
+	suffix := ""
+	for strings.HasPrefix(t, "[]") {
+		t, suffix = t[2:], suffix+"[]"
+	}
+	return strings.NewReplacer("*", "", ".", "_", "[", "_", "]", "_", " ", "_", "{", "", "}", "").Replace(t) + suffix
+}
+
+func writeMermaid(w *bytes.Buffer, entities []entity, edges []edge) {
+	fmt.Fprintf(w, "erDiagram\n")
+	for _, e := range entities {
+		fmt.Fprintf(w, "    %s {\n", e.Name)
+		for _, a := range e.Attributes {
+			fmt.Fprintf(w, "        %s %s", mermaidType(a.Type), a.Name)
+			if a.Key != "" {
+				fmt.Fprintf(w, " %s", a.Key)
+			}
+			fmt.Fprintf(w, "\n")
+		}
+		fmt.Fprintf(w, "    }\n")
+	}
+	for _, e := range edges {
+		// The left end is From, the right end To.
+		from, to := "||", map[cardinality]string{one: "||", zeroOrOne: "o|", many: "o{"}[e.Card]
+		label := e.Field
+		switch {
+		case e.Ref:
+			from = "}o"
+		case e.Embedded:
+			label += " (embedded)"
+		}
+		line := "--"
+		if e.Ref {
+			line = ".."
+		}
+		fmt.Fprintf(w, "    %s %s%s%s %s : %q\n", e.From, from, line, to, e.To, label)
+	}
+}
+
+// dotEscape escapes the characters of s that are special in record labels.
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`).Replace(s)
+}
+
+func writeDot(w *bytes.Buffer, name string, entities []entity, edges []edge) {
+	fmt.Fprintf(w, "digraph %q {\n", name)
+	fmt.Fprintf(w, "\tnode [shape=record];\n")
+	for _, e := range entities {
+		var rows []string
+		for _, a := range e.Attributes {
+			row := a.Name + " " + a.Type
+			if a.Key != "" {
+				row += " (" + a.Key + ")"
+			}
+			rows = append(rows, dotEscape(row)+`\l`)
+		}
+		fmt.Fprintf(w, "\t%s [label=\"{%s|%s}\"];\n", e.Name, e.Name, strings.Join(rows, ""))
+	}
+	for _, e := range edges {
+		attrs := fmt.Sprintf("label=%q, headlabel=%q", e.Field, map[cardinality]string{one: "1", zeroOrOne: "0..1", many: "0..*"}[e.Card])
+		switch {
+		case e.Ref:
+			attrs += ", style=dashed"
+		case e.Embedded:
+			attrs += ", arrowhead=empty"
+		default:
+			attrs += ", arrowtail=diamond, dir=both"
+		}
+		fmt.Fprintf(w, "\t%s -> %s [%s];\n", e.From, e.To, attrs)
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-diagram:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-diagram [flags] [-type T] [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-diagram [flags] [-type T] files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Writes a diagram of the structs of the package and the fields nesting,\n")
+	fmt.Fprintf(os.Stderr, "embedding or referencing (ref:\"<Type>\") each other.\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-diagram: ")
+	flag.Usage = usage
+	flag.Parse()
+	ext := map[string]string{"mermaid": ".mmd", "dot": ".dot"}[*format]
+	if ext == "" {
+		log.Fatalf("unknown format %q", *format)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+	pkg, infos, err := structutil.LoadStructs(args, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	entities, edges := diagram(pkg, infos)
+
+	var buf bytes.Buffer
+	header := fmt.Sprintf("Code generated by \"%s\"; DO NOT EDIT.\n", structutil.JoinArgs(append([]string{"go-gen-diagram"}, os.Args[1:]...)))
+	if *format == "mermaid" {
+		buf.WriteString("%% " + header)
+		writeMermaid(&buf, entities, edges)
+	} else {
+		buf.WriteString("// " + header)
+		writeDot(&buf, pkg.GetName(), entities, edges)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		dir, err := structutil.SourceDir(args)
+		if err != nil {
+			log.Fatal(err)
+		}
+		outputName = filepath.Join(dir, pkg.GetName()+"_diagram"+ext)
+	}
+	var inputs []string
+	for _, info := range infos {
+		for _, field := range info.Fields {
+			if len(inputs) == 0 || inputs[len(inputs)-1] != field.Pos.Filename {
+				inputs = append(inputs, field.Pos.Filename)
+			}
+		}
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), inputs...); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,13 @@
+package example
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-errors -type=ErrorCode
+
+type ErrorCode int
+
+const (
+	NotFound ErrorCode = iota + 1
+	//gentoolkit:error msg="permission denied"
+	PermissionDenied
+	//gentoolkit:error msg="invalid field %s: %s" fields=Field:string,Reason:string
+	InvalidField
+)
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/constant"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/constutil"
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var (
+	typeName = flag.String("type", "", "error code type name; must be set")
+	output   = flag.String("output", "", "output file name; default srcdir/<type>_errors.go")
+)
+
+type errorField struct {
+	Name string
+	Type string
+}
+
+type errorDef struct {
+	Const   string
+	Message string
+	Fields  []errorField
+}
+
+var errorsTemplate = template.Must(template.New("errors").Parse(`
+var {{.Unexported}}Messages = map[{{.Type}}]string{
+{{- range .Errors}}
+	{{.Const}}: {{printf "%q" .Message}},
+{{- end}}
+}
+
+// {{.Type}}s lists all registered {{.Type}} values.
+var {{.Type}}s = []{{.Type}}{
+{{- range .Errors}}
+	{{.Const}},
+{{- end}}
+}
+
+// Error returns the message registered for c.
+func (c {{.Type}}) Error() string {
+	if msg, ok := {{.Unexported}}Messages[c]; ok {
+		return msg
+	}
+	return {{.Fallback}}
+}
+
+// Sentinel errors for use with errors.Is.
+var (
+{{- range .Errors}}
+	Err{{.Const}} error = {{.Const}}
+{{- end}}
+)
+{{range .Errors}}{{if .Fields}}
+// {{.Const}}Error is the typed error for {{.Const}}.
+type {{.Const}}Error struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+	Err error
+}
+
+func (e *{{.Const}}Error) Error() string {
+	msg := fmt.Sprintf({{printf "%q" .Message}}{{range .Fields}}, e.{{.Name}}{{end}})
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *{{.Const}}Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the {{.Const}} code.
+func (e *{{.Const}}Error) Is(target error) bool {
+	c, ok := target.({{$.Type}})
+	return ok && c == {{.Const}}
+}
+
+// Code returns {{.Const}}.
+func (e *{{.Const}}Error) Code() {{$.Type}} {
+	return {{.Const}}
+}
+{{end}}{{end}}
+// {{.Type}}Of returns the {{.Type}} of err or of any error it wraps.
+func {{.Type}}Of(err error) ({{.Type}}, bool) {
+	var coded interface{ Code() {{.Type}} }
+	if errors.As(err, &coded) {
+		return coded.Code(), true
+	}
+	var c {{.Type}}
+	if errors.As(err, &c) {
+		return c, true
+	}
+	return c, false
+}
+`))
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-errors:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-errors [flags] -type T [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-errors [flags] -type T files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Constants of type T are annotated with\n")
+	fmt.Fprintf(os.Stderr, "\t//gentoolkit:error msg=\"invalid %%s\" fields=Name:string\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-errors: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := constutil.ParsePackage(args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var (
+		defs   []errorDef
+		inputs []string
+		kind   constant.Kind
+	)
+	for _, c := range pkg.OfType(*typeName) {
+		def, err := parseErrorDef(c)
+		if err != nil {
+			log.Fatalf("%s: %s", c.Pos, err)
+		}
+		defs = append(defs, def)
+		inputs = appendUnique(inputs, c.Pos.Filename)
+		kind = c.Value.Kind()
+	}
+	if len(defs) == 0 {
+		log.Fatalf("no constants of type %s found", *typeName)
+	}
+
+	fallback := fmt.Sprintf(`fmt.Sprintf("%s(%%d)", int64(c))`, *typeName)
+	if kind == constant.String {
+		fallback = "string(c)"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-errors %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	err = errorsTemplate.Execute(&buf, map[string]interface{}{
+		"Type":       *typeName,
+		"Unexported": strings.ToLower((*typeName)[:1]) + (*typeName)[1:],
+		"Errors":     defs,
+		"Fallback":   fallback,
+	})
+	if err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, tagutil.Snake.Apply(*typeName)+"_errors.go")
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), inputs...); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseErrorDef reads the error directive of c. Constants without one get a
+// message derived from their name.
+func parseErrorDef(c constutil.Const) (errorDef, error) {
+	def := errorDef{
+		Const:   c.Name,
+		Message: strings.Replace(tagutil.Snake.Apply(c.Name), "_", " ", -1),
+	}
+	d := c.Directive("error")
+	if d == nil {
+		return def, nil
+	}
+	if d.Has("msg") {
+		def.Message = d.Get("msg")
+	}
+	if fields := d.Get("fields"); fields != "" {
+		for _, f := range strings.Split(fields, ",") {
+			nameType := strings.SplitN(f, ":", 2)
+			if len(nameType) != 2 {
+				return def, fmt.Errorf("invalid field %q; want Name:Type", f)
+			}
+			def.Fields = append(def.Fields, errorField{Name: nameType[0], Type: nameType[1]})
+		}
+	}
+	return def, nil
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, l := range list {
+		if l == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,30 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-events
+
+type UserCreated struct {
+	_     struct{} `event:"user.created,v1"`
+	ID    int64    `json:"id"`
+	Email string   `json:"email"`
+}
+
+// UserCreatedV2 adds the time of creation.
+type UserCreatedV2 struct {
+	_       struct{}  `event:"user.created,v2"`
+	ID      int64     `json:"id"`
+	Email   string    `json:"email"`
+	Created time.Time `json:"created"`
+}
+
+type OrderPlaced struct {
+	_       struct{} `event:"order.placed,v1"`
+	OrderID string   `json:"order_id"`
+	Total   int64    `json:"total"`
+}
+
+// Address is not an event.
+type Address struct {
+	Street string
+}
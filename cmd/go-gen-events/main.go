@@ -0,0 +1,240 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+type event struct {
+	Struct  string
+	Type    string
+	Version string
+}
+
+var eventsTemplate = template.Must(template.New("events").Parse(`
+// Event is implemented by the event types of the package, each marked with
+// a field _ struct{} ` + "`" + `event:"<type>,<version>"` + "`" + `.
+type Event interface {
+	EventType() string
+	EventVersion() string
+}
+{{range .Events}}
+// EventType returns {{printf "%q" .Type}}.
+func ({{.Struct}}) EventType() string {
+	return {{printf "%q" .Type}}
+}
+
+// EventVersion returns {{printf "%q" .Version}}.
+func ({{.Struct}}) EventVersion() string {
+	return {{printf "%q" .Version}}
+}
+{{end}}
+// EventTopics lists the event types of the package, the topics to
+// subscribe to.
+var EventTopics = []string{
+{{- range .Topics}}
+	{{printf "%q" .}},
+{{- end}}
+}
+
+// eventTypes returns a new event per "<type>,<version>" discriminator.
+var eventTypes = map[string]func() Event{
+{{- range .Events}}
+	"{{.Type}},{{.Version}}": func() Event { return new({{.Struct}}) },
+{{- end}}
+}
+
+// EventEnvelope is the encoding of an event, with the type discriminators
+// UnmarshalEvent decodes it by.
+type EventEnvelope struct {
+	Type    string          ` + "`" + `json:"type"` + "`" + `
+	Version string          ` + "`" + `json:"version"` + "`" + `
+	Data    json.RawMessage ` + "`" + `json:"data"` + "`" + `
+}
+
+// MarshalEvent encodes e in an EventEnvelope.
+func MarshalEvent(e Event) ([]byte, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&EventEnvelope{Type: e.EventType(), Version: e.EventVersion(), Data: data})
+}
+
+// UnmarshalEvent decodes an envelope written by MarshalEvent into a pointer
+// to the event type it names.
+func UnmarshalEvent(data []byte) (Event, error) {
+	var env EventEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+	newEvent, ok := eventTypes[env.Type+","+env.Version]
+	if !ok {
+		return nil, fmt.Errorf("unknown event %s %s", env.Type, env.Version)
+	}
+	e := newEvent()
+	if err := json.Unmarshal(env.Data, e); err != nil {
+		return nil, fmt.Errorf("event %s %s: %w", env.Type, env.Version, err)
+	}
+	return e, nil
+}
+
+// EventHandler handles each event type of the package.
+type EventHandler interface {
+{{- range .Events}}
+	Handle{{.Struct}}({{if $.Context}}ctx context.Context, {{end}}e *{{.Struct}}) error
+{{- end}}
+}
+
+// DispatchEvent calls the method of h handling e, a value or pointer of an
+// event type of the package.
+func DispatchEvent({{if .Context}}ctx context.Context, {{end}}h EventHandler, e Event) error {
+	switch e := e.(type) {
+{{- range .Events}}
+	case *{{.Struct}}:
+		return h.Handle{{.Struct}}({{if $.Context}}ctx, {{end}}e)
+	case {{.Struct}}:
+		return h.Handle{{.Struct}}({{if $.Context}}ctx, {{end}}&e)
+{{- end}}
+	}
+	return fmt.Errorf("unknown event %T", e)
+}
+
+// EventConsumer decodes the messages of a Kafka or NATS consumer and
+// dispatches them to Handler. Subscriptions pass the payload of each
+// message, such as kafka.Message.Value or nats.Msg.Data, to Consume.
+type EventConsumer struct {
+	Handler EventHandler
+	// Retries is the number of times a failing handler is called again.
+	Retries int
+	// Backoff returns the wait before retry attempt, counting from 1; no
+	// wait if nil.
+	Backoff func(attempt int) time.Duration
+	// OnRetry, if set, is called before each retry with the error of the
+	// previous attempt.
+	OnRetry func(msg []byte, attempt int, err error)
+	// DeadLetter, if set, receives messages that cannot be decoded or whose
+	// handler still fails after all retries, with the error; Consume then
+	// returns its result instead of the error.
+	DeadLetter func(ctx context.Context, msg []byte, err error) error
+}
+
+// Consume decodes msg and dispatches it, retrying and dead-lettering as
+// configured. The message may be acknowledged if the error is nil.
+func (c *EventConsumer) Consume(ctx context.Context, msg []byte) error {
+	e, err := UnmarshalEvent(msg)
+	if err != nil {
+		return c.deadLetter(ctx, msg, err)
+	}
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if c.OnRetry != nil {
+				c.OnRetry(msg, attempt, err)
+			}
+			if c.Backoff != nil {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(c.Backoff(attempt)):
+				}
+			}
+		}
+		if err = DispatchEvent({{if .Context}}ctx, {{end}}c.Handler, e); err == nil {
+			return nil
+		}
+		if attempt >= c.Retries || ctx.Err() != nil {
+			return c.deadLetter(ctx, msg, err)
+		}
+	}
+}
+
+func (c *EventConsumer) deadLetter(ctx context.Context, msg []byte, err error) error {
+	if c.DeadLetter == nil {
+		return err
+	}
+	return c.DeadLetter(ctx, msg, err)
+}
+`))
+
+// eventTag returns the event tag of the _ marker field of info, or nil.
+func eventTag(info *structutil.StructInfo) *structutil.StructFieldInfo {
+	for i := range info.Fields {
+		if field := &info.Fields[i]; field.Name == "_" && field.Tag("event") != "" {
+			return field
+		}
+	}
+	return nil
+}
+
+func generateEvents(pkg *structutil.Package, infos []*structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"%s\"; DO NOT EDIT.\n", structutil.JoinArgs(append([]string{"go-gen-events"}, os.Args[1:]...)))
+	p.Printf("\n")
+	p.Printf("package %s\n", pkg.GetName())
+
+	var (
+		events  []event
+		topics  []string
+		seen    = make(map[string]string)
+		context bool
+	)
+	for _, info := range infos {
+		field := eventTag(info)
+		if field == nil {
+			continue
+		}
+		context = info.ContextFirst()
+		parts := strings.Split(field.Tag("event"), ",")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			p.Fatalf("%s: %s: event tag must be \"<type>,<version>\", got %q", field.Pos, info.Name, field.Tag("event"))
+		}
+		e := event{Struct: info.Name, Type: parts[0], Version: parts[1]}
+		key := e.Type + "," + e.Version
+		if other, ok := seen[key]; ok {
+			p.Fatalf("%s: %s: event %s %s is also %s", field.Pos, info.Name, e.Type, e.Version, other)
+		}
+		seen[key] = info.Name
+		if !contains(topics, e.Type) {
+			topics = append(topics, e.Type)
+		}
+		events = append(events, e)
+	}
+	if len(events) == 0 {
+		p.Fatalf("no struct is marked with _ struct{} `event:\"<type>,<version>\"`")
+	}
+
+	eventsTemplate.Execute(p, map[string]interface{}{
+		"Events":  events,
+		"Topics":  topics,
+		"Context": context,
+	})
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+var generator = structutil.NewForPackageGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-events",
+	FileSuffix:  "events",
+	GoFmtOutput: true,
+}, generateEvents)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
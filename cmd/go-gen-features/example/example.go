@@ -0,0 +1,18 @@
+package example
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-features -type=Features
+
+type Theme string
+
+const (
+	ThemeLight Theme = "light"
+	ThemeDark  Theme = "dark"
+)
+
+type Features struct {
+	NewCheckout bool    `flag:"" description:"Routes checkouts through the new payment flow."`
+	SearchLimit int     `flag:"search-limit" default:"50" description:"Maximum number of search results."`
+	Theme       Theme   `flag:"ui-theme" default:"ThemeLight" description:"Default theme of the web UI."`
+	SampleRate  float64 `flag:"trace-sample-rate" default:"0.1" description:"Share of requests traced, from 0 to 1."`
+	Legacy      bool    `flag:"-"`
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var inventory = flag.Bool("inventory", true, "also write <package>_features.md, a markdown inventory of the flags")
+
+type featureFlag struct {
+	Field       string
+	Key         string
+	Kind        string // Bool, String, Int or Float: the FlagEvaluator method.
+	Type        string // Field type.
+	Default     string // Go literal of the default value.
+	DefaultText string // Default value as tagged.
+	Description string
+}
+
+type featureStruct struct {
+	Name  string
+	Flags []featureFlag
+}
+
+// evalTypes maps the flag kinds to the types FlagEvaluator evaluates
+// them as.
+var evalTypes = map[string]string{"Bool": "bool", "String": "string", "Int": "int64", "Float": "float64"}
+
+var featuresTemplate = template.Must(template.New("features").Funcs(template.FuncMap{
+	"evalType": func(kind string) string { return evalTypes[kind] },
+}).Parse(`
+// FlagEvaluator evaluates feature flags in a context, as an adapter of an
+// OpenFeature or LaunchDarkly client does. On error the flag takes its
+// default.
+type FlagEvaluator interface {
+	BoolFlag(ctx context.Context, key string, def bool) (bool, error)
+	StringFlag(ctx context.Context, key string, def string) (string, error)
+	IntFlag(ctx context.Context, key string, def int64) (int64, error)
+	FloatFlag(ctx context.Context, key string, def float64) (float64, error)
+}
+
+// FlagDefinition describes a feature flag to register with the flag
+// service.
+type FlagDefinition struct {
+	Key         string
+	Type        string // bool, string, int64 or float64.
+	Default     interface{}
+	Description string
+}
+
+// StaticFlagEvaluator evaluates flags from a map of keys to values, such
+// as in tests or local development. Missing flags take their default.
+type StaticFlagEvaluator map[string]interface{}
+{{range $kind := .Kinds}}
+// {{$kind}}Flag returns the value of key, or def if there is none.
+func (e StaticFlagEvaluator) {{$kind}}Flag(ctx context.Context, key string, def {{evalType $kind}}) ({{evalType $kind}}, error) {
+	v, ok := e[key]
+	if !ok {
+		return def, nil
+	}
+	if v, ok := v.({{evalType $kind}}); ok {
+		return v, nil
+	}
+	return def, fmt.Errorf("flag %s: %T is not {{evalType $kind}}", key, v)
+}
+{{end}}
+{{- range .Structs}}
+// {{.Name}}Flags lists the flags of {{.Name}}.
+var {{.Name}}Flags = []FlagDefinition{
+{{- range .Flags}}
+	{Key: {{printf "%q" .Key}}, Type: "{{evalType .Kind}}", Default: {{evalType .Kind}}({{.Default}}), Description: {{printf "%q" .Description}}},
+{{- end}}
+}
+
+// {{.Name}}Client evaluates the flags of {{.Name}}.
+type {{.Name}}Client struct {
+	Evaluator FlagEvaluator
+	// OnError, if set, is called with the errors of evaluations, which
+	// then return the default.
+	OnError func(key string, err error)
+}
+{{$name := .Name}}
+{{- range .Flags}}
+// {{.Field}} returns the flag {{printf "%q" .Key}}{{if .Description}}: {{.Description}}{{end}}
+func (c *{{$name}}Client) {{.Field}}(ctx context.Context) {{.Type}} {
+	v, err := c.Evaluator.{{.Kind}}Flag(ctx, {{printf "%q" .Key}}, {{evalType .Kind}}({{.Default}}))
+	if err != nil {
+		if c.OnError != nil {
+			c.OnError({{printf "%q" .Key}}, err)
+		}
+		return {{.Default}}
+	}
+	return {{.Type}}(v)
+}
+{{end}}
+// Evaluate returns the values of all flags of {{.Name}} in ctx.
+func (c *{{.Name}}Client) Evaluate(ctx context.Context) {{.Name}} {
+	return {{.Name}}{
+	{{- range .Flags}}
+		{{.Field}}: c.{{.Field}}(ctx),
+	{{- end}}
+	}
+}
+{{end}}`))
+
+// kind returns the FlagEvaluator method evaluating flags of type t.
+func kind(t types.Type) (string, bool) {
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return "", false
+	}
+	switch info := b.Info(); {
+	case info&types.IsBoolean != 0:
+		return "Bool", true
+	case info&types.IsString != 0:
+		return "String", true
+	case info&types.IsInteger != 0:
+		return "Int", true
+	case info&types.IsFloat != 0:
+		return "Float", true
+	}
+	return "", false
+}
+
+func writeInventory(p structutil.PrinterWriter, name string, structs []featureStruct, inputs []string) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<!-- Code generated by \"go-gen-features %s\"; DO NOT EDIT. -->\n\n", structutil.JoinArgs(os.Args[1:]))
+	cell := strings.NewReplacer("|", `\|`, "\n", " ").Replace
+	for _, s := range structs {
+		fmt.Fprintf(&buf, "## %s\n\n", s.Name)
+		fmt.Fprintf(&buf, "| Flag | Type | Default | Description | Accessor |\n")
+		fmt.Fprintf(&buf, "| --- | --- | --- | --- | --- |\n")
+		for _, f := range s.Flags {
+			fmt.Fprintf(&buf, "| `%s` | %s | `%s` | %s | `%sClient.%s` |\n", f.Key, evalTypes[f.Kind], cell(f.DefaultText), cell(f.Description), s.Name, f.Field)
+		}
+		fmt.Fprintf(&buf, "\n")
+	}
+	if err := structutil.WriteGenerated(name, buf.Bytes(), inputs...); err != nil {
+		p.Fatalf("writing inventory: %s", err)
+	}
+}
+
+func generateFeatures(pkg *structutil.Package, infos []*structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-features %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", pkg.GetName())
+
+	var (
+		structs []featureStruct
+		inputs  []string
+		dir     string
+	)
+	for _, info := range infos {
+		s := featureStruct{Name: info.Name}
+		for _, field := range info.Fields {
+			if field.Tags == nil {
+				continue
+			}
+			tag, err := field.Tags.Get("flag")
+			if err != nil || tag.Name == "-" {
+				continue
+			}
+			if field.GoType == nil {
+				p.Fatalf("%s: no type information for %s", field.Pos, field.Name)
+			}
+			k, ok := kind(field.GoType)
+			if !ok {
+				p.Fatalf("%s: %s: a flag must be a bool, string, integer or float", field.Pos, field.Name)
+			}
+			f := featureFlag{
+				Field:       field.Name,
+				Key:         tag.Name,
+				Kind:        k,
+				Type:        field.Type,
+				Description: field.Tag("description"),
+			}
+			if f.Key == "" {
+				f.Key = tagutil.Kebab.Apply(field.Name)
+			}
+			f.DefaultText = field.Tag("default")
+			if f.DefaultText == "" {
+				f.DefaultText = map[string]string{"Bool": "false", "String": "", "Int": "0", "Float": "0"}[k]
+			}
+			if f.Default, err = structutil.GoLiteral(f.DefaultText, field.GoType, pkg.GetPath()); err != nil {
+				p.Fatalf("%s: default of %s: %s", field.Pos, field.Name, err)
+			}
+			s.Flags = append(s.Flags, f)
+			dir = filepath.Dir(field.Pos.Filename)
+			if len(inputs) == 0 || inputs[len(inputs)-1] != field.Pos.Filename {
+				inputs = append(inputs, field.Pos.Filename)
+			}
+		}
+		if len(s.Flags) > 0 {
+			structs = append(structs, s)
+		}
+	}
+	if len(structs) == 0 {
+		p.Fatalf("no struct has a field tagged flag")
+	}
+
+	featuresTemplate.Execute(p, map[string]interface{}{
+		"Structs": structs,
+		"Kinds":   []string{"Bool", "String", "Int", "Float"},
+	})
+	if *inventory {
+		writeInventory(p, filepath.Join(dir, pkg.GetName()+"_features.md"), structs, inputs)
+	}
+}
+
+var generator = structutil.NewForPackageGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-features",
+	FileSuffix:  "features",
+	GoFmtOutput: true,
+}, generateFeatures)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
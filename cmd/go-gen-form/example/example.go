@@ -0,0 +1,17 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-form -type=Signup
+
+type Signup struct {
+	Email    string    `form:"email" label:"E-mail address" validate:"required,email"`
+	Name     string    `validate:"required,min=2,max=64"`
+	Age      int       `validate:"gte=18,lte=130"`
+	Plan     string    `validate:"oneof=free pro team"`
+	Budget   float64   `label:"Monthly budget"`
+	Birthday time.Time `form:"birthday"`
+	Remember time.Duration
+	Terms    bool   `label:"I accept the terms" validate:"required"`
+	Internal string `form:"-"`
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"html"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var (
+	action = flag.String("action", "", "action attribute of the generated forms; empty posts to the current URL")
+	submit = flag.String("submit", "Save", "label of the submit button")
+)
+
+type formField struct {
+	Name   string
+	Key    string // Form value name.
+	Decode string // Statements setting dst.<Name> from the string s.
+	Err    string // Expression wrapping err from decoding the field.
+}
+
+var formTemplate = template.Must(template.New("form").Parse(`
+// {{.Struct}}Form renders an HTML form editing a {{.Struct}}, filled in from
+// the *{{.Struct}} it is executed with. Decode{{.Struct}}Form reads the
+// submitted values back.
+var {{.Struct}}Form = template.Must(template.New({{printf "%q" .Name}}).Parse(` + "`" + `{{.HTML}}` + "`" + `))
+
+// Decode{{.Struct}}Form sets the fields of dst from submitted form values.
+// Fields without a value are left alone, except that unchecked checkboxes
+// are false.
+func Decode{{.Struct}}Form(dst *{{.Struct}}, form url.Values) error {
+{{- range .Fields}}
+	{{- if .Err}}
+	if s := form.Get({{printf "%q" .Key}}); s != "" {
+		err := func(s string) error {
+			{{.Decode}}return nil
+		}(s)
+		if err != nil {
+			return {{.Err}}
+		}
+	}
+	{{- else}}
+	{{.Decode}}
+	{{- end}}
+{{- end}}
+	return nil
+}
+`))
+
+// inputType returns the type attribute of the input for values of t and
+// the Go template expression of its value attribute, for the field
+// expression expr.
+func inputType(t types.Type, expr string) (string, string, error) {
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time" {
+		return "datetime-local", fmt.Sprintf(`{{if not %[1]s.IsZero}}{{%[1]s.Format "2006-01-02T15:04"}}{{end}}`, expr), nil
+	}
+	if _, known := structutil.LookupKnownType(t); known || structutil.IsDuration(t) {
+		return "text", "{{" + expr + "}}", nil
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return "", "", fmt.Errorf("type %s has no form input; tag the field form:\"-\"", t)
+	}
+	info := b.Info()
+	switch {
+	case info&types.IsBoolean != 0:
+		return "checkbox", "true", nil
+	case info&types.IsInteger != 0:
+		return "number", "{{" + expr + "}}", nil
+	case info&types.IsFloat != 0:
+		return "number", "{{" + expr + "}}", nil
+	case info&types.IsString != 0:
+		return "text", "{{" + expr + "}}", nil
+	}
+	return "", "", fmt.Errorf("type %s has no form input; tag the field form:\"-\"", t)
+}
+
+// fieldHTML returns the label and input of field, named key, with the
+// validation attributes its validate tag implies.
+func fieldHTML(field structutil.StructFieldInfo, id, key string) (string, error) {
+	expr := "." + field.Name
+	typ, value, err := inputType(field.GoType, expr)
+	if err != nil {
+		return "", err
+	}
+	label := field.Tag("label")
+	if label == "" {
+		label = field.Name
+	}
+	// The markup is emitted as a raw string literal.
+	label = strings.Replace(label, "`", "&#96;", -1)
+	numeric := typ == "number"
+	var attrs []string
+	var options []string
+	if tag := field.TagValue("validate"); tag != nil {
+		for _, o := range tag.Options {
+			switch o.Name {
+			case "required":
+				if typ != "checkbox" {
+					attrs = append(attrs, "required")
+				}
+			case "email":
+				typ = "email"
+			case "url":
+				typ = "url"
+			case "min", "gte":
+				if numeric {
+					attrs = append(attrs, fmt.Sprintf("min=%q", o.Arg()))
+				} else {
+					attrs = append(attrs, fmt.Sprintf("minlength=%q", o.Arg()))
+				}
+			case "max", "lte":
+				if numeric {
+					attrs = append(attrs, fmt.Sprintf("max=%q", o.Arg()))
+				} else {
+					attrs = append(attrs, fmt.Sprintf("maxlength=%q", o.Arg()))
+				}
+			case "len":
+				if !numeric {
+					attrs = append(attrs, fmt.Sprintf("minlength=%q maxlength=%q", o.Arg(), o.Arg()))
+				}
+			case "oneof":
+				options = o.Args
+			}
+		}
+	}
+	if numeric {
+		step := "1"
+		if b, ok := field.GoType.Underlying().(*types.Basic); ok && b.Info()&types.IsFloat != 0 {
+			step = "any"
+		}
+		attrs = append(attrs, fmt.Sprintf("step=%q", step))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n\t<label for=%q>%s</label>", id, html.EscapeString(label))
+	extra := ""
+	if len(attrs) > 0 {
+		extra = " " + strings.Join(attrs, " ")
+	}
+	switch {
+	case len(options) > 0:
+		fmt.Fprintf(&b, "\n\t<select id=%q name=%q%s>", id, key, extra)
+		for _, option := range options {
+			fmt.Fprintf(&b, "\n\t\t<option value=%q{{if eq (print %s) %q}} selected{{end}}>%s</option>", html.EscapeString(option), expr, option, html.EscapeString(option))
+		}
+		b.WriteString("\n\t</select>")
+	case typ == "checkbox":
+		fmt.Fprintf(&b, "\n\t<input id=%q name=%q type=\"checkbox\" value=\"true\"{{if %s}} checked{{end}}%s>", id, key, expr, extra)
+	default:
+		fmt.Fprintf(&b, "\n\t<input id=%q name=%q type=%q value=\"%s\"%s>", id, key, typ, value, extra)
+	}
+	return b.String(), nil
+}
+
+// decodeCode returns statements setting dst.<field> from the string s, and
+// whether they may fail.
+func decodeCode(field structutil.StructFieldInfo, key, pkgPath string) (string, bool, error) {
+	dst := "dst." + field.Name
+	t := field.GoType
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time" {
+		return fmt.Sprintf("t, err := time.Parse(\"2006-01-02T15:04\", s)\nif err != nil {\nreturn err\n}\n%s = t\n", dst), true, nil
+	}
+	if b, ok := t.Underlying().(*types.Basic); ok && b.Info()&types.IsBoolean != 0 {
+		return fmt.Sprintf("%s = form.Get(%q) == \"true\"", dst, key), false, nil
+	}
+	parse, err := structutil.ParseCode("s", dst, t, pkgPath)
+	return parse, true, err
+}
+
+func generateForm(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-form %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+	p.Printf("\n")
+	p.Printf("import \"html/template\"\n")
+
+	prefix := tagutil.Kebab.Apply(info.Name)
+	var fields []formField
+	var markup strings.Builder
+	fmt.Fprintf(&markup, "<form method=\"post\"")
+	if *action != "" {
+		fmt.Fprintf(&markup, " action=%q", html.EscapeString(*action))
+	}
+	markup.WriteString(">")
+	for _, field := range info.Fields {
+		if field.Embedded || !ast.IsExported(field.Name) {
+			continue
+		}
+		if field.GoType == nil {
+			p.Fatalf("%s: no type information for %s", field.Pos, field.Name)
+		}
+		key, ok := info.EffectiveName(field, "form")
+		if !ok {
+			continue
+		}
+		if _, cased := info.TagCasing("form"); !cased && strings.Split(field.Tag("form"), ",")[0] == "" {
+			key = tagutil.Snake.Apply(field.Name)
+		}
+		input, err := fieldHTML(field, prefix+"-"+tagutil.Kebab.Apply(field.Name), key)
+		if err != nil {
+			p.Fatalf("%s: %s: %s", field.Pos, field.Name, err)
+		}
+		markup.WriteString(input)
+		decode, fallible, err := decodeCode(field, key, info.Package.GetPath())
+		if err != nil {
+			p.Fatalf("%s: %s: %s; tag the field form:\"-\"", field.Pos, field.Name, err)
+		}
+		f := formField{Name: field.Name, Key: key, Decode: decode}
+		if fallible {
+			f.Err = info.WrapError("err", key)
+		}
+		fields = append(fields, f)
+	}
+	fmt.Fprintf(&markup, "\n\t<button type=\"submit\">%s</button>\n</form>\n", html.EscapeString(*submit))
+
+	formTemplate.Execute(p, map[string]interface{}{
+		"Struct": info.Name,
+		"Name":   tagutil.Snake.Apply(info.Name) + "_form",
+		"HTML":   markup.String(),
+		"Fields": fields,
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-form",
+	FileSuffix:  "form",
+	GoFmtOutput: true,
+	SkipEmbedFS: true,
+}, generateForm)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
@@ -0,0 +1,6 @@
+package example
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-fsm -type=OrderState
+
+//gentoolkit:fsm states=Pending,Active,Closed transitions=Pending->Active:Activate,Active->Closed,Pending->Closed
+type OrderState int
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	typeName = flag.String("type", "", "state type name; must be set")
+	output   = flag.String("output", "", "output file name; default srcdir/<type>_fsm.go")
+)
+
+type transition struct {
+	From, To string
+}
+
+// event is a transition method; it may be reachable from several states.
+type event struct {
+	Method string
+	To     string
+	From   []string
+}
+
+type machine struct {
+	Type        string
+	StringBased bool
+	States      []string
+	Transitions []transition
+	Events      []*event
+}
+
+var fsmTemplate = template.Must(template.New("fsm").Funcs(template.FuncMap{
+	"join": strings.Join,
+	// state returns the name of the constant of state s.
+	"state": func(s string) string {
+		return structutil.Naming().ConstName(*typeName, s)
+	},
+}).Parse(`
+// {{.Type}} state diagram:
+//
+//	stateDiagram-v2
+//	    [*] --> {{index .States 0}}
+{{- range .Transitions}}
+//	    {{.From}} --> {{.To}}
+{{- end}}
+const (
+{{- range $i, $s := .States}}
+	{{state $s}} {{$.Type}} = {{if $.StringBased}}{{printf "%q" $s}}{{else}}{{$i}}{{end}}
+{{- end}}
+)
+
+var {{.Type}}Transitions = map[{{.Type}}][]{{.Type}}{
+{{- range $s := .States}}
+	{{state $s}}: { {{- range $.Transitions}}{{if eq .From $s}}{{state .To}}, {{end}}{{end -}} },
+{{- end}}
+}
+
+func (s {{.Type}}) String() string {
+	switch s {
+{{- range .States}}
+	case {{state .}}:
+		return {{printf "%q" .}}
+{{- end}}
+	}
+	return fmt.Sprintf("{{.Type}}(%v)", {{if .StringBased}}string(s){{else}}int64(s){{end}})
+}
+
+// Valid reports whether s is a known state.
+func (s {{.Type}}) Valid() bool {
+	_, ok := {{.Type}}Transitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether next may follow s.
+func (s {{.Type}}) CanTransitionTo(next {{.Type}}) bool {
+	for _, t := range {{.Type}}Transitions[s] {
+		if t == next {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo returns next if it may follow s, or a *{{.Type}}TransitionError.
+func (s {{.Type}}) TransitionTo(next {{.Type}}) ({{.Type}}, error) {
+	if !s.CanTransitionTo(next) {
+		return s, &{{.Type}}TransitionError{From: s, To: next}
+	}
+	return next, nil
+}
+{{range .Events}}
+// {{.Method}} transitions to {{state .To}} from {{join .From ", "}}.
+func (s {{$.Type}}) {{.Method}}() ({{$.Type}}, error) {
+	return s.TransitionTo({{state .To}})
+}
+{{end}}
+// {{.Type}}TransitionError reports an illegal state transition.
+type {{.Type}}TransitionError struct {
+	From, To {{.Type}}
+}
+
+func (e *{{.Type}}TransitionError) Error() string {
+	return fmt.Sprintf("illegal {{.Type}} transition from %s to %s", e.From, e.To)
+}
+`))
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-fsm:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-fsm [flags] -type T [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-fsm [flags] -type T files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "T is annotated with\n")
+	fmt.Fprintf(os.Stderr, "\t//gentoolkit:fsm states=A,B,C transitions=A->B,B->C:Finish\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-fsm: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := structutil.LoadPackage(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	m, input, err := findMachine(pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-fsm %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	if err := fsmTemplate.Execute(&buf, m); err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, tagutil.Snake.Apply(*typeName)+"_fsm.go")
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), input); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// findMachine locates the state type and parses its fsm directive.
+func findMachine(pkg *packages.Package) (*machine, string, error) {
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Name.Name != *typeName {
+					continue
+				}
+				doc := ts.Doc
+				if doc == nil {
+					doc = gd.Doc
+				}
+				d, err := structutil.LookupDirective(doc, "fsm")
+				if err != nil {
+					return nil, "", err
+				}
+				if d == nil {
+					return nil, "", fmt.Errorf("type %s has no //gentoolkit:fsm directive", *typeName)
+				}
+				m, err := parseMachine(d)
+				if err != nil {
+					return nil, "", fmt.Errorf("%s: %s", pkg.Fset.Position(ts.Pos()), err)
+				}
+				if id, ok := ts.Type.(*ast.Ident); ok && id.Name == "string" {
+					m.StringBased = true
+				}
+				return m, pkg.Fset.File(file.Pos()).Name(), nil
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("type %s not found", *typeName)
+}
+
+func parseMachine(d *structutil.Directive) (*machine, error) {
+	m := &machine{Type: *typeName}
+	known := make(map[string]bool)
+	for _, s := range strings.Split(d.Get("states"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			m.States = append(m.States, s)
+			known[s] = true
+		}
+	}
+	if len(m.States) == 0 {
+		return nil, fmt.Errorf("fsm directive lists no states")
+	}
+
+	events := make(map[string]*event)
+	for _, t := range strings.Split(d.Get("transitions"), ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		// A transition is From->To, optionally followed by :Method.
+		method := ""
+		if i := strings.Index(t, ":"); i >= 0 {
+			t, method = t[:i], t[i+1:]
+		}
+		fromTo := strings.SplitN(t, "->", 2)
+		if len(fromTo) != 2 {
+			return nil, fmt.Errorf("invalid transition %q; want From->To[:Method]", t)
+		}
+		tr := transition{From: fromTo[0], To: fromTo[1]}
+		for _, s := range []string{tr.From, tr.To} {
+			if !known[s] {
+				return nil, fmt.Errorf("transition %q refers to unknown state %s", t, s)
+			}
+		}
+		m.Transitions = append(m.Transitions, tr)
+
+		if method == "" {
+			method = "To" + tr.To
+		}
+		e, ok := events[method]
+		if !ok {
+			e = &event{Method: method, To: tr.To}
+			events[method] = e
+			m.Events = append(m.Events, e)
+		}
+		if e.To != tr.To {
+			return nil, fmt.Errorf("method %s leads to both %s and %s", method, e.To, tr.To)
+		}
+		e.From = append(e.From, tr.From)
+	}
+	return m, nil
+}
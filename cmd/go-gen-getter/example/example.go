@@ -7,4 +7,6 @@ import "time"
 type ExampleStruct struct {
 	Field1 time.Time
 	Field2 string
+	Field3 []string          `copy:"true"`
+	Field4 map[string]string `copy:"true"`
 }
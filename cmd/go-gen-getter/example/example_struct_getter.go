@@ -1,10 +1,31 @@
 // Code generated by "go-gen-getter -type=ExampleStruct"; DO NOT EDIT.
+// gentoolkit:stamp version=v0.1.0 input=sha256:5a215f2fd16b86231fcc2d945f887ce703bf64ec07bb81787e712725f5809b8d type=github.com/jakoblorz/go-gentoolkit/cmd/go-gen-getter/example.ExampleStruct
 
 package example
 
+import "time"
+
 func (e *ExampleStruct) GetField1() time.Time {
 	return e.Field1
 }
 func (e *ExampleStruct) GetField2() string {
 	return e.Field2
 }
+func (e *ExampleStruct) GetField3() []string {
+	if e.Field3 == nil {
+		return nil
+	}
+	c := make([]string, len(e.Field3))
+	copy(c, e.Field3)
+	return c
+}
+func (e *ExampleStruct) GetField4() map[string]string {
+	if e.Field4 == nil {
+		return nil
+	}
+	c := make(map[string]string, len(e.Field4))
+	for k, v := range e.Field4 {
+		c[k] = v
+	}
+	return c
+}
@@ -3,29 +3,82 @@ package main
 import (
 	"flag"
 	"os"
+	"path"
 	"strings"
-	"text/template"
 
 	"github.com/jakoblorz/go-gentoolkit/structutil"
 )
 
-var getterTemplate = template.Must(template.New("getter").Parse(`
-func ({{.Receiver}} *{{.Struct}}) Get{{.Field}}() {{.Type}} {
-	return {{.Receiver}}.{{.Field}}
-}`))
+var (
+	copyAll    = flag.Bool("copy", false, "return copies of slice and map fields; per field with the copy:\"true\" tag")
+	optionType = flag.String("option", "", "generic option type returned for pointer fields, such as github.com/samber/mo.Option; opt out per field with the option:\"false\" tag")
+	optionSome = flag.String("option-some", "Some", "function of the -option package returning a present option")
+	optionNone = flag.String("option-none", "None", "generic function of the -option package returning an absent option")
+)
+
+// copyKind returns "slice" or "map" if the getter for field returns a copy.
+func copyKind(field structutil.StructFieldInfo) string {
+	switch field.Tag("copy") {
+	case "true":
+	case "false":
+		return ""
+	default:
+		if !*copyAll {
+			return ""
+		}
+	}
+	switch {
+	case field.IsSlice():
+		return "slice"
+	case field.IsMap():
+		return "map"
+	}
+	return ""
+}
+
+// optionElem returns the element type of a pointer field whose getter
+// returns an option, or "".
+func optionElem(field structutil.StructFieldInfo) string {
+	if *optionType == "" || field.Tag("option") == "false" || field.NullStrategy() != structutil.NullPointer {
+		return ""
+	}
+	return strings.TrimPrefix(field.Type, "*")
+}
+
+// optionPackage splits -option into its import path and package-qualified
+// type name, such as github.com/samber/mo and mo.Option.
+func optionPackage(p structutil.PrinterWriter) (string, string) {
+	i := strings.LastIndex(*optionType, ".")
+	if i <= 0 || i < strings.LastIndex(*optionType, "/") {
+		p.Fatalf("-option %q must be an import path and type name, such as github.com/samber/mo.Option", *optionType)
+	}
+	importPath := (*optionType)[:i]
+	return importPath, path.Base(importPath) + (*optionType)[i:]
+}
 
 func generateGetter(info *structutil.StructInfo, p structutil.PrinterWriter) {
-	p.Printf("// Code generated by \"go-gen-getter %s\"; DO NOT EDIT.\n", strings.Join(os.Args[1:], " "))
+	p.Printf("// Code generated by \"go-gen-getter %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
 	p.Printf("\n")
 	p.Printf("package %s\n", info.Package.GetName())
 	p.Printf("\n\n")
+	var option, pkg string
+	if *optionType != "" {
+		var importPath string
+		importPath, option = optionPackage(p)
+		pkg = path.Base(importPath)
+		// Unused imports are removed when formatting.
+		p.Printf("import %q\n", importPath)
+	}
 	for _, field := range info.Fields {
-		getterTemplate.Execute(p, map[string]string{
-			"Receiver": strings.ToLower(info.Name[0:1]),
-			"Struct":   info.Name,
-			"Field":    field.Name,
-			"Type":     field.Type,
-		})
+		p.Printf("\n")
+		p.Annotate(field)
+		snippet := info.Snippet(field, "Get")
+		snippet.Copy = copyKind(field)
+		if elem := optionElem(field); elem != "" {
+			snippet.Type, snippet.Elem = option+"["+elem+"]", elem
+			snippet.Some, snippet.None = pkg+"."+*optionSome, pkg+"."+*optionNone
+		}
+		structutil.GetterSnippet.Execute(p, snippet)
 	}
 }
 
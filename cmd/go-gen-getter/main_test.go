@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+func TestGetterSnippetOverride(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/p\n\ngo 1.17\n",
+		"p.go":   "package p\n\ntype A struct {\n\tName string\n}\n",
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	generate := func() string {
+		t.Helper()
+		res, err := generator.Generate(structutil.Options{Dir: dir, Types: []string{"A"}, DryRun: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(res.Files[0].Src)
+	}
+
+	if src := generate(); !strings.Contains(src, "func (a *A) GetName() string {\n\treturn a.Name\n}") {
+		t.Fatalf("default getter missing from\n%s", src)
+	}
+
+	getter := structutil.GetterSnippet
+	t.Cleanup(func() { structutil.GetterSnippet = getter })
+	structutil.GetterSnippet = template.Must(template.New("getter").Parse(`// {{.Method}} returns the {{.Field}} of {{.Receiver}}.
+func ({{.Receiver}} *{{.Struct}}) {{.Method}}() {{.Type}} { return {{.Receiver}}.{{.Field}} }`))
+	if src := generate(); !strings.Contains(src, "// GetName returns the Name of a.\nfunc (a *A) GetName() string { return a.Name }") {
+		t.Fatalf("overridden getter missing from\n%s", src)
+	}
+}
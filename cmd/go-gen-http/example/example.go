@@ -0,0 +1,33 @@
+package example
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-http -iface=Users
+
+type User struct {
+	ID      int64     `json:"id"`
+	Name    string    `json:"name"`
+	Email   string    `json:"email"`
+	Created time.Time `json:"created"`
+}
+
+// Users manages user accounts.
+//
+//gentoolkit:http prefix=/api
+type Users interface {
+	//gentoolkit:http method=GET path=/users/{id}
+	Get(ctx context.Context, id int64) (*User, error)
+	//gentoolkit:http method=GET path=/users/me
+	Me(ctx context.Context) (*User, error)
+	//gentoolkit:http method=GET path=/users
+	List(ctx context.Context, limit int, after time.Duration) ([]*User, int, error)
+	//gentoolkit:http method=POST path=/users status=201
+	Create(ctx context.Context, name, email string) (*User, error)
+	//gentoolkit:http method=PUT path=/users/{id}/email
+	SetEmail(ctx context.Context, id int64, email string) error
+	//gentoolkit:http method=DELETE path=/users/{id}
+	Delete(ctx context.Context, id int64) error
+}
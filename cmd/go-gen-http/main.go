@@ -0,0 +1,649 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/interfaceutil"
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var (
+	ifaceName = flag.String("iface", "", "interface name; must be set")
+	output    = flag.String("output", "", "output file name; default srcdir/<iface>_http.go")
+	ctxFirst  = flag.Bool("ctx", false, "require every method of the interface to take a context.Context first")
+)
+
+type bodyField struct {
+	Field string
+	Type  string
+	JSON  string
+	Var   string // Result variable, for response fields.
+}
+
+// binding sets the argument Var of a method from a path segment or a
+// query parameter.
+type binding struct {
+	Var     string
+	Type    string
+	Name    string // Parameter name, for errors.
+	Segment int    // Index of the path segment; -1 for query parameters.
+	Parse   string // Statements parsing s into Var.
+}
+
+type httpMethod struct {
+	*interfaceutil.Method
+	Verb     string // Such as GET.
+	Const    string // Constant of net/http for Verb, such as MethodGet.
+	Path     string
+	Match    string // Condition matching the split request path.
+	Literals int    // Number of literal path segments.
+	Type     string // Unexported name prefix of the request and response types.
+	Body     []bodyField
+	Bindings []binding
+	Args     string      // Arguments of the call of impl.
+	Vars     []string    // Variables of the results other than the error.
+	Response []bodyField // Set if the method has several results.
+	Status   string
+
+	Recv        string
+	Ctx         string // Context expression for the client request.
+	ClientPath  string // Expression of the request path.
+	ClientQuery string // Statements setting the query parameters.
+	ClientBody  string // Expression of the request body; nil without one.
+	Locals      map[string]string
+	ResultType  string // Type of the single result other than the error.
+	ZeroResults string // Zero values of the results other than the error, each followed by a comma.
+	RespResults string // Results other than the error from the response, each followed by a comma.
+}
+
+var httpTemplate = template.Must(template.New("http").Parse(`
+{{- range .Methods}}{{if .Body}}
+type {{.Type}}Request struct {
+{{- range .Body}}
+	{{.Field}} {{.Type}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+}
+{{end}}{{if .Response}}
+type {{.Type}}Response struct {
+{{- range .Response}}
+	{{.Field}} {{.Type}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+}
+{{end}}{{end}}
+// New{{.Iface}}HTTPHandler returns an http.Handler serving impl on the
+// routes:
+//
+{{- range .Methods}}
+//	{{.Verb}} {{.Path}}
+{{- end}}
+//
+// Errors are written as {"error": "..."} with status 500, or the status
+// returned by an HTTPStatus() int method of the error.
+func New{{.Iface}}HTTPHandler(impl {{.Iface}}) http.Handler {
+	return &{{.Type}}HTTPHandler{impl: impl}
+}
+
+// Register{{.Iface}}Routes registers the handler returned by
+// New{{.Iface}}HTTPHandler with mux for the paths of its routes.
+func Register{{.Iface}}Routes(mux *http.ServeMux, impl {{.Iface}}) {
+	h := New{{.Iface}}HTTPHandler(impl)
+{{- range .Patterns}}
+	mux.Handle({{printf "%q" .}}, h)
+{{- end}}
+}
+
+type {{.Type}}HTTPHandler struct {
+	impl {{.Iface}}
+}
+
+func (h *{{.Type}}HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var allow []string
+{{- range .Routes}}
+	if {{.Match}} {
+		if r.Method == http.{{.Const}} {
+			h.serve{{.Name}}(w, r, path)
+			return
+		}
+		allow = append(allow, http.{{.Const}})
+	}
+{{- end}}
+	if len(allow) > 0 {
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		h.writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	h.writeError(w, http.StatusNotFound, errors.New("not found"))
+}
+{{range .Methods}}
+func (h *{{$.Type}}HTTPHandler) serve{{.Name}}(w http.ResponseWriter, r *http.Request, path []string) {
+{{- if .Body}}
+	var req {{.Type}}Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+{{- end}}
+{{- range .Bindings}}
+	var {{.Var}} {{.Type}}
+	{{- if ge .Segment 0}}
+	if err := func(s string) error {
+		{{.Parse}}return nil
+	}(path[{{.Segment}}]); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Errorf("{{.Name}}: %w", err))
+		return
+	}
+	{{- else}}
+	if s := r.URL.Query().Get({{printf "%q" .Name}}); s != "" {
+		err := func(s string) error {
+			{{.Parse}}return nil
+		}(s)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, fmt.Errorf("{{.Name}}: %w", err))
+			return
+		}
+	}
+	{{- end}}
+{{- end}}
+	{{range .Vars}}{{.}}, {{end}}err := h.impl.{{.Name}}({{.Args}})
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+{{- if .Response}}
+	h.writeJSON(w, {{.Status}}, &{{.Type}}Response{ {{- range $i, $f := .Response}}{{if $i}}, {{end}}{{$f.Field}}: {{$f.Var}}{{end -}} })
+{{- else if .Vars}}
+	h.writeJSON(w, {{.Status}}, {{index .Vars 0}})
+{{- else}}
+	w.WriteHeader({{.Status}})
+{{- end}}
+}
+{{end}}
+func (h *{{.Type}}HTTPHandler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (h *{{.Type}}HTTPHandler) writeError(w http.ResponseWriter, status int, err error) {
+	var coded interface{ HTTPStatus() int }
+	if errors.As(err, &coded) {
+		status = coded.HTTPStatus()
+	}
+	h.writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// {{.Iface}}HTTPError is an error response of a handler created with
+// New{{.Iface}}HTTPHandler.
+type {{.Iface}}HTTPError struct {
+	Status  int
+	Message string
+}
+
+func (e *{{.Iface}}HTTPError) Error() string {
+	return e.Message
+}
+
+// HTTPStatus returns the status of the response, so that handlers passing
+// the error on keep it.
+func (e *{{.Iface}}HTTPError) HTTPStatus() int {
+	return e.Status
+}
+
+// {{.Iface}}HTTPClient implements {{.Iface}} by calling a handler created
+// with New{{.Iface}}HTTPHandler. Error responses are returned as
+// *{{.Iface}}HTTPError.
+type {{.Iface}}HTTPClient struct {
+	// BaseURL is the URL the handler is mounted at, without trailing slash.
+	BaseURL string
+	// HTTPClient is used for requests; http.DefaultClient if nil.
+	HTTPClient *http.Client
+	// Auth, if set, is called with each request before it is sent, for
+	// example to set an Authorization header.
+	Auth func(req *http.Request) error
+	// Retries is the number of times GET, HEAD, PUT and DELETE requests are
+	// retried after transport errors and 429, 502, 503 and 504 responses.
+	Retries int
+	// Backoff is the wait before the first retry, doubled for each further
+	// one; 100ms if zero.
+	Backoff time.Duration
+}
+
+func (c *{{.Iface}}HTTPClient) do(ctx context.Context, method, path string, query url.Values, req, resp interface{}) error {
+	var body []byte
+	if req != nil {
+		var err error
+		if body, err = json.Marshal(req); err != nil {
+			return err
+		}
+	}
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	retries := 0
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		retries = c.Retries
+	}
+	wait := c.Backoff
+	if wait == 0 {
+		wait = 100 * time.Millisecond
+	}
+	for attempt := 0; ; attempt++ {
+		retry, err := c.try(ctx, method, u, body, resp)
+		if !retry || attempt >= retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// try sends a single request and reports whether it may be retried.
+func (c *{{.Iface}}HTTPClient) try(ctx context.Context, method, u string, body []byte, resp interface{}) (bool, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return false, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.Auth != nil {
+		if err := c.Auth(httpReq); err != nil {
+			return false, err
+		}
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode >= 300 {
+		e := &{{.Iface}}HTTPError{Status: httpResp.StatusCode, Message: http.StatusText(httpResp.StatusCode)}
+		var msg struct {
+			Error string ` + "`json:\"error\"`" + `
+		}
+		data, _ := ioutil.ReadAll(io.LimitReader(httpResp.Body, 4096))
+		if json.Unmarshal(data, &msg) == nil && msg.Error != "" {
+			e.Message = msg.Error
+		}
+		switch httpResp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true, e
+		}
+		return false, e
+	}
+	if resp == nil {
+		return false, nil
+	}
+	return false, json.NewDecoder(httpResp.Body).Decode(resp)
+}
+{{range .Methods}}
+func ({{.Recv}} *{{$.Iface}}HTTPClient) {{.Name}}{{.Signature}} {
+{{- if .ClientQuery}}
+	{{.Locals.query}} := make(url.Values)
+	{{.ClientQuery}}
+{{- end}}
+{{- if .Response}}
+	var {{.Locals.resp}} {{.Type}}Response
+{{- else if .Vars}}
+	var {{.Locals.resp}} {{.ResultType}}
+{{- end}}
+	if {{.Locals.err}} := {{.Recv}}.do({{.Ctx}}, http.{{.Const}}, {{.ClientPath}}, {{if .ClientQuery}}{{.Locals.query}}{{else}}nil{{end}}, {{.ClientBody}}, {{if .Vars}}&{{.Locals.resp}}{{else}}nil{{end}}); {{.Locals.err}} != nil {
+		return {{.ZeroResults}}{{.Locals.err}}
+	}
+	return {{.RespResults}}nil
+}
+{{end}}`))
+
+// encodable reports whether values of type t can be sent as JSON.
+func encodable(t types.Type) bool {
+	switch u := t.Underlying().(type) {
+	case *types.Chan, *types.Signature:
+		return false
+	case *types.Basic:
+		return u.Kind() != types.UnsafePointer && u.Info()&types.IsComplex == 0
+	case *types.Pointer:
+		return encodable(u.Elem())
+	case *types.Slice:
+		return encodable(u.Elem())
+	case *types.Array:
+		return encodable(u.Elem())
+	case *types.Map:
+		return encodable(u.Elem())
+	}
+	return true
+}
+
+var verbs = map[string]string{
+	"GET":    "MethodGet",
+	"HEAD":   "MethodHead",
+	"POST":   "MethodPost",
+	"PUT":    "MethodPut",
+	"PATCH":  "MethodPatch",
+	"DELETE": "MethodDelete",
+}
+
+var statuses = map[int]string{
+	200: "http.StatusOK",
+	201: "http.StatusCreated",
+	202: "http.StatusAccepted",
+	204: "http.StatusNoContent",
+}
+
+// pathExpr returns a Go expression of the request path with the segments
+// of the route, the parameters of params formatted into their segments.
+func pathExpr(segments []string, params map[int]*interfaceutil.Param) (string, error) {
+	var parts []string
+	literal := ""
+	for i, seg := range segments {
+		p, ok := params[i]
+		if !ok {
+			literal += "/" + seg
+			continue
+		}
+		format, err := structutil.FormatCode(p.Name, p.GoType)
+		if err != nil {
+			return "", fmt.Errorf("parameter %s: %s", p.Name, err)
+		}
+		parts = append(parts, strconv.Quote(literal+"/"), "url.PathEscape("+format+")")
+		literal = ""
+	}
+	if literal != "" || len(parts) == 0 {
+		parts = append(parts, strconv.Quote(literal))
+	}
+	return strings.Join(parts, " + "), nil
+}
+
+// querySet returns statements setting the query parameter of p, unless p
+// is the zero value. Slices are joined with commas, as ParseCode splits
+// them.
+func querySet(p *interfaceutil.Param, locals map[string]string) (string, error) {
+	zero := &structutil.StructFieldInfo{Type: p.Type, GoType: p.GoType}
+	if s, ok := p.GoType.Underlying().(*types.Slice); ok {
+		format, err := structutil.FormatCode("e", s.Elem())
+		if err != nil {
+			return "", err
+		}
+		parts := locals["parts"]
+		return fmt.Sprintf("if len(%[1]s) > 0 {\n%[2]s := make([]string, len(%[1]s))\nfor i, e := range %[1]s {\n%[2]s[i] = %[3]s\n}\n%[4]s.Set(%[5]q, strings.Join(%[2]s, \",\"))\n}\n",
+			p.Name, parts, format, locals["query"], p.Name), nil
+	}
+	format, err := structutil.FormatCode(p.Name, p.GoType)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("if %s {\n%s.Set(%q, %s)\n}\n", zero.NonZeroTest(p.Name), locals["query"], p.Name, format), nil
+}
+
+// coveredPattern reports whether a subtree pattern in patterns other than
+// pattern itself, such as /users/, also matches pattern.
+func coveredPattern(patterns map[string]bool, pattern string) bool {
+	for p := range patterns {
+		if p != pattern && strings.HasSuffix(p, "/") && strings.HasPrefix(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-http:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-http [flags] -iface I [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-http [flags] -iface I files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Routes default to POST /<iface>/<method>; methods set theirs with\n")
+	fmt.Fprintf(os.Stderr, "\t//gentoolkit:http method=GET path=/users/{id} status=200\n")
+	fmt.Fprintf(os.Stderr, "relative to the prefix set with //gentoolkit:http prefix=/api on the interface.\n")
+	fmt.Fprintf(os.Stderr, "Parameters named in the path are bound from it; the others from the query\n")
+	fmt.Fprintf(os.Stderr, "for GET, HEAD and DELETE, and from a JSON body otherwise.\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-http: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *ifaceName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := interfaceutil.ParsePackage(args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	iface := pkg.Lookup(*ifaceName)
+	if iface == nil {
+		log.Fatalf("interface %s not found", *ifaceName)
+	}
+	if *ctxFirst {
+		if err := iface.CheckContext(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	prefix := "/" + tagutil.Kebab.Apply(iface.Name)
+	if d := iface.Directive("http"); d != nil && d.Has("prefix") {
+		prefix = strings.TrimSuffix(d.Get("prefix"), "/")
+	}
+	typePrefix := strings.ToLower(iface.Name[:1]) + iface.Name[1:]
+	patterns := make(map[string]bool)
+	var methods []*httpMethod
+	for _, m := range iface.Methods {
+		hm := &httpMethod{
+			Method: m,
+			Verb:   "POST",
+			Const:  "MethodPost",
+			Path:   prefix + "/" + tagutil.Kebab.Apply(m.Name),
+			Type:   typePrefix + m.Name,
+			Recv:   m.Local("c"),
+			Ctx:    "context.Background()",
+			Locals: make(map[string]string),
+		}
+		if !m.ReturnsError() {
+			log.Fatalf("%s: %s must return an error to report transport failures", m.Pos, m.Name)
+		}
+		for _, name := range []string{"query", "resp", "err", "parts"} {
+			hm.Locals[name] = m.Local(name)
+		}
+		status := 0
+		if d := m.Directive("http"); d != nil {
+			if d.Has("method") {
+				hm.Verb = strings.ToUpper(d.Get("method"))
+				if hm.Const = verbs[hm.Verb]; hm.Const == "" {
+					log.Fatalf("%s: %s: unsupported method %s", m.Pos, m.Name, d.Get("method"))
+				}
+			}
+			if d.Has("path") {
+				hm.Path = prefix + "/" + strings.TrimPrefix(d.Get("path"), "/")
+			}
+			if d.Has("status") {
+				if status, err = strconv.Atoi(d.Get("status")); err != nil || statuses[status] == "" {
+					log.Fatalf("%s: %s: unsupported status %s", m.Pos, m.Name, d.Get("status"))
+				}
+			}
+		}
+
+		params := m.Params
+		var callArgs []string
+		if m.HasContext() {
+			params = params[1:]
+			callArgs = append(callArgs, "r.Context()")
+			hm.Ctx = m.ContextParam()
+		}
+		segments := strings.Split(strings.Trim(hm.Path, "/"), "/")
+		inPath := make(map[string]int)
+		pathParams := make(map[int]*interfaceutil.Param)
+		leading := -1 // Number of literal segments before the first parameter.
+		match := []string{fmt.Sprintf("len(path) == %d", len(segments))}
+		for i, seg := range segments {
+			if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+				inPath[seg[1:len(seg)-1]] = i
+				if leading < 0 {
+					leading = i
+				}
+				continue
+			}
+			match = append(match, fmt.Sprintf("path[%d] == %q", i, seg))
+			hm.Literals++
+		}
+		hm.Match = strings.Join(match, " && ")
+		query := hm.Verb == "GET" || hm.Verb == "HEAD" || hm.Verb == "DELETE"
+		var bodyValues []string
+		for _, p := range params {
+			segment, ok := inPath[p.Name]
+			delete(inPath, p.Name)
+			if ok {
+				pathParams[segment] = p
+			}
+			if !ok && !query {
+				if !encodable(p.GoType) {
+					log.Fatalf("%s: parameter %s of %s cannot be encoded as JSON", m.Pos, p.Name, m.Name)
+				}
+				field := tagutil.Pascal.Apply(p.Name)
+				hm.Body = append(hm.Body, bodyField{Field: field, Type: p.Type, JSON: p.Name})
+				callArgs = append(callArgs, "req."+field)
+				bodyValues = append(bodyValues, field+": "+p.Name)
+				continue
+			}
+			if !ok {
+				segment = -1
+			}
+			b := binding{Var: "arg" + tagutil.Pascal.Apply(p.Name), Type: p.Type, Name: p.Name, Segment: segment}
+			if b.Parse, err = structutil.ParseCode("s", b.Var, p.GoType, pkg.Path); err != nil {
+				log.Fatalf("%s: parameter %s of %s: %s", m.Pos, p.Name, m.Name, err)
+			}
+			hm.Bindings = append(hm.Bindings, b)
+			callArgs = append(callArgs, b.Var)
+			if !ok {
+				set, err := querySet(p, hm.Locals)
+				if err != nil {
+					log.Fatalf("%s: parameter %s of %s: %s", m.Pos, p.Name, m.Name, err)
+				}
+				hm.ClientQuery += set
+			}
+		}
+		for name := range inPath {
+			log.Fatalf("%s: path %s of %s names no parameter %s", m.Pos, hm.Path, m.Name, name)
+		}
+		if m.Variadic {
+			callArgs[len(callArgs)-1] += "..."
+		}
+		hm.Args = strings.Join(callArgs, ", ")
+		hm.ClientQuery = strings.TrimSuffix(hm.ClientQuery, "\n")
+		hm.ClientBody = "nil"
+		if len(hm.Body) > 0 {
+			hm.ClientBody = fmt.Sprintf("&%sRequest{%s}", hm.Type, strings.Join(bodyValues, ", "))
+		}
+		if hm.ClientPath, err = pathExpr(segments, pathParams); err != nil {
+			log.Fatalf("%s: %s: %s", m.Pos, m.Name, err)
+		}
+
+		results := m.Results[:len(m.Results)-1]
+		for i, r := range results {
+			if !encodable(r.GoType) {
+				log.Fatalf("%s: result %d of %s cannot be encoded as JSON", m.Pos, i, m.Name)
+			}
+			hm.Vars = append(hm.Vars, fmt.Sprintf("res%d", i))
+			zero := &structutil.StructFieldInfo{Type: r.Type, GoType: r.GoType}
+			hm.ZeroResults += zero.ZeroValue() + ", "
+			if len(results) > 1 {
+				field := fmt.Sprintf("R%d", i)
+				hm.Response = append(hm.Response, bodyField{Field: field, Type: r.Type, JSON: strings.ToLower(field), Var: hm.Vars[i]})
+				hm.RespResults += hm.Locals["resp"] + "." + field + ", "
+			}
+		}
+		if len(results) == 1 {
+			hm.ResultType = results[0].Type
+			hm.RespResults = hm.Locals["resp"] + ", "
+		}
+		switch {
+		case status != 0:
+		case len(results) == 0:
+			status = 204
+		default:
+			status = 200
+		}
+		hm.Status = statuses[status]
+
+		// ServeMux patterns cover the static prefix of the path.
+		static := "/" + strings.Join(segments, "/")
+		if leading >= 0 {
+			static = strings.TrimSuffix("/"+strings.Join(segments[:leading], "/"), "/") + "/"
+		}
+		patterns[static] = true
+		methods = append(methods, hm)
+	}
+	// Routes with more literal segments win, so /users/me is served before
+	// /users/{id}.
+	routes := append([]*httpMethod(nil), methods...)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Literals > routes[j].Literals
+	})
+	var sortedPatterns []string
+	for pattern := range patterns {
+		if !coveredPattern(patterns, pattern) {
+			sortedPatterns = append(sortedPatterns, pattern)
+		}
+	}
+	sort.Strings(sortedPatterns)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-http %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	err = httpTemplate.Execute(&buf, map[string]interface{}{
+		"Iface":    iface.Name,
+		"Type":     typePrefix,
+		"Methods":  methods,
+		"Routes":   routes,
+		"Patterns": sortedPatterns,
+	})
+	if err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, tagutil.Snake.Apply(iface.Name)+"_http.go")
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), iface.Pos.Filename); err != nil {
+		log.Fatal(err)
+	}
+}
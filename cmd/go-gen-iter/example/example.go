@@ -0,0 +1,21 @@
+package example
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-iter -type=Playlist,Library,Category
+
+type Track struct {
+	Title  string
+	Length int
+}
+
+// Playlist is an ordered list of tracks.
+type Playlist []Track
+
+// Library holds the playlists by name.
+type Library map[string]Playlist
+
+// Category is a node of the category tree.
+type Category struct {
+	Name     string
+	Featured *Category
+	Children []*Category
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"flag"
+	"go/types"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+var sliceTemplate = template.Must(template.New("slice").Parse(`
+// All returns an iterator over the indexes and elements of {{.Receiver}}.
+func ({{.Receiver}} {{.Type}}) All() iter.Seq2[int, {{.Elem}}] {
+	return func(yield func(int, {{.Elem}}) bool) {
+		for i, v := range {{.Receiver}} {
+			if !yield(i, v) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the elements of {{.Receiver}}.
+func ({{.Receiver}} {{.Type}}) Values() iter.Seq[{{.Elem}}] {
+	return func(yield func({{.Elem}}) bool) {
+		for _, v := range {{.Receiver}} {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over the indexes and elements of
+// {{.Receiver}} from the last to the first.
+func ({{.Receiver}} {{.Type}}) Backward() iter.Seq2[int, {{.Elem}}] {
+	return func(yield func(int, {{.Elem}}) bool) {
+		for i := len({{.Receiver}}) - 1; i >= 0; i-- {
+			if !yield(i, {{.Receiver}}[i]) {
+				return
+			}
+		}
+	}
+}
+`))
+
+var mapTemplate = template.Must(template.New("map").Parse(`
+// All returns an iterator over the entries of {{.Receiver}} in no particular
+// order.
+func ({{.Receiver}} {{.Type}}) All() iter.Seq2[{{.Key}}, {{.Elem}}] {
+	return func(yield func({{.Key}}, {{.Elem}}) bool) {
+		for k, v := range {{.Receiver}} {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the keys of {{.Receiver}} in no particular
+// order.
+func ({{.Receiver}} {{.Type}}) Keys() iter.Seq[{{.Key}}] {
+	return func(yield func({{.Key}}) bool) {
+		for k := range {{.Receiver}} {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values of {{.Receiver}} in no
+// particular order.
+func ({{.Receiver}} {{.Type}}) Values() iter.Seq[{{.Elem}}] {
+	return func(yield func({{.Elem}}) bool) {
+		for _, v := range {{.Receiver}} {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+{{- if .Ordered}}
+
+// Sorted returns an iterator over the entries of {{.Receiver}} in
+// ascending order of their keys.
+func ({{.Receiver}} {{.Type}}) Sorted() iter.Seq2[{{.Key}}, {{.Elem}}] {
+	return func(yield func({{.Key}}, {{.Elem}}) bool) {
+		keys := make([]{{.Key}}, 0, len({{.Receiver}}))
+		for k := range {{.Receiver}} {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+		for _, k := range keys {
+			if !yield(k, {{.Receiver}}[k]) {
+				return
+			}
+		}
+	}
+}
+{{- end}}
+`))
+
+var treeTemplate = template.Must(template.New("tree").Parse(`
+// All returns an iterator over {{.Receiver}} and the {{.Type}} values reachable
+// from it through {{range $i, $f := .Fields}}{{if $i}}, {{end}}{{$f.Name}}{{end}}, depth-first with each node before its
+// children.
+func ({{.Receiver}} *{{.Type}}) All() iter.Seq[*{{.Type}}] {
+	return func(yield func(*{{.Type}}) bool) {
+		{{.Receiver}}.yieldAll(yield)
+	}
+}
+
+// yieldAll passes the nodes of All to yield and reports whether to go on.
+func ({{.Receiver}} *{{.Type}}) yieldAll(yield func(*{{.Type}}) bool) bool {
+	if {{.Receiver}} == nil {
+		return true
+	}
+	if !yield({{.Receiver}}) {
+		return false
+	}
+{{- range .Fields}}
+{{- if eq .Kind "pointer"}}
+	if !{{$.Receiver}}.{{.Name}}.yieldAll(yield) {
+		return false
+	}
+{{- else if eq .Kind "slice"}}
+	for i := range {{$.Receiver}}.{{.Name}} {
+		if !{{$.Receiver}}.{{.Name}}[i].yieldAll(yield) {
+			return false
+		}
+	}
+{{- else if eq .Kind "slice-pointer"}}
+	for _, n := range {{$.Receiver}}.{{.Name}} {
+		if !n.yieldAll(yield) {
+			return false
+		}
+	}
+{{- else if eq .Kind "map"}}
+	for _, n := range {{$.Receiver}}.{{.Name}} {
+		// Map values are not addressable; n is a copy.
+		n := n
+		if !n.yieldAll(yield) {
+			return false
+		}
+	}
+{{- else if eq .Kind "map-pointer"}}
+	for _, n := range {{$.Receiver}}.{{.Name}} {
+		if !n.yieldAll(yield) {
+			return false
+		}
+	}
+{{- end}}
+{{- end}}
+	return true
+}
+`))
+
+// treeField is a field of a struct holding more values of the struct.
+type treeField struct {
+	Name string
+	Kind string // pointer, slice, slice-pointer, map or map-pointer.
+}
+
+// nestedKind returns how field t holds values of the named type, or "".
+func nestedKind(t types.Type, pkgPath, name string) string {
+	self := func(t types.Type) bool {
+		named, ok := t.(*types.Named)
+		return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == pkgPath && named.Obj().Name() == name
+	}
+	pointer := func(t types.Type) bool {
+		p, ok := t.(*types.Pointer)
+		return ok && self(p.Elem())
+	}
+	if pointer(t) {
+		return "pointer"
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		if self(u.Elem()) {
+			return "slice"
+		}
+		if pointer(u.Elem()) {
+			return "slice-pointer"
+		}
+	case *types.Map:
+		if self(u.Elem()) {
+			return "map"
+		}
+		if pointer(u.Elem()) {
+			return "map-pointer"
+		}
+	}
+	return ""
+}
+
+// isOrdered reports whether the values of t can be compared with <.
+func isOrdered(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsOrdered != 0
+}
+
+func generateIter(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-iter %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	// Range-over-func iterators need the iter package of Go 1.23.
+	p.Printf("//go:build go1.23\n")
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+	p.Printf("\n")
+
+	data := map[string]interface{}{
+		"Receiver": strings.ToLower(info.Name[0:1]),
+		"Type":     info.Name,
+	}
+	switch {
+	case info.IsMap():
+		data["Key"], data["Elem"] = info.Key.Type, info.Elem.Type
+		data["Ordered"] = isOrdered(info.Key.GoType)
+		if data["Ordered"].(bool) {
+			p.Printf("import (\n\t\"iter\"\n\t\"sort\"\n)\n")
+		} else {
+			p.Printf("import \"iter\"\n")
+		}
+		mapTemplate.Execute(p, data)
+	case info.IsSlice():
+		data["Elem"] = info.Elem.Type
+		p.Printf("import \"iter\"\n")
+		sliceTemplate.Execute(p, data)
+	default:
+		var fields []treeField
+		for _, field := range info.Fields {
+			if field.GoType == nil {
+				continue
+			}
+			if kind := nestedKind(field.GoType, info.Package.GetPath(), info.Name); kind != "" {
+				fields = append(fields, treeField{Name: field.Name, Kind: kind})
+			}
+		}
+		if len(fields) == 0 {
+			p.Fatalf("type %s is neither a defined map or slice type nor has fields holding %s values", info.Name, info.Name)
+		}
+		data["Fields"] = fields
+		p.Printf("import \"iter\"\n")
+		treeTemplate.Execute(p, data)
+	}
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:   "go-gen-iter",
+	FileSuffix: "iter",
+	// The output uses generics, which the formatter of older toolchains
+	// cannot parse; the templates are formatted already.
+	GoFmtOutput: false,
+	Collections: true,
+}, generateIter)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
@@ -0,0 +1,17 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-merge -type=Config
+
+type Config struct {
+	Name     string
+	Port     int  `merge:"ours"`
+	Debug    bool `merge:"theirs"`
+	Timeout  time.Duration
+	Plugins  []string          `merge:"append"`
+	Labels   map[string]string `merge:"append"`
+	Endpoint *string
+	Limits   struct{ CPU, Memory int }
+	Internal string `merge:"-"`
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+type mergeField struct {
+	Name      string
+	Type      string
+	Strategy  string // theirs, ours, append-slice or append-map.
+	OursZero  string
+	TheirsSet string
+}
+
+var mergeTemplate = template.Must(template.New("merge").Parse(`
+// Merge copies the fields of other into {{.Receiver}}. Non-zero fields of
+// other win unless tagged merge:"ours"; merge:"append" fields are
+// concatenated (slices) or combined (maps).
+func ({{.Receiver}} *{{.Struct}}) Merge(other *{{.Struct}}) {
+	if other == nil {
+		return
+	}
+{{- range .Fields}}
+{{- if eq .Strategy "theirs"}}
+	if {{.TheirsSet}} {
+		{{$.Receiver}}.{{.Name}} = other.{{.Name}}
+	}
+{{- else if eq .Strategy "ours"}}
+	if {{.OursZero}} {
+		{{$.Receiver}}.{{.Name}} = other.{{.Name}}
+	}
+{{- else if eq .Strategy "append-slice"}}
+	{{$.Receiver}}.{{.Name}} = append({{$.Receiver}}.{{.Name}}, other.{{.Name}}...)
+{{- else if eq .Strategy "append-map"}}
+	if {{$.Receiver}}.{{.Name}} == nil && len(other.{{.Name}}) > 0 {
+		{{$.Receiver}}.{{.Name}} = make({{.Type}}, len(other.{{.Name}}))
+	}
+	for k, v := range other.{{.Name}} {
+		{{$.Receiver}}.{{.Name}}[k] = v
+	}
+{{- end}}
+{{- end}}
+}
+
+// Merged returns a copy of {{.Receiver}} with other merged into it.
+func ({{.Receiver}} {{.Struct}}) Merged(other *{{.Struct}}) {{.Struct}} {
+{{- range .Fields}}
+{{- if eq .Strategy "append-slice"}}
+	{{$.Receiver}}.{{.Name}} = {{$.Receiver}}.{{.Name}}[:len({{$.Receiver}}.{{.Name}}):len({{$.Receiver}}.{{.Name}})]
+{{- else if eq .Strategy "append-map"}}
+	if {{$.Receiver}}.{{.Name}} != nil {
+		m := make({{.Type}}, len({{$.Receiver}}.{{.Name}}))
+		for k, v := range {{$.Receiver}}.{{.Name}} {
+			m[k] = v
+		}
+		{{$.Receiver}}.{{.Name}} = m
+	}
+{{- end}}
+{{- end}}
+	{{.Receiver}}.Merge(other)
+	return {{.Receiver}}
+}
+`))
+
+func generateMerge(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-merge %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+
+	receiver := strings.ToLower(info.Name[0:1])
+	var fields []mergeField
+	for _, field := range info.Fields {
+		f := mergeField{
+			Name:      field.Name,
+			Type:      field.Type,
+			Strategy:  field.Tag("merge"),
+			OursZero:  field.ZeroTest(receiver + "." + field.Name),
+			TheirsSet: field.NonZeroTest("other." + field.Name),
+		}
+		switch f.Strategy {
+		case "", "theirs":
+			f.Strategy = "theirs"
+		case "ours":
+		case "append":
+			switch {
+			case field.IsSlice():
+				f.Strategy = "append-slice"
+			case field.IsMap():
+				f.Strategy = "append-map"
+			default:
+				p.Fatalf("%s: merge:\"append\" requires a slice or map field", field.Pos)
+			}
+		case "-":
+			continue
+		default:
+			p.Fatalf("%s: unknown merge strategy %q", field.Pos, f.Strategy)
+		}
+		fields = append(fields, f)
+	}
+
+	mergeTemplate.Execute(p, map[string]interface{}{
+		"Receiver": receiver,
+		"Struct":   info.Name,
+		"Fields":   fields,
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-merge",
+	FileSuffix:  "merge",
+	GoFmtOutput: true,
+	SkipEmbedFS: true,
+}, generateMerge)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/interfaceutil"
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var (
+	ifaceName = flag.String("iface", "", "interface name; must be set")
+	output    = flag.String("output", "", "output file name; default srcdir/<iface>_metrics.go")
+	ctxFirst  = flag.Bool("ctx", false, "require every method of the interface to take a context.Context first")
+)
+
+const header = `
+// {{.Iface}}Metrics holds the metrics of instrumented {{.Iface}}s: a duration
+// histogram per method and outcome ("ok" or "error") and an error counter
+// per method.
+`
+
+var prometheusTemplate = template.Must(template.New("prometheus").Parse(header + `type {{.Iface}}Metrics struct {
+	Duration *prometheus.HistogramVec
+	Errors   *prometheus.CounterVec
+}
+
+// New{{.Iface}}Metrics creates {{.Iface}}Metrics and registers them with reg.
+func New{{.Iface}}Metrics(reg prometheus.Registerer) *{{.Iface}}Metrics {
+	m := &{{.Iface}}Metrics{
+		Duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: {{printf "%q" .Namespace}},
+			Subsystem: {{printf "%q" .Subsystem}},
+			Name:      "duration_seconds",
+			Help:      "Duration of {{.Iface}} calls in seconds.",
+			Buckets:   {{.Buckets}},
+		}, []string{"method", "outcome"}),
+		Errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: {{printf "%q" .Namespace}},
+			Subsystem: {{printf "%q" .Subsystem}},
+			Name:      "errors_total",
+			Help:      "Number of {{.Iface}} calls that returned an error.",
+		}, []string{"method"}),
+	}
+	reg.MustRegister(m.Duration, m.Errors)
+	return m
+}
+
+// Instrumented{{.Iface}} records metrics for every call to the wrapped
+// {{.Iface}}.
+type Instrumented{{.Iface}} struct {
+	next    {{.Iface}}
+	metrics *{{.Iface}}Metrics
+
+	// Exemplar, if set, returns the exemplar labels, such as a trace ID, to
+	// attach to the duration observation of a call with the given context.
+	// Methods without a context parameter are observed without exemplar.
+	Exemplar func(ctx context.Context) prometheus.Labels
+}
+
+func (i *Instrumented{{.Iface}}) observe(ctx context.Context, method string, start time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+		i.metrics.Errors.WithLabelValues(method).Inc()
+	}
+	elapsed := time.Since(start).Seconds()
+	observer := i.metrics.Duration.WithLabelValues(method, outcome)
+	if ctx != nil && i.Exemplar != nil {
+		if e, ok := observer.(prometheus.ExemplarObserver); ok {
+			e.ObserveWithExemplar(elapsed, i.Exemplar(ctx))
+			return
+		}
+	}
+	observer.Observe(elapsed)
+}
+` + methods))
+
+var otelTemplate = template.Must(template.New("otel").Parse(header + `type {{.Iface}}Metrics struct {
+	Duration metric.Float64Histogram
+	Errors   metric.Int64Counter
+}
+
+// New{{.Iface}}Metrics creates {{.Iface}}Metrics with meter.
+func New{{.Iface}}Metrics(meter metric.Meter) (*{{.Iface}}Metrics, error) {
+	duration, err := meter.Float64Histogram({{printf "%q" (print .Prefix "duration")}},
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of {{.Iface}} calls."),
+{{- if .Bounds}}
+		metric.WithExplicitBucketBoundaries({{.Bounds}}),
+{{- end}}
+	)
+	if err != nil {
+		return nil, err
+	}
+	errors, err := meter.Int64Counter({{printf "%q" (print .Prefix "errors")}},
+		metric.WithDescription("Number of {{.Iface}} calls that returned an error."),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Iface}}Metrics{Duration: duration, Errors: errors}, nil
+}
+
+// Instrumented{{.Iface}} records metrics for every call to the wrapped
+// {{.Iface}}. Exemplars are taken from the span in the call's context by the
+// SDK.
+type Instrumented{{.Iface}} struct {
+	next    {{.Iface}}
+	metrics *{{.Iface}}Metrics
+}
+
+func (i *Instrumented{{.Iface}}) observe(ctx context.Context, method string, start time.Time, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+		i.metrics.Errors.Add(ctx, 1, metric.WithAttributes(attribute.String("method", method)))
+	}
+	i.metrics.Duration.Record(ctx, time.Since(start).Seconds(),
+		metric.WithAttributes(attribute.String("method", method), attribute.String("outcome", outcome)))
+}
+` + methods))
+
+const methods = `
+// Instrument{{.Iface}} returns next instrumented with metrics.
+func Instrument{{.Iface}}(next {{.Iface}}, metrics *{{.Iface}}Metrics) *Instrumented{{.Iface}} {
+	return &Instrumented{{.Iface}}{next: next, metrics: metrics}
+}
+{{range .Methods}}{{$i := .Local "i"}}
+func ({{$i}} *Instrumented{{$.Iface}}) {{.Name}}{{.Signature}} {
+{{- if index $.Skip .Name}}
+	{{if .Results}}return {{end}}{{$i}}.next.{{.Name}}({{.Args}})
+{{- else}}
+	{{.Local "start"}} := time.Now()
+{{- if .Results}}
+	{{.ResultNames}} := {{$i}}.next.{{.Name}}({{.Args}})
+{{- else}}
+	{{$i}}.next.{{.Name}}({{.Args}})
+{{- end}}
+	{{$i}}.observe({{if .HasContext}}{{.ContextParam}}{{else}}nil{{end}}, {{printf "%q" (index $.Labels .Name)}}, {{.Local "start"}}, {{if .ReturnsError}}{{.ErrorResult}}{{else}}nil{{end}})
+{{- if .Results}}
+	return {{.ResultNames}}
+{{- end}}
+{{- end}}
+}
+{{end}}`
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-metrics:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-metrics [flags] -iface I [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-metrics [flags] -iface I files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "The interface is configured with a directive comment:\n")
+	fmt.Fprintf(os.Stderr, "\t//gentoolkit:metrics backend=prometheus|otel namespace=N subsystem=S buckets=0.01,0.1,1\n")
+	fmt.Fprintf(os.Stderr, "and its methods with //gentoolkit:metrics skip or label=L.\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-metrics: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *ifaceName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := interfaceutil.ParsePackage(args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	iface := pkg.Lookup(*ifaceName)
+	if iface == nil {
+		log.Fatalf("interface %s not found", *ifaceName)
+	}
+	if *ctxFirst {
+		if err := iface.CheckContext(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	config := iface.Directive("metrics")
+	if config == nil {
+		config = &structutil.Directive{Name: "metrics"}
+	}
+	subsystem := config.Get("subsystem")
+	if !config.Has("subsystem") {
+		subsystem = tagutil.Snake.Apply(iface.Name)
+	}
+	var buckets []string
+	if config.Has("buckets") {
+		for _, b := range strings.Split(config.Get("buckets"), ",") {
+			if _, err := strconv.ParseFloat(b, 64); err != nil {
+				log.Fatalf("%s: invalid bucket %q", iface.Pos, b)
+			}
+			buckets = append(buckets, b)
+		}
+	}
+	labels := make(map[string]string, len(iface.Methods))
+	skip := make(map[string]bool)
+	for _, m := range iface.Methods {
+		labels[m.Name] = m.Name
+		if d := m.Directive("metrics"); d != nil {
+			skip[m.Name] = d.Has("skip")
+			if d.Has("label") {
+				labels[m.Name] = d.Get("label")
+			}
+		}
+	}
+
+	var (
+		tmpl    *template.Template
+		imports []string
+		data    = map[string]interface{}{
+			"Iface":   iface.Name,
+			"Methods": iface.Methods,
+			"Labels":  labels,
+			"Skip":    skip,
+		}
+	)
+	switch backend := config.Get("backend"); backend {
+	case "", "prometheus":
+		tmpl = prometheusTemplate
+		imports = []string{"github.com/prometheus/client_golang/prometheus"}
+		data["Namespace"] = config.Get("namespace")
+		data["Subsystem"] = subsystem
+		data["Buckets"] = "prometheus.DefBuckets"
+		if buckets != nil {
+			data["Buckets"] = "[]float64{" + strings.Join(buckets, ", ") + "}"
+		}
+	case "otel":
+		tmpl = otelTemplate
+		imports = []string{"go.opentelemetry.io/otel/attribute", "go.opentelemetry.io/otel/metric"}
+		var prefix []string
+		for _, s := range []string{config.Get("namespace"), subsystem} {
+			if s != "" {
+				prefix = append(prefix, s)
+			}
+		}
+		data["Prefix"] = strings.Join(append(prefix, ""), ".")
+		data["Bounds"] = strings.Join(buckets, ", ")
+	default:
+		log.Fatalf("%s: unknown metrics backend %q", iface.Pos, backend)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-metrics %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	fmt.Fprintf(&buf, "\n")
+	for _, path := range imports {
+		fmt.Fprintf(&buf, "import %q\n", path)
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, tagutil.Snake.Apply(iface.Name)+"_metrics.go")
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), iface.Pos.Filename); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,22 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-migrate -type=User,Post
+
+type User struct {
+	ID        int64     `db:"id,pk,autoincr"`
+	Email     string    `db:"email,unique"`
+	Name      string    `db:"name"`
+	Bio       *string   `db:"bio"`
+	CreatedAt time.Time `db:"created_at"`
+	Password  []byte    `db:"-"`
+}
+
+type Post struct {
+	ID       int64    `db:"id,pk,autoincr"`
+	AuthorID int64    `db:"author_id" index:"posts_author_published_idx"`
+	Title    string   `db:"title"`
+	Tags     []string `db:"tags"`
+	Draft    bool     `db:"draft" index:"posts_author_published_idx"`
+}
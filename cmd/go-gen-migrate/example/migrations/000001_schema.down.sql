@@ -0,0 +1,4 @@
+-- Generated by "go-gen-migrate -type=User,Post"; review before applying.
+
+DROP TABLE "posts";
+DROP TABLE "users";
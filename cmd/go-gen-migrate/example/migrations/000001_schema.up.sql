@@ -0,0 +1,19 @@
+-- Generated by "go-gen-migrate -type=User,Post"; review before applying.
+
+CREATE TABLE "users" (
+	"id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY,
+	"email" text NOT NULL UNIQUE,
+	"name" text NOT NULL,
+	"bio" text,
+	"created_at" timestamptz NOT NULL,
+	PRIMARY KEY ("id")
+);
+CREATE TABLE "posts" (
+	"id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY,
+	"author_id" bigint NOT NULL,
+	"title" text NOT NULL,
+	"tags" jsonb NOT NULL,
+	"draft" boolean NOT NULL,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX "posts_author_published_idx" ON "posts" ("author_id", "draft");
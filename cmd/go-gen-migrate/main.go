@@ -0,0 +1,466 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/types"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/typemap"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of type names; default all structs")
+	dialect   = flag.String("dialect", "postgres", "SQL dialect: postgres or mysql")
+	dir       = flag.String("dir", "migrations", "directory of the migrations and the schema snapshot, relative to the package")
+	name      = flag.String("name", "schema", "name of the migration, after its sequence number")
+	dryRun    = flag.Bool("n", false, "print the migration instead of writing it")
+)
+
+// snapshotName is the file in -dir recording the schema the migrations
+// lead to.
+const snapshotName = "schema.json"
+
+type schema struct {
+	Dialect string   `json:"dialect"`
+	Tables  []*table `json:"tables"`
+}
+
+type table struct {
+	Name    string   `json:"name"`
+	Columns []column `json:"columns"`
+	Indexes []index  `json:"indexes,omitempty"`
+}
+
+type column struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	PrimaryKey    bool   `json:"pk,omitempty"`
+	AutoIncrement bool   `json:"autoincr,omitempty"`
+	Unique        bool   `json:"unique,omitempty"`
+	Null          bool   `json:"null,omitempty"`
+}
+
+type index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+func (t *table) column(name string) *column {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+func (t *table) index(name string) *index {
+	for i := range t.Indexes {
+		if t.Indexes[i].Name == name {
+			return &t.Indexes[i]
+		}
+	}
+	return nil
+}
+
+func (t *table) primaryKey() []string {
+	var names []string
+	for _, c := range t.Columns {
+		if c.PrimaryKey {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+func (s *schema) table(name string) *table {
+	for _, t := range s.Tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// buildTable returns the table of a struct.
+func buildTable(info *structutil.StructInfo) (*table, error) {
+	columns, err := info.Columns()
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := structutil.Indexes(columns)
+	if err != nil {
+		return nil, err
+	}
+	t := &table{Name: info.TableName()}
+	for _, c := range columns {
+		if c.Field.GoType == nil {
+			return nil, fmt.Errorf("%s: no type information for %s", c.Field.Pos, c.Field.Name)
+		}
+		overrides, err := typemap.ParseOverrides(c.Field.Tag(typemap.TagKey))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", c.Field.Pos, c.Field.Name, err)
+		}
+		indexed := c.PrimaryKey || c.Unique || c.Index != ""
+		sqlType, null := columnType(c.Field.GoType, indexed, overrides)
+		t.Columns = append(t.Columns, column{
+			Name:          c.Name,
+			Type:          sqlType,
+			PrimaryKey:    c.PrimaryKey,
+			AutoIncrement: c.AutoIncrement,
+			Unique:        c.Unique,
+			Null:          c.Null || null,
+		})
+	}
+	for _, i := range indexes {
+		t.Indexes = append(t.Indexes, index{Name: i.Name, Columns: i.Columns, Unique: i.Unique})
+	}
+	return t, nil
+}
+
+// columnType returns the SQL type of a field of type t in the dialect and
+// whether t itself is nullable: pointers, sql.Null types and other null
+// wrappers store their value's type. indexed columns get types that MySQL
+// can index, unless the field's genmap tag overrides the type.
+func columnType(t types.Type, indexed bool, overrides typemap.Overrides) (string, bool) {
+	strategy, t := structutil.Nullability(t)
+	null := strategy != structutil.NotNull
+	target := typemap.Target(*dialect)
+	if m, ok := overrides[target]; ok {
+		return m.Type, null
+	}
+	m, ok := typemap.Lookup(t, target)
+	if !ok {
+		// Everything else is stored as JSON.
+		m, _ = typemap.LookupName("encoding/json.RawMessage", target)
+	}
+	if indexed && *dialect == "mysql" {
+		// MySQL indexes text and blob columns only by a prefix.
+		switch m.Type {
+		case "text":
+			m.Type = "varchar(255)"
+		case "blob":
+			m.Type = "varbinary(255)"
+		}
+	}
+	return m.Type, null || m.Null
+}
+
+func quote(name string) string {
+	if *dialect == "postgres" {
+		return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+	}
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+func quoteAll(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quote(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// columnDef returns the definition of c in CREATE TABLE and ADD COLUMN.
+func columnDef(c column) string {
+	def := quote(c.Name) + " " + c.Type
+	if !c.Null {
+		def += " NOT NULL"
+	}
+	if c.AutoIncrement {
+		if *dialect == "postgres" {
+			def += " GENERATED BY DEFAULT AS IDENTITY"
+		} else {
+			def += " AUTO_INCREMENT"
+		}
+	}
+	if c.Unique {
+		def += " UNIQUE"
+	}
+	return def
+}
+
+// uniqueName returns the name the dialect gives the inline UNIQUE
+// constraint of a column.
+func uniqueName(t *table, c column) string {
+	if *dialect == "postgres" {
+		return t.Name + "_" + c.Name + "_key"
+	}
+	return c.Name
+}
+
+func createTable(w *bytes.Buffer, t *table) {
+	fmt.Fprintf(w, "CREATE TABLE %s (\n", quote(t.Name))
+	for i, c := range t.Columns {
+		if i > 0 {
+			w.WriteString(",\n")
+		}
+		fmt.Fprintf(w, "\t%s", columnDef(c))
+	}
+	if pk := t.primaryKey(); len(pk) > 0 {
+		fmt.Fprintf(w, ",\n\tPRIMARY KEY (%s)", quoteAll(pk))
+	}
+	w.WriteString("\n);\n")
+	for _, i := range t.Indexes {
+		createIndex(w, t, i)
+	}
+}
+
+func createIndex(w *bytes.Buffer, t *table, i index) {
+	unique := ""
+	if i.Unique {
+		unique = "UNIQUE "
+	}
+	fmt.Fprintf(w, "CREATE %sINDEX %s ON %s (%s);\n", unique, quote(i.Name), quote(t.Name), quoteAll(i.Columns))
+}
+
+func dropIndex(w *bytes.Buffer, t *table, i index) {
+	if *dialect == "postgres" {
+		fmt.Fprintf(w, "DROP INDEX %s;\n", quote(i.Name))
+		return
+	}
+	fmt.Fprintf(w, "DROP INDEX %s ON %s;\n", quote(i.Name), quote(t.Name))
+}
+
+// migrate writes the statements turning the tables of from into those of
+// to, and returns the changes a migration cannot make on its own.
+func migrate(w *bytes.Buffer, from, to *schema) []string {
+	var manual []string
+	for _, t := range to.Tables {
+		old := from.table(t.Name)
+		if old == nil {
+			createTable(w, t)
+			continue
+		}
+		manual = append(manual, alterTable(w, old, t)...)
+	}
+	for i := len(from.Tables) - 1; i >= 0; i-- {
+		if t := from.Tables[i]; to.table(t.Name) == nil {
+			fmt.Fprintf(w, "DROP TABLE %s;\n", quote(t.Name))
+		}
+	}
+	return manual
+}
+
+func alterTable(w *bytes.Buffer, from, to *table) []string {
+	var manual []string
+	alter := func(format string, args ...interface{}) {
+		fmt.Fprintf(w, "ALTER TABLE %s %s;\n", quote(to.Name), fmt.Sprintf(format, args...))
+	}
+
+	if strings.Join(from.primaryKey(), ",") != strings.Join(to.primaryKey(), ",") {
+		manual = append(manual, fmt.Sprintf("table %s: primary key changes from (%s) to (%s)",
+			to.Name, strings.Join(from.primaryKey(), ", "), strings.Join(to.primaryKey(), ", ")))
+	}
+	// Constraints and indexes go first and come back last, so that the
+	// columns in between can change freely.
+	for _, i := range from.Indexes {
+		if n := to.index(i.Name); n == nil || !sameIndex(i, *n) {
+			dropIndex(w, from, i)
+		}
+	}
+	for _, c := range from.Columns {
+		if n := to.column(c.Name); c.Unique && (n == nil || !n.Unique) {
+			if *dialect == "postgres" {
+				alter("DROP CONSTRAINT %s", quote(uniqueName(from, c)))
+			} else {
+				alter("DROP INDEX %s", quote(uniqueName(from, c)))
+			}
+		}
+	}
+	for _, c := range from.Columns {
+		if to.column(c.Name) == nil {
+			alter("DROP COLUMN %s", quote(c.Name))
+		}
+	}
+	for _, c := range to.Columns {
+		old := from.column(c.Name)
+		if old == nil {
+			alter("ADD COLUMN %s", columnDef(c))
+			continue
+		}
+		if old.AutoIncrement != c.AutoIncrement {
+			manual = append(manual, fmt.Sprintf("table %s: column %s changes autoincr", to.Name, c.Name))
+		}
+		if *dialect == "postgres" {
+			if old.Type != c.Type {
+				alter("ALTER COLUMN %s TYPE %s", quote(c.Name), c.Type)
+			}
+			if old.Null != c.Null {
+				action := "SET"
+				if c.Null {
+					action = "DROP"
+				}
+				alter("ALTER COLUMN %s %s NOT NULL", quote(c.Name), action)
+			}
+		} else if old.Type != c.Type || old.Null != c.Null {
+			// MODIFY COLUMN restates the definition; UNIQUE is handled below.
+			def := *old
+			def.Type, def.Null, def.Unique = c.Type, c.Null, false
+			alter("MODIFY COLUMN %s", columnDef(def))
+		}
+	}
+	for _, c := range to.Columns {
+		if old := from.column(c.Name); old != nil && !old.Unique && c.Unique {
+			alter("ADD CONSTRAINT %s UNIQUE (%s)", quote(uniqueName(to, c)), quote(c.Name))
+		}
+	}
+	for _, i := range to.Indexes {
+		if o := from.index(i.Name); o == nil || !sameIndex(i, *o) {
+			createIndex(w, to, i)
+		}
+	}
+	return manual
+}
+
+func sameIndex(a, b index) bool {
+	return a.Unique == b.Unique && strings.Join(a.Columns, ",") == strings.Join(b.Columns, ",")
+}
+
+var migrationFile = regexp.MustCompile(`^(\d+)_.*\.up\.sql$`)
+
+// nextVersion returns the sequence number of the next migration in dir.
+func nextVersion(dir string) (int, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	next := 1
+	for _, e := range entries {
+		if m := migrationFile.FindStringSubmatch(e.Name()); m != nil {
+			if v, _ := strconv.Atoi(m[1]); v >= next {
+				next = v + 1
+			}
+		}
+	}
+	return next, nil
+}
+
+func readSnapshot(name string) (*schema, error) {
+	data, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return &schema{Dialect: *dialect}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	if s.Dialect != *dialect {
+		return nil, fmt.Errorf("%s: schema is for %s, not %s", name, s.Dialect, *dialect)
+	}
+	return &s, nil
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-migrate:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-migrate [flags] [-type T] [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-migrate [flags] [-type T] files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Writes the up and down migrations from the schema recorded in %s to the\n", filepath.Join("<dir>", snapshotName))
+	fmt.Fprintf(os.Stderr, "tables of the structs, and records the new schema. Tables missing from the\n")
+	fmt.Fprintf(os.Stderr, "structs are dropped.\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-migrate: ")
+	flag.Usage = usage
+	flag.Parse()
+	switch *dialect {
+	case "postgres", "mysql":
+	default:
+		log.Fatalf("unknown dialect %q", *dialect)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+	_, infos, err := structutil.LoadStructs(args, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	current := &schema{Dialect: *dialect}
+	for _, info := range infos {
+		t, err := buildTable(info)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if current.table(t.Name) != nil {
+			log.Fatalf("type %s: table %s is declared twice", info.Name, t.Name)
+		}
+		current.Tables = append(current.Tables, t)
+	}
+
+	srcDir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrations := filepath.Join(srcDir, *dir)
+	snapshot := filepath.Join(migrations, snapshotName)
+	previous, err := readSnapshot(snapshot)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	header := fmt.Sprintf("-- Generated by \"go-gen-migrate %s\"; review before applying.\n\n", structutil.JoinArgs(os.Args[1:]))
+	var up, down bytes.Buffer
+	manual := migrate(&up, previous, current)
+	migrate(&down, current, previous)
+	for _, m := range manual {
+		log.Printf("warning: %s; edit the migration by hand", m)
+	}
+	if up.Len() == 0 {
+		log.Printf("schema unchanged")
+		return
+	}
+
+	if *dryRun {
+		fmt.Print(header + up.String())
+		return
+	}
+	version, err := nextVersion(migrations)
+	if err != nil {
+		log.Fatal(err)
+	}
+	snapshotData, err := json.MarshalIndent(current, "", "\t")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.MkdirAll(migrations, 0755); err != nil {
+		log.Fatal(err)
+	}
+	base := filepath.Join(migrations, fmt.Sprintf("%06d_%s", version, *name))
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{base + ".up.sql", []byte(header + up.String())},
+		{base + ".down.sql", []byte(header + down.String())},
+		{snapshot, append(snapshotData, '\n')},
+	} {
+		if err := ioutil.WriteFile(f.name, f.data, 0644); err != nil {
+			log.Fatalf("writing output: %s", err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package example
+
+import "context"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-mock -iface=UserStore
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-mock -iface=UserStore -kind=fake
+
+type User struct {
+	ID    int64 `db:"id,pk,autoincr"`
+	Email string
+	Team  string
+}
+
+type Session struct {
+	Token  string `id:"true"`
+	UserID int64
+}
+
+// UserStore stores users and their sessions.
+type UserStore interface {
+	GetUser(ctx context.Context, id int64) (*User, error)
+	FindUserByEmail(ctx context.Context, email string) (*User, error)
+	ListUsersByTeam(ctx context.Context, team string) ([]*User, error)
+	CreateUser(ctx context.Context, u *User) (int64, error)
+	UpdateUser(ctx context.Context, u *User) error
+	DeleteUser(ctx context.Context, id int64) error
+
+	SaveSession(ctx context.Context, s Session) error
+	LookupSession(ctx context.Context, token string) (Session, bool)
+	AllSessions(ctx context.Context) []Session
+	RemoveSession(ctx context.Context, token string) error
+
+	Ping(ctx context.Context) error
+	Close()
+}
@@ -0,0 +1,611 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/interfaceutil"
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var (
+	ifaceName = flag.String("iface", "", "interface name; must be set")
+	kind      = flag.String("kind", "mock", "test double to generate: mock, panicking on unexpected calls, or fake, storing values in memory")
+	output    = flag.String("output", "", "output file name; default srcdir/<iface>_<kind>.go")
+)
+
+var mockTemplate = template.Must(template.New("mock").Parse(`
+var _ {{.Iface}} = (*Mock{{.Iface}})(nil)
+
+// Mock{{.Iface}} is a strict mock of {{.Iface}}: a call to a method whose
+// <Method>Func is nil panics.
+type Mock{{.Iface}} struct {
+{{- range .Methods}}
+	{{.Name}}Func func{{.Signature}}
+{{- end}}
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+{{range .Methods}}{{$m := .Local "m"}}
+func ({{$m}} *Mock{{$.Iface}}) {{.Name}}{{.Signature}} {
+	{{$m}}.called({{printf "%q" .Name}})
+	if {{$m}}.{{.Name}}Func == nil {
+		panic("unexpected call to Mock{{$.Iface}}.{{.Name}}")
+	}
+	{{if .Results}}return {{end}}{{$m}}.{{.Name}}Func({{.Args}})
+}
+{{end}}
+func (m *Mock{{.Iface}}) called(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.calls == nil {
+		m.calls = make(map[string]int)
+	}
+	m.calls[method]++
+}
+
+// Calls returns how often the method was called.
+func (m *Mock{{.Iface}}) Calls(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls[method]
+}
+`))
+
+var fakeTemplate = template.Must(template.New("fake").Parse(`
+var _ {{.Iface}} = (*Fake{{.Iface}})(nil)
+
+// Errors returned by the methods of Fake{{.Iface}} whose results include an
+// error.
+var (
+	ErrFake{{.Iface}}NotFound = errors.New("fake {{.Iface}}: not found")
+	ErrFake{{.Iface}}Exists   = errors.New("fake {{.Iface}}: already exists")
+)
+
+// Fake{{.Iface}} is an in-memory fake of {{.Iface}}. A method whose
+// <Method>Func is set calls it; otherwise it behaves as its documentation
+// states, inferred from its name and signature. The zero value is empty and
+// ready to use.
+type Fake{{.Iface}} struct {
+{{- range .Methods}}
+	{{.Name}}Func func{{.Signature}}
+{{- end}}
+
+	mu sync.Mutex
+{{- range .Entities}}
+	{{.Store}} []{{.Type}}
+{{- if .AutoKey}}
+	{{.Last}} {{.KeyType}}
+{{- end}}
+{{- end}}
+}
+{{range .Methods}}{{$f := .Local "f"}}
+// {{.Name}} {{.Doc}}
+func ({{$f}} *Fake{{$.Iface}}) {{.Name}}{{.Signature}} {
+	if {{$f}}.{{.Name}}Func != nil {
+{{- if .Results}}
+		return {{$f}}.{{.Name}}Func({{.Args}})
+{{- else}}
+		{{$f}}.{{.Name}}Func({{.Args}})
+		return
+{{- end}}
+	}
+{{- if .Op}}
+	{{$f}}.mu.Lock()
+	defer {{$f}}.mu.Unlock()
+{{- end}}
+{{- if eq (len .Results) 1}}
+	var {{(index .Results 0).Name}} {{(index .Results 0).Type}}
+{{- else if .Results}}
+	var (
+{{- range .Results}}
+		{{.Name}} {{.Type}}
+{{- end}}
+	)
+{{- end}}
+{{- .Body}}
+{{- if .Results}}
+	return {{.ResultNames}}
+{{- end}}
+}
+{{end}}
+{{- range .Entities}}
+func (f *Fake{{$.Iface}}) {{.Index}}(key {{.KeyType}}) int {
+	for i, v := range f.{{.Store}} {
+		if v.{{.Key}} == key {
+			return i
+		}
+	}
+	return -1
+}
+{{end}}`))
+
+// entity is a struct type that a fake stores, keyed by one of its fields.
+type entity struct {
+	Name    string
+	Type    string
+	Key     string
+	KeyType string
+	KeyGo   types.Type
+	AutoKey bool // Zero integer keys are assigned the next key on insert.
+	Store   string
+	Last    string
+	Index   string
+	Obj     *types.TypeName
+	Struct  *types.Struct
+}
+
+type op int
+
+const (
+	opNone op = iota
+	opGet
+	opList
+	opPut
+	opDelete
+)
+
+// fakeMethod is a method of a fake with the behavior inferred for it.
+type fakeMethod struct {
+	*interfaceutil.Method
+	Op   op
+	Doc  string
+	Body string
+}
+
+var (
+	getPrefixes    = []string{"Get", "Find", "Load", "Lookup", "Fetch", "Read"}
+	listPrefixes   = []string{"List", "All", "Find", "Search", "Query"}
+	createPrefixes = []string{"Create", "Insert", "Add"}
+	updatePrefixes = []string{"Update"}
+	putPrefixes    = []string{"Put", "Save", "Store", "Upsert", "Set"}
+	deletePrefixes = []string{"Delete", "Remove"}
+)
+
+// prefix returns the first of prefixes that name starts with as a word,
+// and the rest of name.
+func prefix(name string, prefixes []string) (string, bool) {
+	for _, p := range prefixes {
+		rest := strings.TrimPrefix(name, p)
+		if len(rest) < len(name) && (rest == "" || rest[0] >= 'A' && rest[0] <= 'Z') {
+			return rest, true
+		}
+	}
+	return "", false
+}
+
+// keyField returns the key field of the struct: the field tagged id:"true"
+// or db:",pk", else the field named ID.
+func keyField(s *types.Struct) (*types.Var, bool) {
+	var byName *types.Var
+	for i := 0; i < s.NumFields(); i++ {
+		field, tag := s.Field(i), reflect.StructTag(s.Tag(i))
+		if !field.Exported() {
+			continue
+		}
+		if tag.Get("id") == "true" {
+			return field, true
+		}
+		if db := strings.Split(tag.Get("db"), ","); len(db) > 1 {
+			for _, o := range db[1:] {
+				if o == "pk" {
+					return field, true
+				}
+			}
+		}
+		if field.Name() == "ID" {
+			byName = field
+		}
+	}
+	return byName, byName != nil
+}
+
+// fieldOf returns the exported field of s named name.
+func fieldOf(s *types.Struct, name string) (*types.Var, bool) {
+	for i := 0; i < s.NumFields(); i++ {
+		if f := s.Field(i); f.Name() == name && f.Exported() {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+type fakeBuilder struct {
+	pkgPath  string
+	entities []*entity
+	byName   map[string]*entity
+}
+
+// entityOf returns the entity of t, a named struct or a pointer to one
+// with a comparable key field, and whether t is a pointer.
+func (b *fakeBuilder) entityOf(t types.Type) (*entity, bool, bool) {
+	ptr := false
+	if p, ok := t.(*types.Pointer); ok {
+		t, ptr = p.Elem(), true
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return nil, false, false
+	}
+	s, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return nil, false, false
+	}
+	name := named.Obj().Name()
+	if e, ok := b.byName[name]; ok {
+		return e, ptr, e.Obj == named.Obj()
+	}
+	key, ok := keyField(s)
+	if !ok || !types.Comparable(key.Type()) {
+		return nil, false, false
+	}
+	qualifier := structutil.Qualifier(b.pkgPath)
+	lower := strings.ToLower(name[:1]) + name[1:]
+	e := &entity{
+		Name:    name,
+		Type:    types.TypeString(named, qualifier),
+		Key:     key.Name(),
+		KeyType: types.TypeString(key.Type(), qualifier),
+		KeyGo:   key.Type(),
+		Store:   lower + "s",
+		Last:    "last" + name,
+		Index:   "index" + name,
+		Obj:     named.Obj(),
+		Struct:  s,
+	}
+	if basic, ok := key.Type().Underlying().(*types.Basic); ok && basic.Info()&types.IsInteger != 0 {
+		e.AutoKey = true
+	}
+	b.entities = append(b.entities, e)
+	b.byName[name] = e
+	return e, ptr, true
+}
+
+// named returns the entity that the rest of a method name names, or the
+// only entity.
+func (b *fakeBuilder) named(rest string) *entity {
+	for _, e := range b.entities {
+		if strings.HasPrefix(rest, e.Name) {
+			return e
+		}
+	}
+	if len(b.entities) == 1 {
+		return b.entities[0]
+	}
+	return nil
+}
+
+// args returns the parameters of m after its context.
+func args(m *interfaceutil.Method) []*interfaceutil.Param {
+	if m.HasContext() {
+		return m.Params[1:]
+	}
+	return m.Params
+}
+
+// values returns the results of m before its error.
+func values(m *interfaceutil.Method) []*interfaceutil.Param {
+	if m.ReturnsError() {
+		return m.Results[:len(m.Results)-1]
+	}
+	return m.Results
+}
+
+// fail returns the statement setting the error result of m to err, or ""
+// if m does not return an error.
+func fail(m *interfaceutil.Method, err string) string {
+	if !m.ReturnsError() {
+		return ""
+	}
+	return fmt.Sprintf("\n%s = %s", m.ErrorResult(), err)
+}
+
+// get infers a method returning the stored entity whose key, or the field
+// named after By, equals its single argument.
+func (b *fakeBuilder) get(fm *fakeMethod, iface string) bool {
+	m := fm.Method
+	rest, ok := prefix(m.Name, getPrefixes)
+	in, out := args(m), values(m)
+	found := ""
+	if len(out) == 2 && types.Identical(out[1].GoType, types.Typ[types.Bool]) {
+		found = fmt.Sprintf("\n%s = true", out[1].Name)
+		out = out[:1]
+	}
+	if !ok || len(in) != 1 || len(out) != 1 {
+		return false
+	}
+	e, ptr, ok := b.entityOf(out[0].GoType)
+	if !ok {
+		return false
+	}
+	field := e.Key
+	if i := strings.Index(rest, "By"); i >= 0 {
+		field = rest[i+2:]
+	}
+	f, ok := fieldOf(e.Struct, field)
+	if !ok || !types.Identical(f.Type(), in[0].GoType) || !types.Comparable(f.Type()) {
+		return false
+	}
+	v := m.Local("v")
+	fm.Op = opGet
+	fm.Doc = fmt.Sprintf("returns the stored %s whose %s is %s", e.Name, field, in[0].Name)
+	if found != "" {
+		fm.Doc += ", and whether there is one"
+	}
+	fm.Doc += "."
+	fm.Body = fmt.Sprintf("\nfor _, %[1]s := range %[2]s.%[3]s {\nif %[1]s.%[4]s == %[5]s {%[6]s%[7]s\nreturn %[8]s\n}\n}%[9]s",
+		v, m.Local("f"), e.Store, field, in[0].Name, assign(out[0].Name, v, ptr), found, m.ResultNames(), fail(m, "ErrFake"+iface+"NotFound"))
+	return true
+}
+
+// list infers a method returning the stored entities, those whose field
+// named after By equals its single argument if it has one, and otherwise
+// ignoring its arguments.
+func (b *fakeBuilder) list(fm *fakeMethod) bool {
+	m := fm.Method
+	rest, ok := prefix(m.Name, listPrefixes)
+	in, out := args(m), values(m)
+	if !ok || len(out) != 1 {
+		return false
+	}
+	slice, ok := out[0].GoType.(*types.Slice)
+	if !ok {
+		return false
+	}
+	e, ptr, ok := b.entityOf(slice.Elem())
+	if !ok {
+		return false
+	}
+	v := m.Local("v")
+	filter := ""
+	fm.Doc = fmt.Sprintf("returns the stored %s values in insertion order.", e.Name)
+	if i := strings.Index(rest, "By"); i >= 0 && len(in) == 1 {
+		field := rest[i+2:]
+		f, ok := fieldOf(e.Struct, field)
+		if !ok || !types.Identical(f.Type(), in[0].GoType) || !types.Comparable(f.Type()) {
+			return false
+		}
+		filter = fmt.Sprintf("\nif %s.%s != %s {\ncontinue\n}", v, field, in[0].Name)
+		fm.Doc = fmt.Sprintf("returns the stored %s values whose %s is %s, in insertion order.", e.Name, field, in[0].Name)
+	}
+	value := v
+	if ptr {
+		value = "&" + v
+		filter += fmt.Sprintf("\n%[1]s := %[1]s", v)
+	}
+	fm.Op = opList
+	fm.Body = fmt.Sprintf("\nfor _, %[1]s := range %[2]s.%[3]s {%[4]s\n%[5]s = append(%[5]s, %[6]s)\n}",
+		v, m.Local("f"), e.Store, filter, out[0].Name, value)
+	return true
+}
+
+// assign returns the statements setting the result r to the copy v of a
+// stored entity, or to a pointer to it.
+func assign(r, v string, ptr bool) string {
+	if ptr {
+		return fmt.Sprintf("\n%[1]s := %[1]s\n%[2]s = &%[1]s", v, r)
+	}
+	return fmt.Sprintf("\n%s = %s", r, v)
+}
+
+// put infers a method storing its single argument: Create, Insert and Add
+// fail if its key is stored, Update fails unless it is, and Put, Save,
+// Store, Upsert and Set do either. A zero integer key is assigned the next
+// key. The method may return the stored entity or its key.
+func (b *fakeBuilder) put(fm *fakeMethod, iface string) bool {
+	m := fm.Method
+	in, out := args(m), values(m)
+	create, update := false, false
+	if _, ok := prefix(m.Name, createPrefixes); ok {
+		create = true
+	} else if _, ok := prefix(m.Name, updatePrefixes); ok {
+		update = true
+	} else if _, ok := prefix(m.Name, putPrefixes); !ok {
+		return false
+	}
+	if len(in) != 1 || len(out) > 1 {
+		return false
+	}
+	e, ptr, ok := b.entityOf(in[0].GoType)
+	if !ok {
+		return false
+	}
+	ret := ""
+	if len(out) == 1 {
+		switch re, rptr, ok := b.entityOf(out[0].GoType); {
+		case ok && re == e && rptr:
+			ret = fmt.Sprintf("\n%s = &%s", out[0].Name, m.Local("v"))
+		case ok && re == e:
+			ret = fmt.Sprintf("\n%s = %s", out[0].Name, m.Local("v"))
+		case types.Identical(out[0].GoType, e.KeyGo):
+			ret = fmt.Sprintf("\n%s = %s.%s", out[0].Name, m.Local("v"), e.Key)
+		default:
+			return false
+		}
+	}
+	var body strings.Builder
+	v, i, f := m.Local("v"), m.Local("i"), m.Local("f")
+	if ptr {
+		fmt.Fprintf(&body, "\n%s := *%s", v, in[0].Name)
+	} else {
+		fmt.Fprintf(&body, "\n%s := %s", v, in[0].Name)
+	}
+	fmt.Fprintf(&body, "\n%s := %s.%s(%s.%s)", i, f, e.Index, v, e.Key)
+	insert := fmt.Sprintf("\n%[1]s.%[2]s = append(%[1]s.%[2]s, %[3]s)", f, e.Store, v)
+	if e.AutoKey && !update {
+		cond := fmt.Sprintf("%s.%s == 0", v, e.Key)
+		if !create {
+			cond = i + " < 0 && " + cond
+		}
+		next := fmt.Sprintf("\nif %[1]s {\n%[2]s.%[3]s = %[4]s.%[5]s + 1", cond, v, e.Key, f, e.Last)
+		if ptr {
+			next += fmt.Sprintf("\n%s.%s = %s.%s", in[0].Name, e.Key, v, e.Key)
+		}
+		next += fmt.Sprintf("\n}\nif %[1]s.%[2]s > %[3]s.%[4]s {\n%[3]s.%[4]s = %[1]s.%[2]s\n}", v, e.Key, f, e.Last)
+		if create {
+			insert = next + insert
+		} else {
+			body.WriteString(next)
+		}
+	}
+	switch {
+	case create:
+		fmt.Fprintf(&body, "\nif %s >= 0 {%s\n}%s", i, orReturn(m, "ErrFake"+iface+"Exists"), insert)
+		fm.Doc = fmt.Sprintf("stores %s, unless a %s with its %s is stored.", in[0].Name, e.Name, e.Key)
+	case update:
+		fmt.Fprintf(&body, "\nif %[1]s < 0 {%[2]s\n}\n%[3]s.%[4]s[%[1]s] = %[5]s", i, orReturn(m, "ErrFake"+iface+"NotFound"), f, e.Store, v)
+		fm.Doc = fmt.Sprintf("replaces the stored %s with the %s of %s.", e.Name, e.Key, in[0].Name)
+	default:
+		fmt.Fprintf(&body, "\nif %[1]s < 0 {%[2]s\n} else {\n%[3]s.%[4]s[%[1]s] = %[5]s\n}", i, insert, f, e.Store, v)
+		fm.Doc = fmt.Sprintf("stores %s, replacing the %s with its %s.", in[0].Name, e.Name, e.Key)
+	}
+	if e.AutoKey && !update {
+		fm.Doc += fmt.Sprintf(" A zero %s is assigned the next one.", e.Key)
+	}
+	body.WriteString(ret)
+	fm.Op = opPut
+	fm.Body = body.String()
+	return true
+}
+
+// orReturn returns the statements returning err from m, or its zero
+// results if it does not return an error.
+func orReturn(m *interfaceutil.Method, err string) string {
+	if len(m.Results) == 0 {
+		return "\nreturn"
+	}
+	return fail(m, err) + "\nreturn " + m.ResultNames()
+}
+
+// remove infers a method deleting the stored entity, named by the method
+// or the only one, whose key is its single argument.
+func (b *fakeBuilder) remove(fm *fakeMethod, iface string) bool {
+	m := fm.Method
+	rest, ok := prefix(m.Name, deletePrefixes)
+	in, out := args(m), values(m)
+	if !ok || len(in) != 1 || len(out) != 0 {
+		return false
+	}
+	e := b.named(rest)
+	if e == nil || !types.Identical(e.KeyGo, in[0].GoType) {
+		return false
+	}
+	i, f := m.Local("i"), m.Local("f")
+	fm.Op = opDelete
+	fm.Doc = fmt.Sprintf("deletes the stored %s whose %s is %s.", e.Name, e.Key, in[0].Name)
+	fm.Body = fmt.Sprintf("\n%[1]s := %[2]s.%[3]s(%[4]s)\nif %[1]s < 0 {%[5]s\n}\n%[2]s.%[6]s = append(%[2]s.%[6]s[:%[1]s], %[2]s.%[6]s[%[1]s+1:]...)",
+		i, f, e.Index, in[0].Name, orReturn(m, "ErrFake"+iface+"NotFound"), e.Store)
+	return true
+}
+
+// fakeMethods infers the behavior of the methods of iface. Getters, lists
+// and puts come first, as they name the entities; deletes are keyed by
+// them.
+func fakeMethods(iface *interfaceutil.Interface, pkgPath string) ([]*fakeMethod, []*entity) {
+	b := &fakeBuilder{pkgPath: pkgPath, byName: make(map[string]*entity)}
+	methods := make([]*fakeMethod, len(iface.Methods))
+	for i, m := range iface.Methods {
+		fm := &fakeMethod{Method: m}
+		if !b.get(fm, iface.Name) && !b.list(fm) {
+			b.put(fm, iface.Name)
+		}
+		methods[i] = fm
+	}
+	for _, fm := range methods {
+		if fm.Op == opNone && !b.remove(fm, iface.Name) {
+			fm.Doc = "returns zero values."
+			if len(fm.Results) == 0 {
+				fm.Doc = "does nothing."
+			}
+		}
+	}
+	return methods, b.entities
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-mock:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-mock [flags] -iface I [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-mock [flags] -iface I files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "With -kind=mock, writes Mock<I>, whose methods call its <Method>Func\n")
+	fmt.Fprintf(os.Stderr, "fields and panic if they are nil. With -kind=fake, writes Fake<I>, whose\n")
+	fmt.Fprintf(os.Stderr, "methods store structs in memory by their key field (id:\"true\", db:\",pk\"\n")
+	fmt.Fprintf(os.Stderr, "or ID) as their names suggest:\n")
+	fmt.Fprintf(os.Stderr, "\tGet, Find, Load, Lookup, Fetch, Read: one, by key or By<Field>\n")
+	fmt.Fprintf(os.Stderr, "\tList, All, Find, Search, Query: all, or those By<Field>\n")
+	fmt.Fprintf(os.Stderr, "\tCreate, Insert, Add; Update; Put, Save, Store, Upsert, Set: store one\n")
+	fmt.Fprintf(os.Stderr, "\tDelete, Remove: delete one by key\n")
+	fmt.Fprintf(os.Stderr, "and return zero values otherwise.\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-mock: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *ifaceName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *kind != "mock" && *kind != "fake" {
+		log.Fatalf("unknown kind %q", *kind)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := interfaceutil.ParsePackage(args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	iface := pkg.Lookup(*ifaceName)
+	if iface == nil {
+		log.Fatalf("interface %s not found", *ifaceName)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-mock %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	if *kind == "mock" {
+		err = mockTemplate.Execute(&buf, map[string]interface{}{
+			"Iface":   iface.Name,
+			"Methods": iface.Methods,
+		})
+	} else {
+		methods, entities := fakeMethods(iface, pkg.Path)
+		err = fakeTemplate.Execute(&buf, map[string]interface{}{
+			"Iface":    iface.Name,
+			"Methods":  methods,
+			"Entities": entities,
+		})
+	}
+	if err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, tagutil.Snake.Apply(iface.Name)+"_"+*kind+".go")
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), iface.Pos.Filename); err != nil {
+		log.Fatal(err)
+	}
+}
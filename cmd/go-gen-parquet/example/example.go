@@ -0,0 +1,18 @@
+package example
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-parquet -type=Event
+
+type Event struct {
+	ID         int64
+	Name       string `parquet:"event_name"`
+	Tags       []string
+	Attributes map[string]int32
+	Source     *Source
+	Payload    []byte
+	Debug      string `parquet:"-"`
+}
+
+type Source struct {
+	Host string
+	Port uint16
+}
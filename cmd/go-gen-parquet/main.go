@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+// element is a node of a parquet-go JSON schema.
+type element struct {
+	Tag    string     `json:"Tag"`
+	Fields []*element `json:"Fields,omitempty"`
+}
+
+// tag returns the Tag of an element from its key=value pairs.
+func tag(pairs ...string) string {
+	var parts []string
+	for i := 0; i < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			parts = append(parts, pairs[i]+"="+pairs[i+1])
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parquetType returns the physical and converted type of a Go basic type.
+func parquetType(b *types.Basic) (string, string, bool) {
+	switch b.Kind() {
+	case types.Bool:
+		return "BOOLEAN", "", true
+	case types.Int8:
+		return "INT32", "INT_8", true
+	case types.Int16:
+		return "INT32", "INT_16", true
+	case types.Int32:
+		return "INT32", "", true
+	case types.Uint8:
+		return "INT32", "UINT_8", true
+	case types.Uint16:
+		return "INT32", "UINT_16", true
+	case types.Uint32:
+		return "INT32", "UINT_32", true
+	case types.Int, types.Int64:
+		return "INT64", "", true
+	case types.Uint, types.Uint64:
+		return "INT64", "UINT_64", true
+	case types.Float32:
+		return "FLOAT", "", true
+	case types.Float64:
+		return "DOUBLE", "", true
+	case types.String:
+		return "BYTE_ARRAY", "UTF8", true
+	}
+	return "", "", false
+}
+
+// node returns the schema element of a value of type t named name, read
+// from the Go field inName of the enclosing struct, or "" for list and map
+// members.
+func node(name, inName string, t types.Type) (*element, error) {
+	repetition := "REQUIRED"
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		repetition, t = "OPTIONAL", p.Elem()
+	}
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" {
+		return nil, fmt.Errorf("parquet-go cannot store %s; store it as an int64", t)
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		typ, converted, ok := parquetType(u)
+		if !ok {
+			break
+		}
+		return &element{Tag: tag("name", name, "inname", inName, "type", typ, "convertedtype", converted, "repetitiontype", repetition)}, nil
+	case *types.Slice:
+		if b, ok := u.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Uint8 {
+			return &element{Tag: tag("name", name, "inname", inName, "type", "BYTE_ARRAY", "repetitiontype", repetition)}, nil
+		}
+		elem, err := node("element", "", u.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &element{
+			Tag:    tag("name", name, "inname", inName, "type", "LIST", "repetitiontype", repetition),
+			Fields: []*element{elem},
+		}, nil
+	case *types.Map:
+		key, err := node("key", "", u.Key())
+		if err != nil {
+			return nil, err
+		}
+		value, err := node("value", "", u.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &element{
+			Tag:    tag("name", name, "inname", inName, "type", "MAP", "repetitiontype", repetition),
+			Fields: []*element{key, value},
+		}, nil
+	case *types.Struct:
+		e := &element{Tag: tag("name", name, "inname", inName, "repetitiontype", repetition)}
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			if !f.Exported() {
+				continue
+			}
+			fieldName := tagutil.Snake.Apply(f.Name())
+			if t := reflect.StructTag(u.Tag(i)).Get("parquet"); t != "" {
+				fieldName = strings.Split(t, ",")[0]
+			}
+			if fieldName == "-" {
+				continue
+			}
+			child, err := node(fieldName, f.Name(), f.Type())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name(), err)
+			}
+			e.Fields = append(e.Fields, child)
+		}
+		return e, nil
+	}
+	return nil, fmt.Errorf("type %s has no parquet type; tag the field parquet:\"-\"", t)
+}
+
+var parquetTemplate = template.Must(template.New("parquet").Parse(`
+// ParquetSchema returns the parquet-go JSON schema of {{.Struct}}, for
+// writer.NewParquetWriter and friends.
+func ({{.Struct}}) ParquetSchema() string {
+	return ` + "`" + `{{.Schema}}` + "`" + `
+}
+`))
+
+func generateParquet(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-parquet %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+
+	root := &element{Tag: tag("name", tagutil.Snake.Apply(info.Name), "repetitiontype", "REQUIRED")}
+	for _, f := range info.Fields {
+		if f.Embedded || !ast.IsExported(f.Name) {
+			continue
+		}
+		name := tagutil.Snake.Apply(f.Name)
+		if casing, ok := info.TagCasing("parquet"); ok {
+			name = casing.Apply(f.Name)
+		}
+		if tag := f.TagValue("parquet"); tag != nil && tag.Name != "" {
+			if tag.Name == "-" {
+				continue
+			}
+			name = tag.Name
+		}
+		if f.GoType == nil {
+			p.Fatalf("%s: no type information for %s", f.Pos, f.Name)
+		}
+		e, err := node(name, f.Name, f.GoType)
+		if err != nil {
+			p.Fatalf("%s: %s: %s", f.Pos, f.Name, err)
+		}
+		root.Fields = append(root.Fields, e)
+	}
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		p.Fatalf("encoding schema of %s: %s", info.Name, err)
+	}
+
+	parquetTemplate.Execute(p, map[string]interface{}{
+		"Struct": info.Name,
+		"Schema": string(data),
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-parquet",
+	FileSuffix:  "parquet",
+	GoFmtOutput: true,
+	SkipEmbedFS: true,
+}, generateParquet)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
@@ -0,0 +1,15 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-pii -type=Account
+
+type Account struct {
+	ID        int64     `json:"id"`
+	Email     string    `json:"email" pii:"export,erase"`
+	Name      string    `json:"name" pii:"export,erase"`
+	Birthday  time.Time `json:"birthday" pii:"export,erase"`
+	IPs       []string  `json:"ips" pii:"erase"`
+	Plan      string    `json:"plan" pii:"export"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+type piiField struct {
+	Name string
+	Key  string
+	Zero string
+}
+
+var piiTemplate = template.Must(template.New("pii").Parse(`
+// ExportPersonalData returns the fields of {{.Struct}} tagged pii:"export",
+// keyed by their JSON names, for answering data subject access requests.
+func ({{.Receiver}} *{{.Struct}}) ExportPersonalData() map[string]interface{} {
+	return map[string]interface{}{
+{{- range .Export}}
+		{{printf "%q" .Key}}: {{$.Receiver}}.{{.Name}},
+{{- end}}
+	}
+}
+
+// ErasePersonalData zeroes the fields of {{.Struct}} tagged pii:"erase".
+func ({{.Receiver}} *{{.Struct}}) ErasePersonalData() {
+{{- range .Erase}}
+	{{$.Receiver}}.{{.Name}} = {{.Zero}}
+{{- end}}
+}
+`))
+
+func generatePII(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-pii %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+
+	var export, erase []piiField
+	for _, field := range info.Fields {
+		if field.Tags == nil {
+			continue
+		}
+		tag, err := field.Tags.Get("pii")
+		if err != nil {
+			continue
+		}
+		f := piiField{Name: field.Name, Key: field.Name, Zero: field.ZeroValue()}
+		if json, err := field.Tags.Get("json"); err == nil && json.Name != "" && json.Name != "-" {
+			f.Key = json.Name
+		}
+		for _, mode := range append([]string{tag.Name}, tag.Options...) {
+			switch mode {
+			case "export":
+				export = append(export, f)
+			case "erase":
+				erase = append(erase, f)
+			default:
+				p.Fatalf("%s: %s: unknown pii mode %q", field.Pos, field.Name, mode)
+			}
+		}
+	}
+
+	piiTemplate.Execute(p, map[string]interface{}{
+		"Receiver": strings.ToLower(info.Name[0:1]),
+		"Struct":   info.Name,
+		"Export":   export,
+		"Erase":    erase,
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-pii",
+	FileSuffix:  "pii",
+	GoFmtOutput: true,
+}, generatePII)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
@@ -0,0 +1,24 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-project -type=User
+
+type Audit struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// User is an account.
+//
+//gentoolkit:project PublicUser=ID,Name,Avatar
+//gentoolkit:project UserUpdate=Name,Email AdminUser=-PasswordHash
+type User struct {
+	ID    int64  `json:"id" db:"id,pk"`
+	Name  string `json:"name"`
+	Email string `json:"email"` // Verified address.
+	// Avatar is the URL of the profile picture, if any.
+	Avatar       *string `json:"avatar,omitempty"`
+	PasswordHash []byte  `json:"-"`
+	Audit
+	loginCount int
+}
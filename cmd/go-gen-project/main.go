@@ -0,0 +1,170 @@
+package main
+
+import (
+	"flag"
+	"go/ast"
+	"go/token"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+type projection struct {
+	Name   string
+	Fields []projectedField
+}
+
+type projectedField struct {
+	Name     string
+	Type     string
+	Tag      string
+	Doc      []string
+	Embedded bool
+}
+
+var projectTemplate = template.Must(template.New("project").Parse(`
+{{- range .Projections}}
+// {{.Name}} is a projection of {{$.Struct}} onto some of its fields.
+type {{.Name}} struct {
+{{- range .Fields}}
+{{- range .Doc}}
+	// {{.}}
+{{- end}}
+	{{if not .Embedded}}{{.Name}} {{end}}{{.Type}}{{if .Tag}} ` + "`{{.Tag}}`" + `{{end}}
+{{- end}}
+}
+
+// New{{.Name}} returns the {{.Name}} projection of v.
+func New{{.Name}}(v {{$.Struct}}) {{.Name}} {
+	return {{.Name}}{
+{{- range .Fields}}
+		{{.Name}}: v.{{.Name}},
+{{- end}}
+	}
+}
+
+// To{{$.Struct}} returns the {{$.Struct}} of p, with the fields {{.Name}} leaves
+// out zero.
+func (p {{.Name}}) To{{$.Struct}}() {{$.Struct}} {
+	var v {{$.Struct}}
+	p.ApplyTo(&v)
+	return v
+}
+
+// ApplyTo copies the fields of p into v, leaving the fields {{.Name}} leaves
+// out unchanged.
+func (p {{.Name}}) ApplyTo(v *{{$.Struct}}) {
+{{- range .Fields}}
+	v.{{.Name}} = p.{{.Name}}
+{{- end}}
+}
+{{end}}`))
+
+// project returns the fields of info that the field list of a project
+// directive selects: the named ones, or all exported ones but those
+// named with a leading "-".
+func project(p structutil.PrinterWriter, info *structutil.StructInfo, name, list string) []projectedField {
+	byName := make(map[string]structutil.StructFieldInfo)
+	for _, field := range info.Fields {
+		byName[field.Name] = field
+	}
+	var (
+		names   []string
+		exclude = make(map[string]bool)
+	)
+	for _, n := range strings.Split(list, ",") {
+		n = strings.TrimSpace(n)
+		excluded := strings.HasPrefix(n, "-")
+		n = strings.TrimPrefix(n, "-")
+		if _, ok := byName[n]; !ok {
+			p.Fatalf("type %s: projection %s: no field %s", info.Name, name, n)
+		}
+		if exclude[n] || contains(names, n) {
+			p.Fatalf("type %s: projection %s: %s is listed twice", info.Name, name, n)
+		}
+		if excluded {
+			exclude[n] = true
+		} else {
+			names = append(names, n)
+		}
+	}
+	if len(names) > 0 && len(exclude) > 0 {
+		p.Fatalf("type %s: projection %s: fields are either listed or excluded", info.Name, name)
+	}
+	if len(exclude) > 0 {
+		for _, field := range info.Fields {
+			if ast.IsExported(field.Name) && !exclude[field.Name] {
+				names = append(names, field.Name)
+			}
+		}
+	}
+
+	var fields []projectedField
+	for _, n := range names {
+		field := byName[n]
+		f := projectedField{Name: field.Name, Type: field.Type, Embedded: field.Embedded}
+		if field.Tags != nil {
+			f.Tag = field.Tags.String()
+		}
+		if field.Doc != "" {
+			f.Doc = strings.Split(strings.TrimSuffix(field.Doc, "\n"), "\n")
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func generateProjections(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-project %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+
+	var projections []projection
+	for _, d := range info.Directives {
+		if d.Name != "project" {
+			continue
+		}
+		for _, name := range d.Keys {
+			if !token.IsIdentifier(name) {
+				p.Fatalf("type %s: invalid projection name %q", info.Name, name)
+			}
+			projections = append(projections, projection{Name: name, Fields: project(p, info, name, d.Get(name))})
+		}
+	}
+	if len(projections) == 0 {
+		p.Fatalf("type %s: no //gentoolkit:project directive", info.Name)
+	}
+
+	projectTemplate.Execute(p, map[string]interface{}{
+		"Struct":      info.Name,
+		"Projections": projections,
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-project",
+	FileSuffix:  "project",
+	GoFmtOutput: true,
+}, generateProjections)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+var checkTemplate = template.Must(template.New("check").Parse(`
+// The providers of {{.Name}} as go-gen-providers -check found each of
+// their dependencies provided exactly once. A change to their signatures
+// or fields fails to compile until it checks them again.
+var (
+{{- range .Providers}}
+	_ = {{.Assert}}
+{{- end}}
+)
+`))
+
+func (p *provider) name() string {
+	if p.Func != "" {
+		return p.Func
+	}
+	return p.Struct
+}
+
+// provides returns the types p provides in mode: with fx, fx.As replaces
+// the provided type by the interfaces, while wire.Bind adds them.
+func (p *provider) provides(mode string) []string {
+	if mode == "fx" && len(p.As) > 0 {
+		return p.As
+	}
+	return append([]string{p.Type}, p.As...)
+}
+
+// checkGraph returns the dependencies of providers that are provided not
+// exactly once, by them or by externs, and the cycles among them, as mode
+// (wire or fx) wires them.
+func checkGraph(mode string, providers []provider, externs []string) []error {
+	if mode == "fx" {
+		externs = append(externs, "fx.Lifecycle", "fx.Shutdowner")
+	}
+	var (
+		errs     []error
+		byType   = make(map[string]*provider)
+		external = make(map[string]bool)
+	)
+	for _, t := range externs {
+		external[strings.TrimSpace(t)] = true
+	}
+	for i := range providers {
+		p := &providers[i]
+		for _, t := range p.provides(mode) {
+			switch other, ok := byType[t]; {
+			case ok:
+				errs = append(errs, fmt.Errorf("%s: %s provides %s, as %s does", p.Pos, p.name(), t, other.name()))
+			case external[t]:
+				errs = append(errs, fmt.Errorf("%s: %s provides %s, which -extern provides", p.Pos, p.name(), t))
+			default:
+				byType[t] = p
+			}
+		}
+	}
+	for _, p := range providers {
+		for _, t := range p.Deps {
+			if _, ok := byType[t]; !ok && !external[t] {
+				errs = append(errs, fmt.Errorf("%s: %s needs %s, which is not provided", p.Pos, p.name(), t))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// Depth-first search, reporting each cycle once at the provider
+	// closing it.
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var (
+		path  []string
+		visit func(p *provider)
+	)
+	visit = func(p *provider) {
+		state[p.name()] = visiting
+		path = append(path, p.name())
+		for _, t := range p.Deps {
+			dep, ok := byType[t]
+			if !ok {
+				continue
+			}
+			switch state[dep.name()] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				start := 0
+				for path[start] != dep.name() {
+					start++
+				}
+				cycle := append(append([]string(nil), path[start:]...), dep.name())
+				errs = append(errs, fmt.Errorf("%s: dependency cycle %s", dep.Pos, strings.Join(cycle, " -> ")))
+			}
+		}
+		path = path[:len(path)-1]
+		state[p.name()] = done
+	}
+	for i := range providers {
+		if state[providers[i].name()] == unvisited {
+			visit(&providers[i])
+		}
+	}
+	return errs
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestCheckGraph(t *testing.T) {
+	newFunc := func(name, typ string, deps ...string) provider {
+		return provider{Func: name, Type: typ, Deps: deps, Pos: token.Position{Filename: "p.go", Line: 1}}
+	}
+	tests := []struct {
+		name    string
+		mode    string
+		extern  []string
+		graph   []provider
+		wantErr []string
+	}{
+		{
+			name: "wired",
+			mode: "wire",
+			graph: []provider{
+				newFunc("NewStore", "*Store", "*sql.DB"),
+				newFunc("NewService", "*Service", "*Store"),
+				newFunc("NewHandler", "*Handler", "*Service"),
+			},
+			extern: []string{"*sql.DB"},
+		},
+		{
+			name: "missing",
+			mode: "wire",
+			graph: []provider{
+				newFunc("NewHandler", "*Handler", "*Service"),
+			},
+			wantErr: []string{"NewHandler needs *Service, which is not provided"},
+		},
+		{
+			name: "duplicate",
+			mode: "wire",
+			graph: []provider{
+				newFunc("NewStore", "*Store"),
+				newFunc("NewCachedStore", "*Store"),
+			},
+			wantErr: []string{"NewCachedStore provides *Store, as NewStore does"},
+		},
+		{
+			name: "extern provided twice",
+			mode: "wire",
+			graph: []provider{
+				newFunc("NewDB", "*sql.DB"),
+			},
+			extern:  []string{"*sql.DB"},
+			wantErr: []string{"NewDB provides *sql.DB, which -extern provides"},
+		},
+		{
+			name: "cycle",
+			mode: "wire",
+			graph: []provider{
+				newFunc("NewA", "*A", "*B"),
+				newFunc("NewB", "*B", "*C"),
+				newFunc("NewC", "*C", "*A"),
+			},
+			wantErr: []string{"dependency cycle NewA -> NewB -> NewC -> NewA"},
+		},
+		{
+			name: "bound interface",
+			mode: "wire",
+			graph: []provider{
+				{Func: "NewStore", Type: "*Store", As: []string{"Repository"}},
+				newFunc("NewService", "*Service", "Repository", "*Store"),
+			},
+		},
+		{
+			name: "fx as replaces type",
+			mode: "fx",
+			graph: []provider{
+				{Func: "NewStore", Type: "*Store", As: []string{"Repository"}},
+				newFunc("NewService", "*Service", "*Store", "fx.Lifecycle"),
+			},
+			wantErr: []string{"NewService needs *Store, which is not provided"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range checkGraph(tt.mode, tt.graph, tt.extern) {
+				got = append(got, err.Error())
+			}
+			if len(got) != len(tt.wantErr) {
+				t.Fatalf("checkGraph returned %q, want errors containing %q", got, tt.wantErr)
+			}
+			for i, want := range tt.wantErr {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("error %d = %q, want it to contain %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckGraphReportsEachCycleOnce(t *testing.T) {
+	var graph []provider
+	for i := 0; i < 4; i++ {
+		graph = append(graph, provider{
+			Func: fmt.Sprintf("New%c", 'A'+i),
+			Type: fmt.Sprintf("*%c", 'A'+i),
+			Deps: []string{fmt.Sprintf("*%c", 'A'+(i+1)%4)},
+		})
+	}
+	if errs := checkGraph("wire", graph, nil); len(errs) != 1 {
+		t.Errorf("checkGraph returned %v, want one cycle", errs)
+	}
+}
+
+func TestCollectGroupedTypes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/p\n\ngo 1.17\n",
+		"p.go": `package p
+
+type (
+	// Store stores.
+	//
+	//gentoolkit:provide
+	Store struct{}
+
+	// Service serves.
+	//
+	//gentoolkit:provide
+	Service struct{ Store *Store }
+
+	// Other is not annotated.
+	Other struct{}
+)
+
+type Handler struct{}
+
+func NewHandler(s *Service) *Handler { return &Handler{} }
+`,
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.LoadSyntax, Dir: dir}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	providers, _, err := collect(pkgs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range providers {
+		names = append(names, p.name())
+	}
+	if got, want := strings.Join(names, " "), "Store Service NewHandler"; got != want {
+		t.Fatalf("collect found %s, want %s", got, want)
+	}
+
+	if errs := checkGraph("wire", providers, nil); len(errs) > 0 {
+		t.Errorf("checkGraph returned %v, want none", errs)
+	}
+}
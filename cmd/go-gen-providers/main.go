@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	mode   = flag.String("mode", "wire", "wire (google/wire ProviderSet) or fx (uber/fx Module)")
+	name   = flag.String("name", "", "name of the generated variable; default ProviderSet for wire, Module for fx")
+	output = flag.String("output", "", "output file name; default srcdir/providers.go")
+	check  = flag.Bool("check", false, "check that each dependency is provided exactly once, and write <output>_check.go asserting the checked providers")
+	extern = flag.String("extern", "", "comma-separated list of types provided outside the package, such as *sql.DB, for -check")
+)
+
+// provider is a constructor function or an annotated struct.
+type provider struct {
+	Func   string   // Constructor name; empty for structs.
+	Struct string   // Struct name; empty for constructors.
+	Type   string   // Provided type, *T for structs.
+	As     []string // Interfaces the provided type is bound to.
+	Fields []field  // Exported fields of structs.
+	Pos    token.Position
+	Deps   []string // Types of the parameters or exported fields.
+	Assert string   // Expression of the provider that compiles only as checked.
+}
+
+type field struct {
+	Name string
+	Type string
+}
+
+var wireTemplate = template.Must(template.New("wire").Parse(`
+// {{.Name}} provides the constructors and annotated structs of package
+// {{.Package}}.
+var {{.Name}} = wire.NewSet(
+{{- range .Providers}}
+{{- if .Func}}
+	{{.Func}},
+{{- else}}
+	wire.Struct(new({{.Struct}}){{range .Fields}}, {{printf "%q" .Name}}{{end}}),
+{{- end}}
+{{- $p := .}}
+{{- range .As}}
+	wire.Bind(new({{.}}), new({{$p.Type}})),
+{{- end}}
+{{- end}}
+)
+`))
+
+var fxTemplate = template.Must(template.New("fx").Parse(`
+// {{.Name}} provides the constructors and annotated structs of package
+// {{.Package}}.
+var {{.Name}} = fx.Module({{printf "%q" .Package}},
+	fx.Provide(
+{{- range .Providers}}
+{{- $ctor := .Func}}{{if not $ctor}}{{$ctor = printf "new%sProvider" .Struct}}{{end}}
+{{- if .As}}
+		fx.Annotate({{$ctor}}{{range .As}}, fx.As(new({{.}})){{end}}),
+{{- else}}
+		{{$ctor}},
+{{- end}}
+{{- end}}
+	),
+)
+{{range .Providers}}{{if .Struct}}
+func new{{.Struct}}Provider({{range $i, $f := .Fields}}{{if $i}}, {{end}}p{{$i}} {{$f.Type}}{{end}}) *{{.Struct}} {
+	return &{{.Struct}}{ {{- range $i, $f := .Fields}}{{if $i}}, {{end}}{{$f.Name}}: p{{$i}}{{end -}} }
+}
+{{end}}{{end}}`))
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-providers:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-providers [flags] [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-providers [flags] files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Exported New* functions returning T, (T, error), (T, func()) or\n")
+	fmt.Fprintf(os.Stderr, "(T, func(), error) are providers unless marked //gentoolkit:provide skip.\n")
+	fmt.Fprintf(os.Stderr, "Structs marked //gentoolkit:provide are filled field by field.\n")
+	fmt.Fprintf(os.Stderr, "Either can bind interfaces with //gentoolkit:provide as=Iface1,Iface2.\n")
+	fmt.Fprintf(os.Stderr, "With -check, dependencies provided by none or several of them, other than\n")
+	fmt.Fprintf(os.Stderr, "-extern types, and dependency cycles are errors.\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-providers: ")
+	flag.Usage = usage
+	flag.Parse()
+
+	var (
+		tmpl       *template.Template
+		importPath string
+	)
+	switch *mode {
+	case "wire":
+		tmpl, importPath = wireTemplate, "github.com/google/wire"
+		if *name == "" {
+			*name = "ProviderSet"
+		}
+	case "fx":
+		tmpl, importPath = fxTemplate, "go.uber.org/fx"
+		if *name == "" {
+			*name = "Module"
+		}
+	default:
+		log.Fatalf("unknown mode %q", *mode)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := structutil.LoadPackage(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	providers, inputs, err := collect(pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(providers) == 0 {
+		log.Fatalf("no providers found in %s", pkg.PkgPath)
+	}
+	if *check {
+		var externs []string
+		if *extern != "" {
+			externs = strings.Split(*extern, ",")
+		}
+		if errs := checkGraph(*mode, providers, externs); len(errs) > 0 {
+			for _, err := range errs {
+				log.Print(err)
+			}
+			log.Fatalf("%s does not wire", *name)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-providers %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "import %q\n", importPath)
+	err = tmpl.Execute(&buf, map[string]interface{}{
+		"Name":      *name,
+		"Package":   pkg.Name,
+		"Providers": providers,
+	})
+	if err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, "providers.go")
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), inputs...); err != nil {
+		log.Fatal(err)
+	}
+
+	if *check {
+		buf.Reset()
+		fmt.Fprintf(&buf, "// Code generated by \"go-gen-providers %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+		fmt.Fprintf(&buf, "\n")
+		fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+		err = checkTemplate.Execute(&buf, map[string]interface{}{
+			"Name":      *name,
+			"Providers": providers,
+		})
+		if err != nil {
+			log.Fatalf("generating output: %s", err)
+		}
+		if err := structutil.WriteGenerated(strings.TrimSuffix(outputName, ".go")+"_check.go", buf.Bytes(), inputs...); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// collect returns the providers of pkg in source order and the files
+// declaring them.
+func collect(pkg *packages.Package) ([]provider, []string, error) {
+	var (
+		providers []provider
+		inputs    []string
+	)
+	for _, file := range pkg.Syntax {
+		filename := pkg.Fset.Position(file.Pos()).Filename
+		if _, generated, _ := structutil.ReadHeader(filename); generated {
+			continue
+		}
+		n := len(providers)
+		for _, decl := range file.Decls {
+			for _, c := range candidates(pkg, decl) {
+				d, err := structutil.LookupDirective(c.doc, "provide")
+				if err != nil {
+					return nil, nil, fmt.Errorf("%s: %w", c.Pos, err)
+				}
+				switch {
+				case d != nil && d.Has("skip"):
+					continue
+				case d == nil && c.Struct != "":
+					continue
+				}
+				if d != nil && d.Has("as") {
+					c.As = strings.Split(d.Get("as"), ",")
+					sort.Strings(c.As)
+				}
+				providers = append(providers, c.provider)
+			}
+		}
+		if len(providers) > n {
+			inputs = append(inputs, filename)
+		}
+	}
+	return providers, inputs, nil
+}
+
+// candidate is a possible provider with the doc comment that may carry its
+// provide directive.
+type candidate struct {
+	provider
+	doc *ast.CommentGroup
+}
+
+// candidates returns the constructors and structs declared by decl, one for
+// each spec of a grouped type declaration.
+func candidates(pkg *packages.Package, decl ast.Decl) []candidate {
+	qualifier := structutil.Qualifier(pkg.PkgPath)
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if decl.Recv != nil || !decl.Name.IsExported() || !strings.HasPrefix(decl.Name.Name, "New") {
+			return nil
+		}
+		sig := pkg.TypesInfo.Defs[decl.Name].Type().(*types.Signature)
+		if !isConstructor(sig) {
+			return nil
+		}
+		p := provider{Func: decl.Name.Name, Type: types.TypeString(sig.Results().At(0).Type(), qualifier), Pos: pkg.Fset.Position(decl.Pos())}
+		for i := 0; i < sig.Params().Len(); i++ {
+			if i < sig.Params().Len()-1 || !sig.Variadic() {
+				p.Deps = append(p.Deps, types.TypeString(sig.Params().At(i).Type(), qualifier))
+			}
+		}
+		p.Assert = fmt.Sprintf("(%s)(%s)", types.TypeString(sig, qualifier), decl.Name.Name)
+		return []candidate{{provider: p, doc: decl.Doc}}
+	case *ast.GenDecl:
+		var cs []candidate
+		for _, spec := range decl.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			st, ok := pkg.TypesInfo.Defs[ts.Name].Type().Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+			doc := ts.Doc
+			if doc == nil && !decl.Lparen.IsValid() {
+				doc = decl.Doc
+			}
+			p := provider{Struct: ts.Name.Name, Type: "*" + ts.Name.Name, Pos: pkg.Fset.Position(ts.Pos())}
+			// The unkeyed literal lists all fields, so that a new field
+			// fails to compile as well.
+			var params, values []string
+			for i := 0; i < st.NumFields(); i++ {
+				f := st.Field(i)
+				t := types.TypeString(f.Type(), qualifier)
+				if f.Exported() {
+					p.Fields = append(p.Fields, field{Name: f.Name(), Type: t})
+					p.Deps = append(p.Deps, t)
+				}
+				params = append(params, fmt.Sprintf("p%d %s", i, t))
+				values = append(values, fmt.Sprintf("p%d", i))
+			}
+			p.Assert = fmt.Sprintf("func(%s) *%s {\n\t\treturn &%[2]s{%s}\n\t}", strings.Join(params, ", "), ts.Name.Name, strings.Join(values, ", "))
+			cs = append(cs, candidate{provider: p, doc: doc})
+		}
+		return cs
+	}
+	return nil
+}
+
+// isConstructor reports whether sig returns T, (T, error), (T, func()) or
+// (T, func(), error).
+func isConstructor(sig *types.Signature) bool {
+	results := sig.Results()
+	isError := func(t types.Type) bool {
+		return types.Identical(t, types.Universe.Lookup("error").Type())
+	}
+	isCleanup := func(t types.Type) bool {
+		s, ok := t.(*types.Signature)
+		return ok && s.Params().Len() == 0 && s.Results().Len() == 0
+	}
+	switch results.Len() {
+	case 1:
+		return !isError(results.At(0).Type())
+	case 2:
+		return isError(results.At(1).Type()) || isCleanup(results.At(1).Type())
+	case 3:
+		return isCleanup(results.At(1).Type()) && isError(results.At(2).Type())
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of type names; default all structs")
+	order     = flag.String("order", "alpha", "field order: alpha, size (minimize padding) or tagged (tagged fields first)")
+	write     = flag.Bool("w", false, "write result to the source files instead of stdout")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-reorder:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-reorder [flags] [-type T] [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-reorder [flags] [-type T] files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+// field is a struct field together with the source text it occupies,
+// including its doc and line comments.
+type field struct {
+	text   []byte
+	name   string
+	tagged bool
+	size   int64
+	align  int64
+}
+
+// edit replaces src[start:end] with text.
+type edit struct {
+	start, end int
+	text       []byte
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-reorder: ")
+	flag.Usage = usage
+	flag.Parse()
+
+	var less func(a, b *field) bool
+	switch *order {
+	case "alpha":
+		less = func(a, b *field) bool { return a.name < b.name }
+	case "size":
+		less = func(a, b *field) bool {
+			// Zero-sized fields go first; a trailing one would add padding.
+			if (a.size == 0) != (b.size == 0) {
+				return a.size == 0
+			}
+			if a.align != b.align {
+				return a.align > b.align
+			}
+			return a.size > b.size
+		}
+	case "tagged":
+		less = func(a, b *field) bool { return a.tagged && !b.tagged }
+	default:
+		log.Fatalf("unknown order %q", *order)
+	}
+
+	want := make(map[string]bool)
+	if *typeNames != "" {
+		for _, name := range strings.Split(*typeNames, ",") {
+			want[name] = true
+		}
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	cfg := &packages.Config{Mode: packages.LoadSyntax}
+	pkgs, err := packages.Load(cfg, args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(pkgs) != 1 {
+		log.Fatalf("error: %d packages found", len(pkgs))
+	}
+	pkg := pkgs[0]
+
+	for _, file := range pkg.Syntax {
+		name := pkg.Fset.File(file.Pos()).Name()
+		if err := reorderFile(pkg, file, name, want, less); err != nil {
+			log.Fatalf("%s: %s", name, err)
+		}
+	}
+}
+
+func reorderFile(pkg *packages.Package, file *ast.File, name string, want map[string]bool, less func(a, b *field) bool) error {
+	src, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	offset := func(p token.Pos) int { return pkg.Fset.Position(p).Offset }
+
+	var edits []edit
+	ast.Inspect(file, func(n ast.Node) bool {
+		ts, ok := n.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok || len(st.Fields.List) < 2 || (len(want) > 0 && !want[ts.Name.Name]) {
+			return true
+		}
+		if pkg.Fset.Position(st.Fields.Opening).Line == pkg.Fset.Position(st.Fields.Closing).Line {
+			return true
+		}
+
+		var (
+			fields   []*field
+			attached = make(map[*ast.CommentGroup]bool)
+		)
+		for _, f := range st.Fields.List {
+			start, end := f.Pos(), f.End()
+			if f.Doc != nil {
+				start = f.Doc.Pos()
+				attached[f.Doc] = true
+			}
+			if f.Comment != nil {
+				end = f.Comment.End()
+				attached[f.Comment] = true
+			}
+			typ := pkg.TypesInfo.TypeOf(f.Type)
+			fd := &field{
+				text:   src[offset(start):offset(end)],
+				tagged: f.Tag != nil,
+				size:   pkg.TypesSizes.Sizeof(typ),
+				align:  pkg.TypesSizes.Alignof(typ),
+			}
+			if len(f.Names) > 0 {
+				fd.name = f.Names[0].Name
+			} else {
+				fd.name = types.TypeString(typ, nil)
+			}
+			fields = append(fields, fd)
+		}
+
+		first, last := st.Fields.List[0], st.Fields.List[len(st.Fields.List)-1]
+		start, end := first.Pos(), last.End()
+		if first.Doc != nil {
+			start = first.Doc.Pos()
+		}
+		if last.Comment != nil {
+			end = last.Comment.End()
+		}
+		for _, cg := range file.Comments {
+			if cg.Pos() >= start && cg.End() <= end && !attached[cg] {
+				log.Printf("%s: skipping %s: free-standing comments between fields", pkg.Fset.Position(cg.Pos()), ts.Name.Name)
+				return true
+			}
+		}
+
+		sort.SliceStable(fields, func(i, j int) bool { return less(fields[i], fields[j]) })
+		texts := make([][]byte, len(fields))
+		for i, f := range fields {
+			texts[i] = f.text
+		}
+		edits = append(edits, edit{
+			start: offset(start),
+			end:   offset(end),
+			text:  bytes.Join(texts, []byte("\n")),
+		})
+		return true
+	})
+
+	if len(edits) == 0 && *write {
+		return nil
+	}
+	// Edits are collected in source order; apply them back to front so
+	// earlier offsets stay valid.
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		src = append(src[:e.start:e.start], append(e.text, src[e.end:]...)...)
+	}
+	out, err := format.Source(src)
+	if err != nil {
+		return err
+	}
+	if !*write {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return ioutil.WriteFile(name, out, 0644)
+}
@@ -0,0 +1,24 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-repo -type=Project,Task -graphql
+
+type Project struct {
+	ID        int64      `db:"id,pk,autoincr"`
+	TenantID  string     `db:"tenant_id" tenant:"true"`
+	Name      string     `db:"name" filter:"eq,in" sort:"true"`
+	Labels    []string   `db:"labels"`
+	CreatedAt time.Time  `db:"created_at" cursor:"true" filter:"range" sort:"true"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+type Task struct {
+	Key       string     `db:"key,pk"`
+	TenantID  string     `db:"tenant_id" tenant:"true"`
+	ProjectID int64      `db:"project_id" filter:"eq,in" ref:"Project"`
+	Title     string     `db:"title" sort:"true"`
+	Due       *time.Time `db:"due" filter:"range" sort:"true"`
+	Done      bool       `db:"done" filter:"eq"`
+	Archived  *time.Time `db:"archived" softdelete:"true"`
+}
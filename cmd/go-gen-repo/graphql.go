@@ -0,0 +1,216 @@
+package main
+
+import (
+	"flag"
+	"go/types"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+var graphql = flag.Bool("graphql", false, "also generate gqlgen query and field resolvers backed by the repositories")
+
+// graphqlRef is a field tagged ref:"<Type>", holding the primary key of a
+// row of another repository of the package.
+type graphqlRef struct {
+	Name     string // Field resolver, the field name without its ID suffix.
+	Field    string
+	Target   string
+	Nullable bool
+}
+
+// graphqlBackref resolves the rows of Source referencing a row of the
+// target through Field, selected by its eq filter.
+type graphqlBackref struct {
+	Name   string // Field resolver, the plural of Source.
+	Source *repoStruct
+	Field  string
+	Key    string // Primary key field of the target.
+}
+
+type graphqlStruct struct {
+	*repoStruct
+	Plural     string
+	Referenced bool
+	Refs       []graphqlRef
+	Backrefs   []graphqlBackref
+}
+
+var graphqlTemplate = template.Must(template.New("graphql").Parse(`
+// GraphQLResolver resolves the queries of the types of the package, and
+// their fields referencing each other, with their repositories. The query
+// resolvers gqlgen scaffolds call its methods, and the type resolver
+// methods of the gqlgen Resolver return its <Type>Fields.
+type GraphQLResolver struct {
+{{- range .Structs}}
+	{{.Name}}Repo *{{.Name}}Repo
+{{- end}}
+{{- range .Structs}}{{if .Referenced}}
+	// Load{{.Name}}, if set, loads the {{.Name}} of a field in place of
+	// {{.Name}}Repo.Get, such as with a dataloader batching the loads of a
+	// request.
+	Load{{.Name}} func(ctx context.Context, id {{.PKType}}) (*{{.Name}}, error)
+{{- end}}{{end}}
+}
+
+func pageOptions(first *int, after *string) PageOptions {
+	var opts PageOptions
+	if first != nil {
+		opts.Limit = *first
+	}
+	if after != nil {
+		opts.Cursor = *after
+	}
+	return opts
+}
+{{range .Structs}}
+// {{.Name}}Page is a page of {{.Name}} nodes. EndCursor is the cursor of the
+// next page, or "" after the last page.
+type {{.Name}}Page struct {
+	Nodes       []*{{.Name}}
+	EndCursor   string
+	HasNextPage bool
+}
+
+// {{.Name}} resolves the query of the {{.Name}} with the primary key id, or
+// nil if there is none.
+func (r *GraphQLResolver) {{.Name}}(ctx context.Context, id {{.PKType}}) (*{{.Name}}, error) {
+	v, err := r.{{.Name}}Repo.Get(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return v, err
+}
+
+// {{.Plural}} resolves the query of the first {{.Name}} rows after the cursor
+// after{{if .Filters}} that filter selects{{end}}{{if .Sorts}}, in the order sort parses to{{end}}.
+func (r *GraphQLResolver) {{.Plural}}(ctx context.Context, first *int, after *string{{if .Filters}}, filter *{{.Name}}Filter{{end}}{{if .Sorts}}, sort *string{{end}}) (*{{.Name}}Page, error) {
+	opts := pageOptions(first, after)
+{{- if .Sorts}}
+	if sort != nil {
+		var err error
+		if opts.Sort, err = Parse{{.Name}}Sort(*sort); err != nil {
+			return nil, err
+		}
+	}
+{{- end}}
+	list, next, err := r.{{.Name}}Repo.{{if .Filters}}Find(ctx, filter, opts){{else}}ListPage(ctx, opts){{end}}
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Name}}Page{Nodes: list, EndCursor: next, HasNextPage: next != ""}, nil
+}
+{{- if .Referenced}}
+
+func (r *GraphQLResolver) load{{.Name}}(ctx context.Context, id {{.PKType}}) (*{{.Name}}, error) {
+	if r.Load{{.Name}} != nil {
+		return r.Load{{.Name}}(ctx, id)
+	}
+	return r.{{.Name}}(ctx, id)
+}
+{{- end}}
+{{- if or .Refs .Backrefs}}
+
+// {{.Name}}Fields resolves the fields of {{.Name}} referencing other types.
+type {{.Name}}Fields struct {
+	r *GraphQLResolver
+}
+
+// {{.Name}}Fields returns the field resolver of {{.Name}}, for the {{.Name}}
+// method of the gqlgen Resolver.
+func (r *GraphQLResolver) {{.Name}}Fields() *{{.Name}}Fields {
+	return &{{.Name}}Fields{r}
+}
+{{- $name := .Name}}
+{{- range .Refs}}
+
+// {{.Name}} resolves the {{.Target}} of obj.{{.Field}}{{if .Nullable}}, or nil if it is nil{{end}}.
+func (f *{{$name}}Fields) {{.Name}}(ctx context.Context, obj *{{$name}}) (*{{.Target}}, error) {
+{{- if .Nullable}}
+	if obj.{{.Field}} == nil {
+		return nil, nil
+	}
+	return f.r.load{{.Target}}(ctx, *obj.{{.Field}})
+{{- else}}
+	return f.r.load{{.Target}}(ctx, obj.{{.Field}})
+{{- end}}
+}
+{{- end}}
+{{- range .Backrefs}}
+
+// {{.Name}} resolves the first {{.Source.Name}} rows whose {{.Field}} is
+// obj.{{.Key}}, after the cursor after.
+func (f *{{$name}}Fields) {{.Name}}(ctx context.Context, obj *{{$name}}, first *int, after *string) (*{{.Source.Name}}Page, error) {
+	list, next, err := f.r.{{.Source.Name}}Repo.Find(ctx, &{{.Source.Name}}Filter{ {{- .Field}}: &obj.{{.Key}}}, pageOptions(first, after))
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Source.Name}}Page{Nodes: list, EndCursor: next, HasNextPage: next != ""}, nil
+}
+{{- end}}
+{{- end}}
+{{end}}`))
+
+// plural returns the plural of the type name name.
+func plural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "y") && !strings.HasSuffix(name, "ay") && !strings.HasSuffix(name, "ey") && !strings.HasSuffix(name, "oy"):
+		return strings.TrimSuffix(name, "y") + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	}
+	return name + "s"
+}
+
+// graphqlStructs returns the resolvers of the repositories, linked by
+// their ref tags. A reference has a reverse field resolver if its field
+// has an eq filter.
+func graphqlStructs(p structutil.PrinterWriter, repos []*repoStruct) []*graphqlStruct {
+	var structs []*graphqlStruct
+	byName := make(map[string]*graphqlStruct)
+	for _, r := range repos {
+		s := &graphqlStruct{repoStruct: r, Plural: plural(r.Name)}
+		structs = append(structs, s)
+		byName[r.Name] = s
+	}
+	for _, s := range structs {
+		for _, c := range s.Columns {
+			name := c.Field.Tag("ref")
+			if name == "" {
+				continue
+			}
+			target, ok := byName[name]
+			if !ok {
+				p.Fatalf("%s: %s: %s has no repository", c.Field.Pos, c.Field.Name, name)
+			}
+			strategy, t := structutil.Nullability(c.Field.GoType)
+			if strategy != structutil.NotNull && strategy != structutil.NullPointer || !types.Identical(t, target.PK.Field.GoType) {
+				p.Fatalf("%s: %s: a reference to %s must be of type %s, or a pointer to it", c.Field.Pos, c.Field.Name, name, target.PKType)
+			}
+			ref := graphqlRef{
+				Name:     strings.TrimSuffix(c.Field.Name, "ID"),
+				Field:    c.Field.Name,
+				Target:   name,
+				Nullable: strategy == structutil.NullPointer,
+			}
+			if ref.Name == "" || ref.Name == c.Field.Name {
+				ref.Name = name
+			}
+			s.Refs = append(s.Refs, ref)
+			target.Referenced = true
+			for _, f := range s.Filters {
+				if f.Field != c.Field.Name || !f.Eq {
+					continue
+				}
+				for _, b := range target.Backrefs {
+					if b.Name == s.Plural {
+						p.Fatalf("%s: %s: %s already references %s", c.Field.Pos, c.Field.Name, b.Field, name)
+					}
+				}
+				target.Backrefs = append(target.Backrefs, graphqlBackref{Name: s.Plural, Source: s.repoStruct, Field: c.Field.Name, Key: target.PK.Field.Name})
+			}
+		}
+	}
+	return structs
+}
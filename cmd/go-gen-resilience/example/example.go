@@ -0,0 +1,27 @@
+package example
+
+import "context"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-resilience -iface=Inventory
+
+type Item struct {
+	SKU   string
+	Count int
+}
+
+// Inventory is a remote stock service.
+//
+//gentoolkit:resilience retries=2 backoff=50ms timeout=1s breaker=5 cooldown=30s
+type Inventory interface {
+	// Lookup returns the stock of an item.
+	//gentoolkit:resilience retries=4 timeout=200ms
+	Lookup(ctx context.Context, sku string) (*Item, error)
+
+	// Reserve is not retried: a timed-out attempt may still have reserved
+	// the stock.
+	//gentoolkit:resilience retries=0
+	Reserve(ctx context.Context, sku string, count int) error
+
+	Ping() error
+	Name() string
+}
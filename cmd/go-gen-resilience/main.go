@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jakoblorz/go-gentoolkit/interfaceutil"
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var (
+	ifaceName = flag.String("iface", "", "interface name; must be set")
+	output    = flag.String("output", "", "output file name; default srcdir/<iface>_resilience.go")
+	ctxFirst  = flag.Bool("ctx", false, "require every method of the interface to take a context.Context first")
+)
+
+// policy is a method's resilience policy as Go expressions.
+type policy struct {
+	Retries          string
+	Backoff          string
+	Timeout          string
+	BreakerThreshold string
+	BreakerCooldown  string
+}
+
+var resilienceTemplate = template.Must(template.New("resilience").Parse(`
+// {{.Iface}}Policy is the resilience policy of a {{.Iface}} method.
+type {{.Iface}}Policy struct {
+	Retries          int           // Attempts after the first one.
+	Backoff          time.Duration // Delay before the first retry, doubled for every further one.
+	Timeout          time.Duration // Per-attempt timeout of methods taking a context; 0 for none.
+	BreakerThreshold int           // Consecutive failures opening the breaker; 0 disables it.
+	BreakerCooldown  time.Duration // How long an open breaker rejects calls.
+}
+
+// {{.Iface}}Policies holds the policy of every {{.Iface}} method that
+// returns an error.
+type {{.Iface}}Policies struct {
+{{- range .Methods}}
+	{{.Name}} {{$.Iface}}Policy
+{{- end}}
+}
+
+// Default{{.Iface}}Policies returns the policies declared by the
+// //gentoolkit:resilience directives of {{.Iface}}.
+func Default{{.Iface}}Policies() {{.Iface}}Policies {
+	return {{.Iface}}Policies{
+{{- range .Methods}}{{$p := index $.Policies .Name}}
+		{{.Name}}: {{$.Iface}}Policy{
+			Retries:          {{$p.Retries}},
+			Backoff:          {{$p.Backoff}},
+			Timeout:          {{$p.Timeout}},
+			BreakerThreshold: {{$p.BreakerThreshold}},
+			BreakerCooldown:  {{$p.BreakerCooldown}},
+		},
+{{- end}}
+	}
+}
+
+// Err{{.Iface}}BreakerOpen is returned by Resilient{{.Iface}} methods while
+// their circuit breaker is open.
+var Err{{.Iface}}BreakerOpen = errors.New("{{.Package}}: {{.Iface}} circuit breaker open")
+
+// Resilient{{.Iface}} retries, times out and short-circuits calls to the
+// wrapped {{.Iface}} according to per-method policies. Methods that do not
+// return an error are passed through.
+type Resilient{{.Iface}} struct {
+	next     {{.Iface}}
+	policies {{.Iface}}Policies
+
+	// Retryable, if set, reports whether a call failing with err may be
+	// retried. All errors are retried if nil.
+	Retryable func(err error) bool
+
+	mu       sync.Mutex
+	breakers map[string]*{{.Breaker}}
+}
+
+type {{.Breaker}} struct {
+	failures  int
+	openUntil time.Time
+}
+
+// NewResilient{{.Iface}} returns next wrapped with policies.
+func NewResilient{{.Iface}}(next {{.Iface}}, policies {{.Iface}}Policies) *Resilient{{.Iface}} {
+	return &Resilient{{.Iface}}{
+		next:     next,
+		policies: policies,
+		breakers: make(map[string]*{{.Breaker}}),
+	}
+}
+
+// call runs fn according to policy, with the breaker of method.
+func (r *Resilient{{.Iface}}) call(ctx context.Context, method string, policy {{.Iface}}Policy, fn func(context.Context) error) error {
+	if !r.allow(method, policy) {
+		return Err{{.Iface}}BreakerOpen
+	}
+	backoff := policy.Backoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if policy.Timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, policy.Timeout)
+		}
+		err = fn(attemptCtx)
+		cancel()
+		if err == nil || attempt >= policy.Retries || (r.Retryable != nil && !r.Retryable(err)) {
+			break
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			r.record(method, policy, err)
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+	r.record(method, policy, err)
+	return err
+}
+
+func (r *Resilient{{.Iface}}) allow(method string, policy {{.Iface}}Policy) bool {
+	if policy.BreakerThreshold <= 0 {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := r.breakers[method]
+	return b == nil || !time.Now().Before(b.openUntil)
+}
+
+// record counts consecutive failures of method. Once the breaker has opened,
+// the first call after the cooldown reopens it if it fails.
+func (r *Resilient{{.Iface}}) record(method string, policy {{.Iface}}Policy, err error) {
+	if policy.BreakerThreshold <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := r.breakers[method]
+	if b == nil {
+		b = new({{.Breaker}})
+		r.breakers[method] = b
+	}
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= policy.BreakerThreshold {
+		b.openUntil = time.Now().Add(policy.BreakerCooldown)
+	}
+}
+{{range .All}}
+func ({{.Recv}} *Resilient{{$.Iface}}) {{.Name}}{{.Signature}} {
+{{- if .ReturnsError}}
+{{- range .Values}}
+	var {{.Name}} {{.Type}}
+{{- end}}
+	{{.ErrorResult}} := {{.Recv}}.call({{if .HasContext}}{{.ContextParam}}{{else}}context.Background(){{end}}, {{printf "%q" .Name}}, {{.Recv}}.policies.{{.Name}}, func({{if .HasContext}}{{.ContextParam}}{{else}}_{{end}} context.Context) error {
+{{- if .Values}}
+		var {{.Err}} error
+		{{.Assign}} = {{.Recv}}.next.{{.Name}}({{.Args}})
+		return {{.Err}}
+{{- else}}
+		return {{.Recv}}.next.{{.Name}}({{.Args}})
+{{- end}}
+	})
+	return {{.ResultNames}}
+{{- else}}
+	{{if .Results}}return {{end}}{{.Recv}}.next.{{.Name}}({{.Args}})
+{{- end}}
+}
+{{end}}`))
+
+type resilientMethod struct {
+	*interfaceutil.Method
+	Recv   string
+	Err    string                 // Local holding the error of an attempt.
+	Values []*interfaceutil.Param // Results other than the error.
+	Assign string                 // Left-hand side of the call in an attempt.
+}
+
+// parsePolicy overrides the fields of p set by the directive d.
+func parsePolicy(p policy, d *structutil.Directive) (policy, error) {
+	if d == nil {
+		return p, nil
+	}
+	for _, key := range d.Keys {
+		value := d.Get(key)
+		switch key {
+		case "retries", "breaker":
+			if _, err := strconv.Atoi(value); err != nil {
+				return p, fmt.Errorf("%s: %w", key, err)
+			}
+			if key == "retries" {
+				p.Retries = value
+			} else {
+				p.BreakerThreshold = value
+			}
+		case "backoff", "timeout", "cooldown":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return p, fmt.Errorf("%s: %w", key, err)
+			}
+			switch key {
+			case "backoff":
+				p.Backoff = structutil.DurationLiteral(d)
+			case "timeout":
+				p.Timeout = structutil.DurationLiteral(d)
+			default:
+				p.BreakerCooldown = structutil.DurationLiteral(d)
+			}
+		default:
+			return p, fmt.Errorf("unknown policy key %q", key)
+		}
+	}
+	return p, nil
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-resilience:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-resilience [flags] -iface I [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-resilience [flags] -iface I files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Policies are declared on the interface (defaults) and its methods:\n")
+	fmt.Fprintf(os.Stderr, "\t//gentoolkit:resilience retries=3 backoff=100ms timeout=2s breaker=5 cooldown=30s\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-resilience: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *ifaceName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := interfaceutil.ParsePackage(args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	iface := pkg.Lookup(*ifaceName)
+	if iface == nil {
+		log.Fatalf("interface %s not found", *ifaceName)
+	}
+	if *ctxFirst {
+		if err := iface.CheckContext(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	defaults := policy{Retries: "0", Backoff: "0", Timeout: "0", BreakerThreshold: "0", BreakerCooldown: "0"}
+	if defaults, err = parsePolicy(defaults, iface.Directive("resilience")); err != nil {
+		log.Fatalf("%s: %s", iface.Pos, err)
+	}
+	var (
+		all      []resilientMethod
+		methods  []*interfaceutil.Method
+		policies = make(map[string]policy)
+	)
+	for _, m := range iface.Methods {
+		rm := resilientMethod{Method: m, Recv: m.Local("r")}
+		if !m.ReturnsError() {
+			if m.Directive("resilience") != nil {
+				log.Fatalf("%s: %s does not return an error", m.Pos, m.Name)
+			}
+			all = append(all, rm)
+			continue
+		}
+		p, err := parsePolicy(defaults, m.Directive("resilience"))
+		if err != nil {
+			log.Fatalf("%s: %s", m.Pos, err)
+		}
+		policies[m.Name] = p
+		methods = append(methods, m)
+
+		rm.Err = m.Local("err")
+		rm.Values = m.Results[:len(m.Results)-1]
+		var lhs []string
+		for _, v := range rm.Values {
+			lhs = append(lhs, v.Name)
+		}
+		rm.Assign = strings.Join(append(lhs, rm.Err), ", ")
+		all = append(all, rm)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-resilience %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	err = resilienceTemplate.Execute(&buf, map[string]interface{}{
+		"Package":  pkg.Name,
+		"Iface":    iface.Name,
+		"Breaker":  strings.ToLower(iface.Name[:1]) + iface.Name[1:] + "Breaker",
+		"Methods":  methods,
+		"Policies": policies,
+		"All":      all,
+	})
+	if err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, tagutil.Snake.Apply(iface.Name)+"_resilience.go")
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), iface.Pos.Filename); err != nil {
+		log.Fatal(err)
+	}
+}
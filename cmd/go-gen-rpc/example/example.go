@@ -0,0 +1,22 @@
+package example
+
+import "context"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-rpc -iface=Accounts
+
+type Account struct {
+	ID      int64  `json:"id"`
+	Owner   string `json:"owner"`
+	Balance int64  `json:"balance"`
+}
+
+// Accounts manages bank accounts.
+//
+//gentoolkit:rpc prefix=/rpc/accounts
+type Accounts interface {
+	Open(ctx context.Context, owner string) (*Account, error)
+	Get(ctx context.Context, id int64) (*Account, error)
+	Transfer(ctx context.Context, from, to int64, amount int64) error
+	//gentoolkit:rpc route=/rpc/accounts/search
+	Find(ctx context.Context, owners ...string) ([]*Account, int, error)
+}
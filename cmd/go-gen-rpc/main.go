@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/interfaceutil"
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var (
+	ifaceName = flag.String("iface", "", "interface name; must be set")
+	output    = flag.String("output", "", "output file name; default srcdir/<iface>_rpc.go")
+	ctxFirst  = flag.Bool("ctx", false, "require every method of the interface to take a context.Context first")
+)
+
+type envelopeField struct {
+	Field string
+	Type  string
+	JSON  string
+	Var   string // Parameter or result name.
+}
+
+type rpcMethod struct {
+	*interfaceutil.Method
+	Recv     string
+	Route    string
+	Type     string // Unexported name prefix of the envelope types.
+	Request  []envelopeField
+	Response []envelopeField // Results other than the error.
+	Ctx      string          // Context expression for the client request.
+	Locals   map[string]string
+
+	ZeroResults string // Zero values of the results other than the error, each followed by a comma.
+	RespResults string // Results other than the error from the response, each followed by a comma.
+}
+
+var rpcTemplate = template.Must(template.New("rpc").Parse(`
+// {{.Iface}}Error is an error returned by a remote {{.Iface}}.
+type {{.Iface}}Error struct {
+	Method  string
+	Message string
+}
+
+func (e *{{.Iface}}Error) Error() string {
+	return "{{.Iface}}." + e.Method + ": " + e.Message
+}
+{{range .Methods}}
+type {{.Type}}Request struct {
+{{- range .Request}}
+	{{.Field}} {{.Type}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+}
+
+type {{.Type}}Response struct {
+{{- range .Response}}
+	{{.Field}} {{.Type}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+	Error string ` + "`json:\"error,omitempty\"`" + `
+}
+{{end}}
+// New{{.Iface}}Handler returns an http.Handler serving impl with one POST
+// route per method:
+//
+{{- range .Methods}}
+//	{{.Route}}
+{{- end}}
+func New{{.Iface}}Handler(impl {{.Iface}}) http.Handler {
+	mux := http.NewServeMux()
+{{- range .Methods}}
+	mux.HandleFunc({{printf "%q" .Route}}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req {{.Type}}Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var resp {{.Type}}Response
+		var err error
+		{{range .Response}}resp.{{.Field}}, {{end}}err = impl.{{.Name}}({{if .HasContext}}r.Context(){{if .Request}}, {{end}}{{end}}{{range $i, $f := .Request}}{{if $i}}, {{end}}req.{{$f.Field}}{{end}}{{if .Variadic}}...{{end}})
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&resp)
+	})
+{{- end}}
+	return mux
+}
+
+// {{.Iface}}Client implements {{.Iface}} by calling a handler created with
+// New{{.Iface}}Handler. Errors returned by the remote implementation are
+// *{{.Iface}}Error.
+type {{.Iface}}Client struct {
+	// BaseURL is the URL the handler is mounted at, without trailing slash.
+	BaseURL string
+	// HTTPClient is used for requests; http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+func (c *{{.Iface}}Client) call(ctx context.Context, route string, req, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+route, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(httpResp.Body, 512))
+		return fmt.Errorf("%s: %s: %s", route, httpResp.Status, bytes.TrimSpace(msg))
+	}
+	return json.NewDecoder(httpResp.Body).Decode(resp)
+}
+{{range .Methods}}
+func ({{.Recv}} *{{$.Iface}}Client) {{.Name}}{{.Signature}} {
+	{{.Locals.req}} := {{.Type}}Request{ {{- range $i, $f := .Request}}{{if $i}}, {{end}}{{$f.Field}}: {{$f.Var}}{{end -}} }
+	var {{.Locals.resp}} {{.Type}}Response
+	if {{.Locals.err}} := {{.Recv}}.call({{.Ctx}}, {{printf "%q" .Route}}, &{{.Locals.req}}, &{{.Locals.resp}}); {{.Locals.err}} != nil {
+		return {{.ZeroResults}}{{.Locals.err}}
+	}
+	if {{.Locals.resp}}.Error != "" {
+		return {{.RespResults}}&{{$.Iface}}Error{Method: {{printf "%q" .Name}}, Message: {{.Locals.resp}}.Error}
+	}
+	return {{.RespResults}}nil
+}
+{{end}}`))
+
+// encodable reports whether values of type t can be sent as JSON.
+func encodable(t types.Type) bool {
+	switch u := t.Underlying().(type) {
+	case *types.Chan, *types.Signature:
+		return false
+	case *types.Basic:
+		return u.Kind() != types.UnsafePointer && u.Info()&types.IsComplex == 0
+	case *types.Pointer:
+		return encodable(u.Elem())
+	case *types.Slice:
+		return encodable(u.Elem())
+	case *types.Array:
+		return encodable(u.Elem())
+	case *types.Map:
+		return encodable(u.Elem())
+	}
+	return true
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-rpc:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-rpc [flags] -iface I [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-rpc [flags] -iface I files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Routes are /<iface>/<method>; the prefix is set with\n")
+	fmt.Fprintf(os.Stderr, "\t//gentoolkit:rpc prefix=/api\n")
+	fmt.Fprintf(os.Stderr, "on the interface and single routes with //gentoolkit:rpc route=/path on methods.\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-rpc: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *ifaceName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := interfaceutil.ParsePackage(args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	iface := pkg.Lookup(*ifaceName)
+	if iface == nil {
+		log.Fatalf("interface %s not found", *ifaceName)
+	}
+	if *ctxFirst {
+		if err := iface.CheckContext(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	prefix := "/" + iface.Name
+	if d := iface.Directive("rpc"); d != nil && d.Has("prefix") {
+		prefix = strings.TrimSuffix(d.Get("prefix"), "/")
+	}
+	typePrefix := strings.ToLower(iface.Name[:1]) + iface.Name[1:]
+	var methods []*rpcMethod
+	for _, m := range iface.Methods {
+		if !m.ReturnsError() {
+			log.Fatalf("%s: %s must return an error to report transport failures", m.Pos, m.Name)
+		}
+		rm := &rpcMethod{
+			Method: m,
+			Recv:   m.Local("c"),
+			Route:  prefix + "/" + m.Name,
+			Type:   typePrefix + m.Name,
+			Ctx:    "context.Background()",
+			Locals: make(map[string]string),
+		}
+		if d := m.Directive("rpc"); d != nil && d.Has("route") {
+			rm.Route = d.Get("route")
+		}
+		params := m.Params
+		if m.HasContext() {
+			rm.Ctx = m.ContextParam()
+			params = params[1:]
+		}
+		for _, p := range params {
+			if !encodable(p.GoType) {
+				log.Fatalf("%s: parameter %s of %s cannot be encoded as JSON", m.Pos, p.Name, m.Name)
+			}
+			rm.Request = append(rm.Request, envelopeField{Field: tagutil.Pascal.Apply(p.Name), Type: p.Type, JSON: p.Name, Var: p.Name})
+		}
+		for i, r := range m.Results[:len(m.Results)-1] {
+			if !encodable(r.GoType) {
+				log.Fatalf("%s: result %d of %s cannot be encoded as JSON", m.Pos, i, m.Name)
+			}
+			field := fmt.Sprintf("R%d", i)
+			rm.Response = append(rm.Response, envelopeField{Field: field, Type: r.Type, JSON: strings.ToLower(field), Var: r.Name})
+			zero := &structutil.StructFieldInfo{Type: r.Type, GoType: r.GoType}
+			rm.ZeroResults += zero.ZeroValue() + ", "
+		}
+		for _, name := range []string{"req", "resp", "err"} {
+			rm.Locals[name] = m.Local(name)
+		}
+		for _, f := range rm.Response {
+			rm.RespResults += rm.Locals["resp"] + "." + f.Field + ", "
+		}
+		methods = append(methods, rm)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-rpc %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	err = rpcTemplate.Execute(&buf, map[string]interface{}{
+		"Iface":   iface.Name,
+		"Methods": methods,
+	})
+	if err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, tagutil.Snake.Apply(iface.Name)+"_rpc.go")
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), iface.Pos.Filename); err != nil {
+		log.Fatal(err)
+	}
+}
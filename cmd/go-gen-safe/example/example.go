@@ -0,0 +1,14 @@
+package example
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-safe -type=Index,Queue
+
+type User struct {
+	Name  string
+	Email string
+}
+
+// Index holds users by email.
+type Index map[string]*User
+
+// Queue holds users waiting for approval.
+type Queue []User
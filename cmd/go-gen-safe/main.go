@@ -0,0 +1,190 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+var prefix = flag.String("prefix", "Safe", "prefix of the name of the wrapper type")
+
+var mapTemplate = template.Must(template.New("map").Parse(`
+// {{.Safe}} wraps {{.Type}} in a read-write mutex for concurrent use.
+type {{.Safe}} struct {
+	mu sync.RWMutex
+	m  {{.Type}}
+}
+
+// New{{.Safe}} returns a {{.Safe}} holding m, which must not be used
+// directly afterwards.
+func New{{.Safe}}(m {{.Type}}) *{{.Safe}} {
+	return &{{.Safe}}{m: m}
+}
+
+// Get returns the value for k and whether it is present.
+func (s *{{.Safe}}) Get(k {{.Key}}) ({{.Elem}}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.m[k]
+	return v, ok
+}
+
+// Set sets the value for k.
+func (s *{{.Safe}}) Set(k {{.Key}}, v {{.Elem}}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.m == nil {
+		s.m = make({{.Type}})
+	}
+	s.m[k] = v
+}
+
+// Delete removes the value for k.
+func (s *{{.Safe}}) Delete(k {{.Key}}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.m, k)
+}
+
+// Len returns the number of entries.
+func (s *{{.Safe}}) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.m)
+}
+
+// Range calls f for each entry until f returns false. The read lock is
+// held meanwhile, so f must not modify s.
+func (s *{{.Safe}}) Range(f func(k {{.Key}}, v {{.Elem}}) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for k, v := range s.m {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+// Snapshot returns a copy of the entries.
+func (s *{{.Safe}}) Snapshot() {{.Type}} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.m == nil {
+		return nil
+	}
+	c := make({{.Type}}, len(s.m))
+	for k, v := range s.m {
+		c[k] = v
+	}
+	return c
+}
+`))
+
+var sliceTemplate = template.Must(template.New("slice").Parse(`
+// {{.Safe}} wraps {{.Type}} in a read-write mutex for concurrent use.
+type {{.Safe}} struct {
+	mu sync.RWMutex
+	s  {{.Type}}
+}
+
+// New{{.Safe}} returns a {{.Safe}} holding s, which must not be used
+// directly afterwards.
+func New{{.Safe}}(s {{.Type}}) *{{.Safe}} {
+	return &{{.Safe}}{s: s}
+}
+
+// Get returns the element at index i.
+func (s *{{.Safe}}) Get(i int) {{.Elem}} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.s[i]
+}
+
+// Set sets the element at index i.
+func (s *{{.Safe}}) Set(i int, v {{.Elem}}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.s[i] = v
+}
+
+// Append appends vs.
+func (s *{{.Safe}}) Append(vs ...{{.Elem}}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.s = append(s.s, vs...)
+}
+
+// Len returns the number of elements.
+func (s *{{.Safe}}) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.s)
+}
+
+// Range calls f for each element in order until f returns false. The read
+// lock is held meanwhile, so f must not modify s.
+func (s *{{.Safe}}) Range(f func(i int, v {{.Elem}}) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i, v := range s.s {
+		if !f(i, v) {
+			return
+		}
+	}
+}
+
+// Snapshot returns a copy of the elements.
+func (s *{{.Safe}}) Snapshot() {{.Type}} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.s == nil {
+		return nil
+	}
+	c := make({{.Type}}, len(s.s))
+	copy(c, s.s)
+	return c
+}
+`))
+
+func generateSafe(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-safe %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+	p.Printf("\n")
+	p.Printf("import \"sync\"\n")
+
+	data := map[string]string{
+		"Safe": *prefix + info.Name,
+		"Type": info.Name,
+	}
+	switch {
+	case info.IsMap():
+		data["Key"], data["Elem"] = info.Key.Type, info.Elem.Type
+		mapTemplate.Execute(p, data)
+	case info.IsSlice():
+		data["Elem"] = info.Elem.Type
+		sliceTemplate.Execute(p, data)
+	default:
+		p.Fatalf("type %s is not a defined map or slice type", info.Name)
+	}
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-safe",
+	FileSuffix:  "safe",
+	GoFmtOutput: true,
+	Collections: true,
+}, generateSafe)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
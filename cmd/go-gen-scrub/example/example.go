@@ -0,0 +1,13 @@
+package example
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-scrub -type=Customer
+
+type Customer struct {
+	ID       int64
+	Name     string
+	Email    string            `sensitive:"mask"`
+	Password string            `sensitive:"true"`
+	Cards    []string          `sensitive:"true"`
+	Secrets  map[string]string `sensitive:"zero"`
+	Country  string            `sensitive:"false"`
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"go/types"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+var mask = flag.String("mask", "****", "replacement for non-empty string fields tagged sensitive:\"mask\"")
+
+type scrubField struct {
+	Name string
+	Zero string // Zero value; empty if the field is masked.
+	Set  string // Test for a non-empty masked field.
+}
+
+var scrubTemplate = template.Must(template.New("scrub").Parse(`
+// SensitiveFields returns the names of the fields of {{.Struct}} tagged
+// sensitive:"...".
+func ({{.Receiver}} *{{.Struct}}) SensitiveFields() []string {
+	return []string{ {{- range $i, $f := .Fields}}{{if $i}}, {{end}}{{printf "%q" $f.Name}}{{end -}} }
+}
+
+// Scrub zeroes or masks the sensitive fields of {{.Receiver}}.
+func ({{.Receiver}} *{{.Struct}}) Scrub() {
+{{- range .Fields}}
+{{- if .Zero}}
+	{{$.Receiver}}.{{.Name}} = {{.Zero}}
+{{- else}}
+	if {{.Set}} {
+		{{$.Receiver}}.{{.Name}} = {{printf "%q" $.Mask}}
+	}
+{{- end}}
+{{- end}}
+}
+
+// Scrubbed returns a copy of {{.Receiver}} with its sensitive fields zeroed or
+// masked, safe to log or attach to error reports.
+func ({{.Receiver}} {{.Struct}}) Scrubbed() {{.Struct}} {
+	{{.Receiver}}.Scrub()
+	return {{.Receiver}}
+}
+`))
+
+func generateScrub(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-scrub %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+
+	receiver := strings.ToLower(info.Name[0:1])
+	var fields []scrubField
+	for _, field := range info.Fields {
+		switch mode := field.Tag("sensitive"); mode {
+		case "", "false":
+			continue
+		case "true", "zero":
+			fields = append(fields, scrubField{Name: field.Name, Zero: field.ZeroValue()})
+		case "mask":
+			if b, ok := field.GoType.Underlying().(*types.Basic); !ok || b.Info()&types.IsString == 0 {
+				p.Fatalf("%s: %s: only string fields can be masked", field.Pos, field.Name)
+			}
+			fields = append(fields, scrubField{Name: field.Name, Set: field.NonZeroTest(receiver + "." + field.Name)})
+		default:
+			p.Fatalf("%s: %s: unknown sensitive mode %q", field.Pos, field.Name, mode)
+		}
+	}
+
+	scrubTemplate.Execute(p, map[string]interface{}{
+		"Receiver": receiver,
+		"Struct":   info.Name,
+		"Fields":   fields,
+		"Mask":     *mask,
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-scrub",
+	FileSuffix:  "scrub",
+	GoFmtOutput: true,
+}, generateScrub)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
@@ -0,0 +1,20 @@
+package example
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-seed -format=go
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-seed -format=sql
+
+type User struct {
+	ID       int64   `db:"id,pk,autoincr"`
+	Email    string  `db:"email,unique"`
+	Name     string  `db:"name"`
+	Bio      *string `db:"bio"`
+	Password []byte  `db:"-"`
+}
+
+type Post struct {
+	ID       int64    `db:"id,pk,autoincr"`
+	AuthorID int64    `db:"author_id"`
+	Title    string   `db:"title"`
+	Tags     []string `db:"tags"`
+	Draft    bool     `db:"draft"`
+}
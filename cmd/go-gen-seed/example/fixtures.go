@@ -0,0 +1,10 @@
+package example
+
+//gentoolkit:seed
+var Admin = User{ID: 1, Email: "admin@example.com", Name: "Admin"}
+
+//gentoolkit:seed
+var Posts = []Post{
+	{ID: 1, AuthorID: 1, Title: "Hello, world", Tags: nil},
+	{ID: 2, AuthorID: 1, Title: "It's a draft", Draft: true},
+}
@@ -0,0 +1,498 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/typemap"
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	format   = flag.String("format", "go", "output format: go for a seeding function or sql for INSERT statements")
+	dialect  = flag.String("dialect", "postgres", "SQL dialect: postgres or mysql")
+	funcName = flag.String("func", "Seed", "name of the seeding function of -format go")
+	output   = flag.String("output", "", "output file name; default srcdir/<package>_seed.go or .sql")
+)
+
+// fixture is a package-level variable annotated //gentoolkit:seed holding
+// one struct value or a slice of them.
+type fixture struct {
+	Name    string
+	File    string // Name of the file declaring the variable.
+	Slice   bool
+	Table   string
+	Columns []seedColumn
+	// Rows are the composite literals of the values, nil for values that
+	// are not literals.
+	Rows []*ast.CompositeLit
+}
+
+// seedColumn is a column inserted for a fixture.
+type seedColumn struct {
+	structutil.Column
+	JSON bool // Whether the value is encoded as JSON.
+}
+
+// Query returns the INSERT statement of the fixture with placeholders.
+func (f *fixture) Query() string {
+	names := make([]string, len(f.Columns))
+	params := make([]string, len(f.Columns))
+	for i, c := range f.Columns {
+		names[i] = quote(c.Name)
+		params[i] = "?"
+		if *dialect == "postgres" {
+			params[i] = "$" + strconv.Itoa(i+1)
+		}
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quote(f.Table), strings.Join(names, ", "), strings.Join(params, ", "))
+}
+
+// Args returns the arguments of Query for the value v.
+func (f *fixture) Args(v string) string {
+	args := make([]string, len(f.Columns))
+	for i, c := range f.Columns {
+		args[i] = v + "." + c.Field.Name
+		if c.JSON {
+			args[i] = "seedJSON{" + args[i] + "}"
+		}
+	}
+	return strings.Join(args, ", ")
+}
+
+// HasJSON reports whether some column of the fixture is stored as JSON.
+func (f *fixture) HasJSON() bool {
+	for _, c := range f.Columns {
+		if c.JSON {
+			return true
+		}
+	}
+	return false
+}
+
+var seedTemplate = template.Must(template.New("seed").Parse(`
+// {{.Func}} inserts the fixtures {{range $i, $f := .Fixtures}}{{if $i}}, {{end}}{{$f.Name}}{{end}} into db, in this order.
+func {{.Func}}(ctx context.Context, db *sql.DB) error {
+{{- range .Fixtures}}
+{{- if .Slice}}
+	for _, v := range {{.Name}} {
+		if _, err := db.ExecContext(ctx, {{printf "%#q" .Query}}, {{.Args "v"}}); err != nil {
+			return fmt.Errorf("seeding {{.Name}}: %w", err)
+		}
+	}
+{{- else}}
+	if _, err := db.ExecContext(ctx, {{printf "%#q" .Query}}, {{.Args .Name}}); err != nil {
+		return fmt.Errorf("seeding {{.Name}}: %w", err)
+	}
+{{- end}}
+{{- end}}
+	return nil
+}
+{{- if .JSON}}
+
+// seedJSON stores a value as JSON.
+type seedJSON struct {
+	v interface{}
+}
+
+func (j seedJSON) Value() (driver.Value, error) {
+	data, err := json.Marshal(j.v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+{{- end}}
+`))
+
+func quote(name string) string {
+	if *dialect == "postgres" {
+		return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+	}
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// isJSON reports whether values of t are encoded as JSON before they are
+// inserted: those stored in JSON columns, as go-gen-migrate maps them,
+// unless they are text already or encode themselves as driver.Valuers.
+func isJSON(t types.Type, overrides typemap.Overrides) bool {
+	if isValuer(t) {
+		return false
+	}
+	_, t = structutil.Nullability(t)
+	if isValuer(t) {
+		return false
+	}
+	target := typemap.Target(*dialect)
+	if m, ok := overrides.Lookup(t, target); ok && m.Type != "json" && m.Type != "jsonb" {
+		return false
+	}
+	m, ok := typemap.Lookup(t, target)
+	if !ok {
+		return true
+	}
+	switch m.Type {
+	case "text", "bytea", "blob", "json", "jsonb":
+		return false
+	}
+	return true
+}
+
+// isValuer reports whether t has the Value method of driver.Valuer.
+func isValuer(t types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(t, false, nil, "Value")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	return sig.Params().Len() == 0 && sig.Results().Len() == 2
+}
+
+// findFixtures returns the variables of pkg annotated //gentoolkit:seed in
+// source order.
+func findFixtures(pkg *packages.Package) ([]*fixture, []string, error) {
+	var (
+		fixtures  []*fixture
+		typeNames []string
+	)
+	for _, f := range pkg.Syntax {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				vs := spec.(*ast.ValueSpec)
+				doc := vs.Doc
+				if doc == nil && !gd.Lparen.IsValid() {
+					doc = gd.Doc
+				}
+				d, err := structutil.LookupDirective(doc, "seed")
+				if err != nil {
+					return nil, nil, err
+				}
+				if d == nil {
+					continue
+				}
+				for i, ident := range vs.Names {
+					fx, typeName, err := newFixture(pkg, ident, vs, i)
+					if err != nil {
+						return nil, nil, err
+					}
+					fixtures = append(fixtures, fx)
+					typeNames = append(typeNames, typeName)
+				}
+			}
+		}
+	}
+	return fixtures, typeNames, nil
+}
+
+// newFixture returns the fixture of the i-th variable of vs and the name of
+// its struct type.
+func newFixture(pkg *packages.Package, ident *ast.Ident, vs *ast.ValueSpec, i int) (*fixture, string, error) {
+	pos := pkg.Fset.Position(ident.Pos())
+	fx := &fixture{Name: ident.Name, File: pos.Filename}
+	t := pkg.TypesInfo.Defs[ident].Type()
+	if s, ok := t.(*types.Slice); ok {
+		fx.Slice, t = true, s.Elem()
+	}
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if _, isStruct := t.Underlying().(*types.Struct); !ok || !isStruct || named.Obj().Pkg() != pkg.Types {
+		return nil, "", fmt.Errorf("%s: fixture %s must hold a struct of the package or a slice of them", pos, ident.Name)
+	}
+
+	if i < len(vs.Values) {
+		value := vs.Values[i]
+		if fx.Slice {
+			if lit, ok := value.(*ast.CompositeLit); ok {
+				for _, elt := range lit.Elts {
+					fx.Rows = append(fx.Rows, rowLiteral(elt))
+				}
+			}
+		} else {
+			fx.Rows = []*ast.CompositeLit{rowLiteral(value)}
+		}
+	}
+	return fx, named.Obj().Name(), nil
+}
+
+// rowLiteral returns the struct literal of a value, or nil.
+func rowLiteral(e ast.Expr) *ast.CompositeLit {
+	if u, ok := e.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		e = u.X
+	}
+	lit, _ := e.(*ast.CompositeLit)
+	return lit
+}
+
+// sets reports whether the struct literal lit sets the named field.
+func sets(lit *ast.CompositeLit, field string) bool {
+	if lit == nil {
+		return false
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			// Unkeyed literals set every field.
+			return true
+		}
+		if id, ok := kv.Key.(*ast.Ident); ok && id.Name == field {
+			return true
+		}
+	}
+	return false
+}
+
+// value returns the expression lit gives the named field, or nil.
+func value(lit *ast.CompositeLit, info *structutil.StructInfo, field string) ast.Expr {
+	for i, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			if i < len(info.Fields) && info.Fields[i].Name == field {
+				return elt
+			}
+			continue
+		}
+		if id, ok := kv.Key.(*ast.Ident); ok && id.Name == field {
+			return kv.Value
+		}
+	}
+	return nil
+}
+
+// setColumns selects the columns inserted for fx. Autoincrement columns are
+// left to the database unless every row sets them.
+func setColumns(fx *fixture, info *structutil.StructInfo) error {
+	columns, err := info.Columns()
+	if err != nil {
+		return err
+	}
+	fx.Table = info.TableName()
+	for _, c := range columns {
+		if c.AutoIncrement {
+			set := 0
+			for _, row := range fx.Rows {
+				if sets(row, c.Field.Name) {
+					set++
+				}
+			}
+			if set == 0 {
+				continue
+			}
+			if set < len(fx.Rows) {
+				return fmt.Errorf("fixture %s: set %s in every value or in none", fx.Name, c.Field.Name)
+			}
+		}
+		if c.Field.GoType == nil {
+			return fmt.Errorf("%s: no type information for %s", c.Field.Pos, c.Field.Name)
+		}
+		overrides, err := typemap.ParseOverrides(c.Field.Tag(typemap.TagKey))
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", c.Field.Pos, c.Field.Name, err)
+		}
+		fx.Columns = append(fx.Columns, seedColumn{Column: c, JSON: isJSON(c.Field.GoType, overrides)})
+	}
+	return nil
+}
+
+// sqlLiteral returns the SQL literal of the expression e of a fixture.
+func sqlLiteral(pkg *packages.Package, e ast.Expr, c seedColumn) (string, error) {
+	if e == nil {
+		if lit, ok := zeroLiteral(c); ok {
+			return lit, nil
+		}
+		return "", fmt.Errorf("%s: %s has no SQL literal for its zero value; use -format go", c.Field.Pos, c.Field.Name)
+	}
+	if id, ok := e.(*ast.Ident); ok && id.Name == "nil" {
+		return "NULL", nil
+	}
+	tv, ok := pkg.TypesInfo.Types[e]
+	if !ok || tv.Value == nil || c.JSON {
+		return "", fmt.Errorf("%s: %s is not a constant; use -format go", pkg.Fset.Position(e.Pos()), c.Field.Name)
+	}
+	switch tv.Value.Kind() {
+	case constant.String:
+		s := strings.Replace(constant.StringVal(tv.Value), "'", "''", -1)
+		if *dialect == "mysql" {
+			// MySQL treats backslashes in strings as escapes.
+			s = strings.Replace(s, `\`, `\\`, -1)
+		}
+		return "'" + s + "'", nil
+	case constant.Bool:
+		if constant.BoolVal(tv.Value) {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	}
+	return tv.Value.ExactString(), nil
+}
+
+// zeroLiteral returns the SQL literal of the zero value of the column and
+// whether it has one.
+func zeroLiteral(c seedColumn) (string, bool) {
+	if c.Field.IsNullable() {
+		return "NULL", true
+	}
+	var b *types.Basic
+	switch t := c.Field.GoType.Underlying().(type) {
+	case *types.Slice, *types.Map, *types.Interface:
+		return "NULL", true
+	case *types.Basic:
+		b = t
+	}
+	switch {
+	case b == nil:
+		return "", false
+	case b.Info()&types.IsString != 0:
+		return "''", true
+	case b.Info()&types.IsBoolean != 0:
+		return "FALSE", true
+	}
+	return "0", true
+}
+
+func generateSQL(pkg *packages.Package, fixtures []*fixture, infos []*structutil.StructInfo, w *bytes.Buffer) error {
+	for i, fx := range fixtures {
+		if len(fx.Rows) == 0 {
+			return fmt.Errorf("fixture %s is not a composite literal; use -format go", fx.Name)
+		}
+		names := make([]string, len(fx.Columns))
+		for j, c := range fx.Columns {
+			names[j] = quote(c.Name)
+		}
+		fmt.Fprintf(w, "INSERT INTO %s (%s) VALUES\n", quote(fx.Table), strings.Join(names, ", "))
+		for j, row := range fx.Rows {
+			if row == nil {
+				return fmt.Errorf("fixture %s: value %d is not a composite literal; use -format go", fx.Name, j)
+			}
+			values := make([]string, len(fx.Columns))
+			for k, c := range fx.Columns {
+				lit, err := sqlLiteral(pkg, value(row, infos[i], c.Field.Name), c)
+				if err != nil {
+					return err
+				}
+				values[k] = lit
+			}
+			sep := ","
+			if j == len(fx.Rows)-1 {
+				sep = ";"
+			}
+			fmt.Fprintf(w, "\t(%s)%s\n", strings.Join(values, ", "), sep)
+		}
+	}
+	return nil
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-seed:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-seed [flags] [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-seed [flags] files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Inserts the package-level variables annotated //gentoolkit:seed, holding\n")
+	fmt.Fprintf(os.Stderr, "db-tagged structs or slices of them, in source order.\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-seed: ")
+	flag.Usage = usage
+	flag.Parse()
+	switch *dialect {
+	case "postgres", "mysql":
+	default:
+		log.Fatalf("unknown dialect %q", *dialect)
+	}
+	if *format != "go" && *format != "sql" {
+		log.Fatalf("unknown format %q", *format)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := structutil.LoadPackage(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fixtures, typeNames, err := findFixtures(pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		log.Fatalf("no variables annotated //gentoolkit:seed in package %s", pkg.Name)
+	}
+	_, infos, err := structutil.PackageStructs(pkg, typeNames)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var inputs []string
+	for i, fx := range fixtures {
+		if err := setColumns(fx, infos[i]); err != nil {
+			log.Fatal(err)
+		}
+		if len(inputs) == 0 || inputs[len(inputs)-1] != fx.File {
+			inputs = append(inputs, fx.File)
+		}
+	}
+
+	var buf bytes.Buffer
+	outputName := *output
+	if *format == "sql" {
+		if outputName == "" {
+			outputName = filepath.Join(dir, pkg.Name+"_seed.sql")
+		}
+		fmt.Fprintf(&buf, "-- Code generated by \"go-gen-seed %s\"; DO NOT EDIT.\n\n", structutil.JoinArgs(os.Args[1:]))
+		if err := generateSQL(pkg, fixtures, infos, &buf); err != nil {
+			log.Fatal(err)
+		}
+		if err := structutil.WriteGenerated(outputName, buf.Bytes(), inputs...); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if outputName == "" {
+		outputName = filepath.Join(dir, pkg.Name+"_seed.go")
+	}
+	hasJSON := false
+	for _, fx := range fixtures {
+		hasJSON = hasJSON || fx.HasJSON()
+	}
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-seed %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	err = seedTemplate.Execute(&buf, map[string]interface{}{
+		"Func":     *funcName,
+		"Fixtures": fixtures,
+		"JSON":     hasJSON,
+	})
+	if err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), inputs...); err != nil {
+		log.Fatal(err)
+	}
+}
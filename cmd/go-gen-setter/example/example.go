@@ -0,0 +1,10 @@
+package example
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-setter -type=ExampleStruct -copy
+
+type ExampleStruct struct {
+	Name   string
+	Labels map[string]string
+	Tags   []string
+	Shared []byte `copy:"false"`
+}
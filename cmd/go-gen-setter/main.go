@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+var copyAll = flag.Bool("copy", false, "store copies of slice and map arguments; per field with the copy:\"true\" tag")
+
+// copyKind returns "slice" or "map" if the setter for field stores a copy.
+func copyKind(field structutil.StructFieldInfo) string {
+	switch field.Tag("copy") {
+	case "true":
+	case "false":
+		return ""
+	default:
+		if !*copyAll {
+			return ""
+		}
+	}
+	switch {
+	case field.IsSlice():
+		return "slice"
+	case field.IsMap():
+		return "map"
+	}
+	return ""
+}
+
+func generateSetter(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-setter %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+	p.Printf("\n\n")
+	for _, field := range info.Fields {
+		p.Printf("\n")
+		p.Annotate(field)
+		snippet := info.Snippet(field, "Set")
+		snippet.Copy = copyKind(field)
+		structutil.SetterSnippet.Execute(p, snippet)
+	}
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-setter",
+	FileSuffix:  "setter",
+	GoFmtOutput: true,
+}, generateSetter)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
@@ -0,0 +1,32 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-split -type=Customer
+
+type Timestamps struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Customer has grown fields of several concerns.
+//
+//gentoolkit:split prefix=Billing,Shipping
+type Customer struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+
+	BillingStreet string `json:"billing_street"`
+	BillingCity   string `json:"billing_city"`
+	// BillingVATID is the VAT identification number, if any.
+	BillingVATID *string `json:"billing_vat_id,omitempty"`
+
+	ShippingStreet string `json:"shipping_street"`
+	ShippingCity   string `json:"shipping_city"`
+
+	Plan        string    `json:"plan" group:"subscription"`
+	RenewsAt    time.Time `json:"renews_at" group:"subscription"`
+	billingNote string
+
+	Timestamps
+}
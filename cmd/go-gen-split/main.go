@@ -0,0 +1,226 @@
+package main
+
+import (
+	"flag"
+	"go/token"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/fatih/structtag"
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+// splitField is a field of the original struct, in the composite or in
+// one of its groups.
+type splitField struct {
+	Orig     string // Name in the original struct.
+	Setter   string // Name of its forwarding setter.
+	Name     string // Name in the composite or group.
+	Type     string
+	Tag      string
+	Doc      []string
+	Embedded bool
+}
+
+type group struct {
+	Name   string
+	Type   string
+	Fields []splitField
+}
+
+var splitTemplate = template.Must(template.New("split").Parse(`
+{{- range .Groups}}
+// {{.Type}} holds the {{.Name}} fields of {{$.Struct}}.
+type {{.Type}} struct {
+{{- template "fields" .Fields}}
+}
+{{end}}
+// {{.Parts}} is {{.Struct}} split into {{range $i, $g := .Groups}}{{if $i}}, {{end}}{{$g.Type}}{{end}}, which it
+// embeds, and its other fields. Its accessor methods stand in for the
+// grouped fields of {{.Struct}} while the code using them moves to the
+// groups.
+type {{.Parts}} struct {
+{{- template "fields" .Fields}}
+{{- range .Groups}}
+	{{.Type}}
+{{- end}}
+}
+
+// New{{.Parts}} returns the {{.Parts}} of v.
+func New{{.Parts}}(v {{.Struct}}) {{.Parts}} {
+	return {{.Parts}}{
+{{- range .Fields}}
+		{{.Name}}: v.{{.Orig}},
+{{- end}}
+{{- range .Groups}}
+		{{.Type}}: {{.Type}}{
+{{- range .Fields}}
+			{{.Name}}: v.{{.Orig}},
+{{- end}}
+		},
+{{- end}}
+	}
+}
+
+// To{{.Struct}} returns the {{.Struct}} of p.
+func (p {{.Parts}}) To{{.Struct}}() {{.Struct}} {
+	return {{.Struct}}{
+{{- range .Fields}}
+		{{.Orig}}: p.{{.Name}},
+{{- end}}
+{{- range $g := .Groups}}
+{{- range .Fields}}
+		{{.Orig}}: p.{{$g.Type}}.{{.Name}},
+{{- end}}
+{{- end}}
+	}
+}
+{{- range $g := .Groups}}
+{{- range .Fields}}
+
+// {{.Orig}} returns the {{.Name}} of {{$g.Type}}, formerly {{$.Struct}}.{{.Orig}}.
+func (p *{{$.Parts}}) {{.Orig}}() {{.Type}} {
+	return p.{{$g.Type}}.{{.Name}}
+}
+
+// {{.Setter}} sets the {{.Name}} of {{$g.Type}}, formerly {{$.Struct}}.{{.Orig}}.
+func (p *{{$.Parts}}) {{.Setter}}(v {{.Type}}) {
+	p.{{$g.Type}}.{{.Name}} = v
+}
+{{- end}}
+{{- end}}
+`))
+
+func init() {
+	template.Must(splitTemplate.New("fields").Parse(`
+{{- range .}}
+{{- range .Doc}}
+	// {{.}}
+{{- end}}
+	{{if not .Embedded}}{{.Name}} {{end}}{{.Type}}{{if .Tag}} ` + "`{{.Tag}}`" + `{{end}}
+{{- end}}`))
+}
+
+// trimPrefix returns the field name name without prefix if it starts with
+// it as a word, lowercase if name is unexported.
+func trimPrefix(name, prefix string) (string, bool) {
+	exported := token.IsExported(name)
+	if !exported {
+		prefix = strings.ToLower(prefix[:1]) + prefix[1:]
+	}
+	rest := strings.TrimPrefix(name, prefix)
+	if rest == name || !token.IsExported(rest) {
+		return name, false
+	}
+	if !exported {
+		rest = strings.ToLower(rest[:1]) + rest[1:]
+	}
+	return rest, true
+}
+
+func generateSplit(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-split %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+
+	config := info.Directive("split")
+	if config == nil {
+		config = &structutil.Directive{Name: "split"}
+	}
+	parts := config.Get("into")
+	if parts == "" {
+		parts = info.Name + "Parts"
+	} else if !token.IsIdentifier(parts) {
+		p.Fatalf("type %s: invalid composite name %q", info.Name, parts)
+	}
+	var prefixes []string
+	if config.Has("prefix") {
+		prefixes = strings.Split(config.Get("prefix"), ",")
+	}
+
+	var (
+		fields []splitField
+		groups []*group
+		byName = make(map[string]*group)
+	)
+	for _, field := range info.Fields {
+		f := splitField{Orig: field.Name, Name: field.Name, Type: field.Type, Embedded: field.Embedded, Setter: "Set" + field.Name}
+		if !token.IsExported(field.Name) {
+			f.Setter = "set" + strings.ToUpper(field.Name[:1]) + field.Name[1:]
+		}
+		if field.Doc != "" {
+			f.Doc = strings.Split(strings.TrimSuffix(field.Doc, "\n"), "\n")
+		}
+		name := ""
+		if field.Tags != nil {
+			tags, _ := structtag.Parse(field.Tags.String())
+			if tag, err := tags.Get("group"); err == nil {
+				name = tagutil.Pascal.Apply(tag.Name)
+				tags.Delete("group")
+			}
+			if tags.Len() > 0 {
+				f.Tag = tags.String()
+			}
+		}
+		if name == "" {
+			for _, prefix := range prefixes {
+				if _, ok := trimPrefix(field.Name, prefix); ok {
+					name = prefix
+					break
+				}
+			}
+		}
+		if name == "" {
+			fields = append(fields, f)
+			continue
+		}
+		if field.Embedded {
+			p.Fatalf("%s: %s: an embedded field cannot be grouped", field.Pos, field.Name)
+		}
+		f.Name, _ = trimPrefix(field.Name, name)
+		if len(f.Doc) > 0 && strings.HasPrefix(f.Doc[0], f.Orig+" ") {
+			f.Doc[0] = f.Name + strings.TrimPrefix(f.Doc[0], f.Orig)
+		}
+		g, ok := byName[name]
+		if !ok {
+			g = &group{Name: name, Type: info.Name + name}
+			groups = append(groups, g)
+			byName[name] = g
+		}
+		for _, other := range g.Fields {
+			if other.Name == f.Name {
+				p.Fatalf("%s: %s: %s has a field %s already, from %s", field.Pos, field.Name, g.Type, f.Name, other.Orig)
+			}
+		}
+		g.Fields = append(g.Fields, f)
+	}
+	if len(groups) == 0 {
+		p.Fatalf("type %s: no field is grouped by a group tag or a //gentoolkit:split prefix", info.Name)
+	}
+
+	splitTemplate.Execute(p, map[string]interface{}{
+		"Struct": info.Name,
+		"Parts":  parts,
+		"Fields": fields,
+		"Groups": groups,
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-split",
+	FileSuffix:  "split",
+	GoFmtOutput: true,
+}, generateSplit)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of type names; default all structs")
+	add       = flag.String("add", "", "comma-separated list of tag keys to add to fields without them, named after the field")
+	casing    = flag.String("case", "snake", "casing of added tag names and of tags listed in -enforce: snake, kebab, camel, pascal or lower")
+	enforce   = flag.String("enforce", "", "comma-separated list of tag keys whose names are converted to -case")
+	sync      = flag.String("sync", "", "comma-separated list of dst=src pairs; dst tags are set to the src tag")
+	remove    = flag.String("remove", "", "comma-separated list of tag keys to remove")
+	write     = flag.Bool("w", false, "write result to the source files instead of stdout")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-tags:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-tags [flags] [-type T] [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-tags [flags] [-type T] files...\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-tags: ")
+	flag.Usage = usage
+	flag.Parse()
+
+	rules, err := parseRules()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(rules) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	files, err := sourceFiles(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, name := range files {
+		if err := rewrite(name, rules); err != nil {
+			log.Fatalf("%s: %s", name, err)
+		}
+	}
+}
+
+func parseRules() ([]tagutil.Rule, error) {
+	c, err := tagutil.ParseCasing(*casing)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []tagutil.Rule
+	for _, key := range split(*add) {
+		rules = append(rules, tagutil.Add(key, c))
+	}
+	for _, key := range split(*enforce) {
+		rules = append(rules, tagutil.Case(key, c))
+	}
+	for _, pair := range split(*sync) {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid -sync pair %q; want dst=src", pair)
+		}
+		rules = append(rules, tagutil.Sync(kv[0], kv[1]))
+	}
+	for _, key := range split(*remove) {
+		rules = append(rules, tagutil.Remove(key))
+	}
+	return rules, nil
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// sourceFiles expands a single directory argument to its non-test Go files.
+func sourceFiles(args []string) ([]string, error) {
+	if len(args) != 1 {
+		return args, nil
+	}
+	info, err := os.Stat(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return args, nil
+	}
+	matches, err := filepath.Glob(filepath.Join(args[0], "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range matches {
+		if !strings.HasSuffix(name, "_test.go") {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+func rewrite(name string, rules []tagutil.Rule) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	var types []string
+	if *typeNames != "" {
+		types = strings.Split(*typeNames, ",")
+	}
+	changed, err := tagutil.RewriteFile(file, types, rules...)
+	if err != nil {
+		return err
+	}
+	if !changed && *write {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return err
+	}
+	if !*write {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return ioutil.WriteFile(name, buf.Bytes(), 0644)
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var schemaKind = flag.String("schema", "resource", "kind of the generated schema: resource or datasource")
+
+// tfKind describes how Go values of a basic kind map to a Terraform type.
+type tfKind struct {
+	Name  string // Prefix of the framework's names, such as Int64.
+	GoTyp string // Go type of the framework's values, such as int64.
+}
+
+var (
+	tfString  = tfKind{"String", "string"}
+	tfBool    = tfKind{"Bool", "bool"}
+	tfInt64   = tfKind{"Int64", "int64"}
+	tfFloat64 = tfKind{"Float64", "float64"}
+)
+
+func basicKind(t types.Type) (tfKind, bool) {
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return tfKind{}, false
+	}
+	switch info := b.Info(); {
+	case info&types.IsString != 0:
+		return tfString, true
+	case info&types.IsBoolean != 0:
+		return tfBool, true
+	case info&types.IsInteger != 0:
+		return tfInt64, true
+	case info&types.IsFloat != 0:
+		return tfFloat64, true
+	}
+	return tfKind{}, false
+}
+
+type tfField struct {
+	Name      string // Name of the Go field.
+	Attr      string // Name of the Terraform attribute.
+	Schema    string // Attribute literal of the schema.
+	ModelType string // Type of the model field.
+	From      string // Statements setting m.Name from v.Name.
+	To        string // Statements setting v.Name from m.Name.
+}
+
+var terraformTemplate = template.Must(template.New("terraform").Parse(`
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/{{.SchemaKind}}/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// {{.Struct}}Model is the Terraform model of {{.Struct}}.
+type {{.Struct}}Model struct {
+{{- range .Fields}}
+	{{.Name}} {{.ModelType}} ` + "`" + `tfsdk:"{{.Attr}}"` + "`" + `
+{{- end}}
+}
+
+// {{.Struct}}Schema returns the Terraform {{.SchemaKind}} schema of {{.Struct}}.
+func {{.Struct}}Schema() schema.Schema {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+{{- range .Fields}}
+			{{printf "%q" .Attr}}: {{.Schema}},
+{{- end}}
+		},
+	}
+}
+
+// New{{.Struct}}Model returns the Terraform model of v.
+func New{{.Struct}}Model(ctx context.Context, v {{.Struct}}) ({{.Struct}}Model, diag.Diagnostics) {
+	var (
+		m     {{.Struct}}Model
+		diags diag.Diagnostics
+	)
+{{- range .Fields}}
+{{.From}}
+{{- end}}
+	return m, diags
+}
+
+// To{{.Struct}} returns the {{.Struct}} of the model. Null and unknown
+// attributes leave their fields zero.
+func (m {{.Struct}}Model) To{{.Struct}}(ctx context.Context) ({{.Struct}}, diag.Diagnostics) {
+	var (
+		v     {{.Struct}}
+		diags diag.Diagnostics
+	)
+{{- range .Fields}}
+{{.To}}
+{{- end}}
+	return v, diags
+}
+`))
+
+// convert returns expr converted to typeName, unless it has that type.
+func convert(typeName, expr, exprType string) string {
+	if typeName == exprType {
+		return expr
+	}
+	return typeName + "(" + expr + ")"
+}
+
+// conversions returns the model type of a field of type t and the
+// statements converting between it and the field.
+func conversions(name string, t types.Type, qualifier types.Qualifier) (model, elem string, from, to string, err error) {
+	typeName := types.TypeString(t, qualifier)
+	if k, ok := basicKind(t); ok {
+		from = fmt.Sprintf("\tm.%s = types.%sValue(%s)", name, k.Name, convert(k.GoTyp, "v."+name, typeName))
+		to = fmt.Sprintf("\tv.%s = %s", name, convert(typeName, "m."+name+".Value"+k.Name+"()", k.GoTyp))
+		return "types." + k.Name, k.Name, from, to, nil
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		k, ok := basicKind(u.Elem())
+		if !ok {
+			break
+		}
+		elemName := types.TypeString(u.Elem(), qualifier)
+		from = fmt.Sprintf("\tm.%[1]s = types.%[2]sNull()\n\tif v.%[1]s != nil {\n\t\tm.%[1]s = types.%[2]sValue(%[3]s)\n\t}", name, k.Name, convert(k.GoTyp, "*v."+name, elemName))
+		to = fmt.Sprintf("\tif !m.%[1]s.IsNull() && !m.%[1]s.IsUnknown() {\n\t\tx := %[2]s\n\t\tv.%[1]s = &x\n\t}", name, convert(elemName, "m."+name+".Value"+k.Name+"()", k.GoTyp))
+		return "types." + k.Name, k.Name, from, to, nil
+	case *types.Slice, *types.Map:
+		var elemType types.Type
+		collection := "List"
+		if s, ok := u.(*types.Slice); ok {
+			elemType = s.Elem()
+		} else {
+			m := u.(*types.Map)
+			if b, ok := m.Key().Underlying().(*types.Basic); !ok || b.Info()&types.IsString == 0 {
+				return "", "", "", "", fmt.Errorf("map keys must be strings")
+			}
+			elemType, collection = m.Elem(), "Map"
+		}
+		k, ok := basicKind(elemType)
+		if !ok {
+			break
+		}
+		from = fmt.Sprintf("\t{\n\t\tvar d diag.Diagnostics\n\t\tm.%[1]s, d = types.%[2]sValueFrom(ctx, types.%[3]sType, v.%[1]s)\n\t\tdiags.Append(d...)\n\t}", name, collection, k.Name)
+		to = fmt.Sprintf("\tif !m.%[1]s.IsNull() && !m.%[1]s.IsUnknown() {\n\t\tdiags.Append(m.%[1]s.ElementsAs(ctx, &v.%[1]s, false)...)\n\t}", name)
+		return "types." + collection, k.Name, from, to, nil
+	}
+	return "", "", "", "", fmt.Errorf("type %s has no Terraform attribute type; tag the field tf:\"-\"", typeName)
+}
+
+func generateTerraform(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-terraform %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+
+	qualifier := structutil.Qualifier(info.Package.GetPath())
+	var fields []tfField
+	for _, field := range info.Fields {
+		if field.Embedded || !ast.IsExported(field.Name) {
+			continue
+		}
+		f := tfField{Name: field.Name, Attr: tagutil.Snake.Apply(field.Name)}
+		if casing, ok := info.TagCasing("tf"); ok {
+			f.Attr = casing.Apply(field.Name)
+		}
+		var required, optional, computed, sensitive bool
+		if tag := field.TagValue("tf"); tag != nil {
+			if tag.Name == "-" {
+				continue
+			}
+			if tag.Name != "" {
+				f.Attr = tag.Name
+			}
+			for _, o := range tag.Options {
+				switch o.Name {
+				case "required":
+					required = true
+				case "optional":
+					optional = true
+				case "computed":
+					computed = true
+				case "sensitive":
+					sensitive = true
+				default:
+					p.Fatalf("%s: %s: unknown tf option %q", field.Pos, field.Name, o.Name)
+				}
+			}
+		}
+		if required && (optional || computed) {
+			p.Fatalf("%s: %s: a required attribute cannot be optional or computed", field.Pos, field.Name)
+		}
+		if !required && !computed {
+			optional = true
+		}
+		if field.GoType == nil {
+			p.Fatalf("%s: no type information for %s", field.Pos, field.Name)
+		}
+
+		model, elem, from, to, err := conversions(field.Name, field.GoType, qualifier)
+		if err != nil {
+			p.Fatalf("%s: %s: %s", field.Pos, field.Name, err)
+		}
+		f.ModelType, f.From, f.To = model, from, to
+
+		var attr []string
+		attrType := strings.TrimPrefix(model, "types.")
+		if attrType == "List" || attrType == "Map" {
+			attr = append(attr, "ElementType: types."+elem+"Type")
+		}
+		for _, o := range []struct {
+			name string
+			set  bool
+		}{{"Required", required}, {"Optional", optional}, {"Computed", computed}, {"Sensitive", sensitive}} {
+			if o.set {
+				attr = append(attr, o.name+": true")
+			}
+		}
+		f.Schema = fmt.Sprintf("schema.%sAttribute{%s}", attrType, strings.Join(attr, ", "))
+		fields = append(fields, f)
+	}
+
+	terraformTemplate.Execute(p, map[string]interface{}{
+		"Struct":     info.Name,
+		"SchemaKind": *schemaKind,
+		"Fields":     fields,
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-terraform",
+	FileSuffix:  "terraform",
+	GoFmtOutput: true,
+	SkipEmbedFS: true,
+}, generateTerraform)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+	if *schemaKind != "resource" && *schemaKind != "datasource" {
+		log.Fatalf("unknown schema kind %q", *schemaKind)
+	}
+
+	generator.Run()
+}
@@ -0,0 +1,15 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-toml -type=RateLimit
+
+type RateLimit struct {
+	Name    string        `toml:"name"`
+	Window  time.Duration `toml:"window,omitempty"`
+	Max     int64         `toml:"max-requests"`
+	Burst   float64       `toml:"burst"`
+	Enabled bool          `toml:"enabled"`
+	Routes  []string      `toml:"routes"`
+	Cache   string        `toml:"-"`
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/types"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+type tomlField struct {
+	Name    string
+	Type    string
+	Key     string
+	Assign  string // "key = ", the key quoted if it is not a bare key.
+	NonZero string // Set for omitempty fields.
+	Encode  string // Statements writing the field to buf.
+	Decode  string // Statements decoding v into out.
+	Err     string // Expression wrapping err from decoding the key.
+}
+
+var tomlTemplate = template.Must(template.New("toml").Parse(`
+// MarshalTOML implements toml.Marshaler of github.com/BurntSushi/toml.
+func ({{.Receiver}} {{.Struct}}) MarshalTOML() ([]byte, error) {
+	var buf bytes.Buffer
+{{- if .Quote}}
+	quote := func(s string) string {
+		var b strings.Builder
+		b.WriteByte('"')
+		for _, r := range s {
+			switch {
+			case r == '"' || r == '\\':
+				b.WriteByte('\\')
+				b.WriteRune(r)
+			case r == '\n':
+				b.WriteString("\\n")
+			case r == '\t':
+				b.WriteString("\\t")
+			case r < 0x20 || r == 0x7f:
+				fmt.Fprintf(&b, "\\u%04X", r)
+			default:
+				b.WriteRune(r)
+			}
+		}
+		b.WriteByte('"')
+		return b.String()
+	}
+{{- end}}
+{{- range .Fields}}
+	{{if .NonZero}}if {{.NonZero}} {{end}}{
+		buf.WriteString({{printf "%q" .Assign}})
+		{{.Encode}}buf.WriteString("\n")
+	}
+{{- end}}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalTOML implements toml.Unmarshaler of github.com/BurntSushi/toml.
+// Errors name the offending key; unknown keys are ignored.
+func ({{.Receiver}} *{{.Struct}}) UnmarshalTOML(data interface{}) error {
+	table, ok := data.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("cannot unmarshal %T into {{.Struct}}", data)
+	}
+{{- range .Fields}}
+	if value, ok := table[{{printf "%q" .Key}}]; ok {
+		var out {{.Type}}
+		err := func(v interface{}) error {
+			{{.Decode}}return nil
+		}(value)
+		if err != nil {
+			return {{.Err}}
+		}
+		{{$.Receiver}}.{{.Name}} = out
+	}
+{{- end}}
+	return nil
+}
+`))
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// encodeCode returns statements writing expr, a value of type t, to buf.
+func encodeCode(expr string, t types.Type) (string, error) {
+	if s, ok := t.Underlying().(*types.Slice); ok {
+		elem, err := encodeCode("e", s.Elem())
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("buf.WriteString(\"[\")\nfor i, e := range %s {\nif i > 0 {\nbuf.WriteString(\", \")\n}\n%s}\nbuf.WriteString(\"]\")\n", expr, elem), nil
+	}
+	format, err := structutil.FormatCode(expr, t)
+	if err != nil {
+		return "", err
+	}
+	if isString(t) {
+		format = "quote(" + format + ")"
+	}
+	return fmt.Sprintf("buf.WriteString(%s)\n", format), nil
+}
+
+// decodeCode returns statements decoding src, a value as produced by the
+// TOML decoder, into dst of type t.
+func decodeCode(src, dst string, t types.Type, qualifier types.Qualifier) (string, error) {
+	typeName := types.TypeString(t, qualifier)
+	expect := func(goType, name string) string {
+		return fmt.Sprintf("%s, ok := %s.(%s)\nif !ok {\nreturn fmt.Errorf(\"expected %s, got %%T\", %s)\n}\n", name, src, goType, goType, src)
+	}
+	if structutil.IsDuration(t) {
+		return expect("string", "str") + fmt.Sprintf("d, err := time.ParseDuration(str)\nif err != nil {\nreturn err\n}\n%s = d\n", dst), nil
+	}
+	if k, ok := structutil.LookupKnownType(t); ok {
+		return expect("string", "str") + fmt.Sprintf("k, err := %s\nif err != nil {\nreturn err\n}\n%s = k\n", fmt.Sprintf(k.Parse, "str"), dst), nil
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		info := u.Info()
+		switch {
+		case info&types.IsString != 0:
+			return expect("string", "str") + fmt.Sprintf("%s = %s(str)\n", dst, typeName), nil
+		case info&types.IsBoolean != 0:
+			return expect("bool", "b") + fmt.Sprintf("%s = %s(b)\n", dst, typeName), nil
+		case info&types.IsInteger != 0:
+			return expect("int64", "n") + fmt.Sprintf("%s = %s(n)\n", dst, typeName), nil
+		case info&types.IsFloat != 0:
+			return fmt.Sprintf("switch n := %[1]s.(type) {\ncase float64:\n%[2]s = %[3]s(n)\ncase int64:\n%[2]s = %[3]s(n)\ndefault:\nreturn fmt.Errorf(\"expected float64, got %%T\", %[1]s)\n}\n", src, dst, typeName), nil
+		}
+	case *types.Slice:
+		elem, err := decodeCode("elem", dst+"[i]", u.Elem(), qualifier)
+		if err != nil {
+			return "", err
+		}
+		return expect("[]interface{}", "list") + fmt.Sprintf("%s = make(%s, len(list))\nfor i, elem := range list {\n%s}\n", dst, typeName, elem), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+func isString(t types.Type) bool {
+	if _, known := structutil.LookupKnownType(t); known || structutil.IsDuration(t) {
+		return true
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+func generateTOML(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-toml %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+
+	receiver := strings.ToLower(info.Name[0:1])
+	qualifier := structutil.Qualifier(info.Package.GetPath())
+
+	var fields []tomlField
+	quote := false
+	for _, field := range info.Fields {
+		if field.GoType == nil {
+			p.Fatalf("%s: no type information for %s", field.Pos, field.Name)
+		}
+		key, ok := info.EffectiveName(field, "toml")
+		if !ok {
+			continue
+		}
+		f := tomlField{Name: field.Name, Type: field.Type, Key: key, Err: info.WrapError("err", key)}
+		if tag := field.TagValue("toml"); tag != nil && tag.HasOption("omitempty") {
+			f.NonZero = field.NonZeroTest(receiver + "." + field.Name)
+		}
+		f.Assign = f.Key + " = "
+		if !bareKey.MatchString(f.Key) {
+			f.Assign = strconv.Quote(f.Key) + " = "
+		}
+
+		var err error
+		if f.Encode, err = encodeCode(receiver+"."+field.Name, field.GoType); err == nil {
+			f.Decode, err = decodeCode("v", "out", field.GoType, qualifier)
+		}
+		if err != nil {
+			p.Fatalf("%s: %s: %s; exclude it with toml:\"-\"", field.Pos, field.Name, err)
+		}
+		if strings.Contains(f.Encode, "quote(") {
+			quote = true
+		}
+		fields = append(fields, f)
+	}
+
+	tomlTemplate.Execute(p, map[string]interface{}{
+		"Receiver": receiver,
+		"Struct":   info.Name,
+		"Fields":   fields,
+		"Quote":    quote,
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-toml",
+	FileSuffix:  "toml",
+	GoFmtOutput: true,
+	SkipEmbedFS: true,
+}, generateTOML)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
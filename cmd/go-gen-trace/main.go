@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/interfaceutil"
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var (
+	ifaceName = flag.String("iface", "", "interface name; must be set")
+	output    = flag.String("output", "", "output file name; default srcdir/<iface>_trace.go")
+	ctxFirst  = flag.Bool("ctx", false, "require every method of the interface to take a context.Context first")
+)
+
+type tracedMethod struct {
+	*interfaceutil.Method
+	Span  string   // Span name; empty if the method is not traced.
+	Attrs []string // attribute.KeyValue expressions of recorded parameters.
+}
+
+var traceTemplate = template.Must(template.New("trace").Parse(`
+// Traced{{.Iface}} starts a span for every call to the wrapped {{.Iface}}
+// that takes a context, and records returned errors on it.
+type Traced{{.Iface}} struct {
+	next   {{.Iface}}
+	tracer trace.Tracer
+}
+
+// Trace{{.Iface}} returns next traced with tracer.
+func Trace{{.Iface}}(next {{.Iface}}, tracer trace.Tracer) *Traced{{.Iface}} {
+	return &Traced{{.Iface}}{next: next, tracer: tracer}
+}
+{{range .Methods}}{{$t := .Local "t"}}
+func ({{$t}} *Traced{{$.Iface}}) {{.Name}}{{.Signature}} {
+{{- if .Span}}
+	{{$span := .Local "span"}}{{.ContextParam}}, {{$span}} := {{$t}}.tracer.Start({{.ContextParam}}, {{printf "%q" .Span}})
+	defer {{$span}}.End()
+{{- if .Attrs}}
+	{{$span}}.SetAttributes({{range $i, $a := .Attrs}}{{if $i}}, {{end}}{{$a}}{{end}})
+{{- end}}
+{{- if .Results}}
+	{{.ResultNames}} := {{$t}}.next.{{.Name}}({{.Args}})
+{{- if .ReturnsError}}
+	if {{.ErrorResult}} != nil {
+		{{$span}}.RecordError({{.ErrorResult}})
+		{{$span}}.SetStatus(codes.Error, {{.ErrorResult}}.Error())
+	}
+{{- end}}
+	return {{.ResultNames}}
+{{- else}}
+	{{$t}}.next.{{.Name}}({{.Args}})
+{{- end}}
+{{- else}}
+	{{if .Results}}return {{end}}{{$t}}.next.{{.Name}}({{.Args}})
+{{- end}}
+}
+{{end}}`))
+
+// attribute returns an attribute.KeyValue expression for the parameter p.
+func attribute(p *interfaceutil.Param) (string, error) {
+	kind := ""
+	switch u := p.GoType.Underlying().(type) {
+	case *types.Basic:
+		info := u.Info()
+		switch {
+		case info&types.IsString != 0:
+			kind = "String"
+		case info&types.IsBoolean != 0:
+			kind = "Bool"
+		case u.Kind() == types.Int64:
+			kind = "Int64"
+		case info&types.IsInteger != 0:
+			return fmt.Sprintf("attribute.Int64(%q, int64(%s))", p.Name, p.Name), nil
+		case info&types.IsFloat != 0:
+			return fmt.Sprintf("attribute.Float64(%q, float64(%s))", p.Name, p.Name), nil
+		}
+	}
+	if kind == "" {
+		if types.Implements(p.GoType, stringer) {
+			return fmt.Sprintf("attribute.Stringer(%q, %s)", p.Name, p.Name), nil
+		}
+		return "", fmt.Errorf("cannot record parameter %s of type %s", p.Name, p.Type)
+	}
+	return fmt.Sprintf("attribute.%s(%q, %s)", kind, p.Name, p.Name), nil
+}
+
+var stringer = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(0, nil, "String", types.NewSignature(nil, nil,
+		types.NewTuple(types.NewVar(0, nil, "", types.Typ[types.String])), false)),
+}, nil).Complete()
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-trace:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-trace [flags] -iface I [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-trace [flags] -iface I files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Spans are named <package>.<iface>.<method>; the prefix is set with\n")
+	fmt.Fprintf(os.Stderr, "\t//gentoolkit:trace prefix=P\n")
+	fmt.Fprintf(os.Stderr, "on the interface, methods take //gentoolkit:trace skip, name=N or attrs=p1,p2.\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-trace: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *ifaceName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := interfaceutil.ParsePackage(args...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	iface := pkg.Lookup(*ifaceName)
+	if iface == nil {
+		log.Fatalf("interface %s not found", *ifaceName)
+	}
+	if *ctxFirst {
+		if err := iface.CheckContext(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	prefix := pkg.Name + "." + iface.Name
+	if d := iface.Directive("trace"); d != nil && d.Has("prefix") {
+		prefix = d.Get("prefix")
+	}
+	var methods []tracedMethod
+	for _, m := range iface.Methods {
+		tm := tracedMethod{Method: m}
+		d := m.Directive("trace")
+		if d == nil {
+			d = &structutil.Directive{Name: "trace"}
+		}
+		if m.HasContext() && !d.Has("skip") {
+			tm.Span = prefix + "." + m.Name
+			if d.Has("name") {
+				tm.Span = d.Get("name")
+			}
+		}
+		if d.Has("attrs") {
+			for _, name := range strings.Split(d.Get("attrs"), ",") {
+				var param *interfaceutil.Param
+				for _, p := range m.Params {
+					if p.Name == name {
+						param = p
+					}
+				}
+				if param == nil {
+					log.Fatalf("%s: %s has no parameter %s", m.Pos, m.Name, name)
+				}
+				attr, err := attribute(param)
+				if err != nil {
+					log.Fatalf("%s: %s", m.Pos, err)
+				}
+				tm.Attrs = append(tm.Attrs, attr)
+			}
+		}
+		methods = append(methods, tm)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-trace %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "import (\n")
+	fmt.Fprintf(&buf, "\t\"go.opentelemetry.io/otel/attribute\"\n")
+	fmt.Fprintf(&buf, "\t\"go.opentelemetry.io/otel/codes\"\n")
+	fmt.Fprintf(&buf, "\t\"go.opentelemetry.io/otel/trace\"\n")
+	fmt.Fprintf(&buf, ")\n")
+	err = traceTemplate.Execute(&buf, map[string]interface{}{
+		"Iface":   iface.Name,
+		"Methods": methods,
+	})
+	if err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, tagutil.Snake.Apply(iface.Name)+"_trace.go")
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), iface.Pos.Filename); err != nil {
+		log.Fatal(err)
+	}
+}
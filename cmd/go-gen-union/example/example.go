@@ -0,0 +1,11 @@
+package example
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-union -name=Shape -variants=Circle,Square -discriminator=kind
+
+type Circle struct {
+	Radius float64 `json:"radius"`
+}
+
+type Square struct {
+	Side float64 `json:"side"`
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+var (
+	unionName     = flag.String("name", "", "name of the union interface; must be set")
+	variantNames  = flag.String("variants", "", "comma-separated list of variant struct names; must be set")
+	discriminator = flag.String("discriminator", "type", "JSON property holding the variant name")
+	output        = flag.String("output", "", "output file name; default srcdir/<name>_union.go")
+)
+
+type variant struct {
+	Name string
+	Tag  string // Discriminator value.
+}
+
+var unionTemplate = template.Must(template.New("union").Parse(`
+// {{.Name}} is one of {{range $i, $v := .Variants}}{{if $i}}, {{end}}*{{$v.Name}}{{end}}.
+type {{.Name}} interface {
+	is{{.Name}}()
+}
+{{range .Variants}}
+func (*{{.Name}}) is{{$.Name}}() {}
+
+// {{$.Name}}From{{.Name}} returns v as a {{$.Name}}.
+func {{$.Name}}From{{.Name}}(v *{{.Name}}) {{$.Name}} {
+	return v
+}
+{{end}}
+// Match{{.Name}} calls the function matching the variant held by u. Adding
+// a variant adds a parameter, so callers that do not handle it fail to
+// compile.
+func Match{{.Name}}(u {{.Name}}{{range .Variants}}, on{{.Name}} func(*{{.Name}}) error{{end}}) error {
+	switch v := u.(type) {
+{{- range .Variants}}
+	case *{{.Name}}:
+		return on{{.Name}}(v)
+{{- end}}
+	}
+	return fmt.Errorf("unexpected {{.Name}} %T", u)
+}
+
+// {{.Name}}JSON marshals a {{.Name}} with a {{printf "%q" .Discriminator}} discriminator property.
+type {{.Name}}JSON struct {
+	{{.Name}} {{.Name}}
+}
+
+func (w {{.Name}}JSON) MarshalJSON() ([]byte, error) {
+	var tag string
+	switch w.{{.Name}}.(type) {
+{{- range .Variants}}
+	case *{{.Name}}:
+		tag = {{printf "%q" .Tag}}
+{{- end}}
+	default:
+		return nil, fmt.Errorf("unexpected {{.Name}} %T", w.{{.Name}})
+	}
+	data, err := json.Marshal(w.{{.Name}})
+	if err != nil {
+		return nil, err
+	}
+	var props map[string]json.RawMessage
+	if err := json.Unmarshal(data, &props); err != nil {
+		return nil, err
+	}
+	if props == nil {
+		props = make(map[string]json.RawMessage)
+	}
+	props[{{printf "%q" .Discriminator}}], _ = json.Marshal(tag)
+	return json.Marshal(props)
+}
+
+func (w *{{.Name}}JSON) UnmarshalJSON(data []byte) error {
+	var head struct {
+		Tag string ` + "`" + `json:{{printf "%q" .Discriminator}}` + "`" + `
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return err
+	}
+	switch head.Tag {
+{{- range .Variants}}
+	case {{printf "%q" .Tag}}:
+		v := new({{.Name}})
+		if err := json.Unmarshal(data, v); err != nil {
+			return err
+		}
+		w.{{$.Name}} = v
+{{- end}}
+	default:
+		return fmt.Errorf("unknown {{.Name}} variant %q", head.Tag)
+	}
+	return nil
+}
+`))
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-union:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-union [flags] -name U -variants A,B [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-union [flags] -name U -variants A,B files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-union: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *unionName == "" || *variantNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := structutil.LoadPackage(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var (
+		variants []variant
+		inputs   []string
+	)
+	for _, name := range strings.Split(*variantNames, ",") {
+		obj, ok := pkg.Types.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			log.Fatalf("variant %s not found", name)
+		}
+		if _, ok := obj.Type().Underlying().(*types.Struct); !ok {
+			log.Fatalf("variant %s is not a struct", name)
+		}
+		variants = append(variants, variant{Name: name, Tag: tagutil.Snake.Apply(name)})
+		inputs = append(inputs, pkg.Fset.Position(obj.Pos()).Filename)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-union %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	err = unionTemplate.Execute(&buf, map[string]interface{}{
+		"Name":          *unionName,
+		"Variants":      variants,
+		"Discriminator": *discriminator,
+	})
+	if err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, tagutil.Snake.Apply(*unionName)+"_union.go")
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), inputs...); err != nil {
+		log.Fatal(err)
+	}
+}
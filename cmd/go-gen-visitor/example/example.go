@@ -0,0 +1,19 @@
+package example
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-visitor -iface=Expr
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-visitor -iface=Expr -mode=switch -output=expr_switch.go
+
+type Expr interface {
+	expr()
+}
+
+type Literal struct {
+	Value int
+}
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (*Literal) expr() {}
+func (*Add) expr()     {}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	ifaceName = flag.String("iface", "", "marker interface name; must be set")
+	mode      = flag.String("mode", "visitor", "visitor (Visitor interface and Accept methods) or switch (exhaustive Switch function)")
+	output    = flag.String("output", "", "output file name; default srcdir/<iface>_visitor.go")
+)
+
+type variant struct {
+	Name string // Type name.
+	Ref  string // Type as used in signatures, *T for pointer implementations.
+}
+
+var visitorTemplate = template.Must(template.New("visitor").Parse(`
+// {{.Iface}}Visitor has a Visit method for every {{.Iface}} implementation.
+type {{.Iface}}Visitor interface {
+{{- range .Variants}}
+	Visit{{.Name}}(n {{.Ref}})
+{{- end}}
+}
+{{range .Variants}}
+// Accept calls v.Visit{{.Name}}.
+func (n {{.Ref}}) Accept(v {{$.Iface}}Visitor) {
+	v.Visit{{.Name}}(n)
+}
+{{end}}`))
+
+var switchTemplate = template.Must(template.New("switch").Parse(`
+// Switch{{.Iface}} calls the function matching the dynamic type of n. Adding
+// an implementation of {{.Iface}} adds a parameter, so callers that do not
+// handle it fail to compile.
+func Switch{{.Iface}}(n {{.Iface}}{{range .Variants}}, on{{.Name}} func({{.Ref}}){{end}}) {
+	switch n := n.(type) {
+{{- range .Variants}}
+	case {{.Ref}}:
+		on{{.Name}}(n)
+{{- end}}
+	default:
+		panic(fmt.Sprintf("unexpected {{.Iface}} %T", n))
+	}
+}
+`))
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of go-gen-visitor:\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-visitor [flags] -iface I [directory]\n")
+	fmt.Fprintf(os.Stderr, "\tgo-gen-visitor [flags] -iface I files... # Must be a single package\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("go-gen-visitor: ")
+	flag.Usage = usage
+	flag.Parse()
+	if *ifaceName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	tmpl := visitorTemplate
+	switch *mode {
+	case "visitor":
+	case "switch":
+		tmpl = switchTemplate
+	default:
+		log.Fatalf("unknown mode %q", *mode)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	dir, err := structutil.SourceDir(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkg, err := structutil.LoadPackage(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	variants, inputs, err := implementations(pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by \"go-gen-visitor %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg.Name)
+	err = tmpl.Execute(&buf, map[string]interface{}{
+		"Iface":    *ifaceName,
+		"Variants": variants,
+	})
+	if err != nil {
+		log.Fatalf("generating output: %s", err)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, tagutil.Snake.Apply(*ifaceName)+"_visitor.go")
+	}
+	if err := structutil.WriteGenerated(outputName, buf.Bytes(), inputs...); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// implementations returns the named non-interface types of pkg implementing
+// the marker interface, in source order, and the files declaring them.
+func implementations(pkg *packages.Package) ([]variant, []string, error) {
+	obj, ok := pkg.Types.Scope().Lookup(*ifaceName).(*types.TypeName)
+	if !ok {
+		return nil, nil, fmt.Errorf("interface %s not found", *ifaceName)
+	}
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not an interface", *ifaceName)
+	}
+
+	var names []*types.TypeName
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || tn == obj {
+			continue
+		}
+		if _, ok := tn.Type().Underlying().(*types.Interface); ok {
+			continue
+		}
+		names = append(names, tn)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i].Pos() < names[j].Pos() })
+
+	var (
+		variants []variant
+		inputs   []string
+		seen     = make(map[string]bool)
+	)
+	for _, tn := range names {
+		var ref string
+		switch {
+		case types.Implements(tn.Type(), iface):
+			ref = tn.Name()
+		case types.Implements(types.NewPointer(tn.Type()), iface):
+			ref = "*" + tn.Name()
+		default:
+			continue
+		}
+		variants = append(variants, variant{Name: tn.Name(), Ref: ref})
+		if file := pkg.Fset.Position(tn.Pos()).Filename; !seen[file] {
+			seen[file] = true
+			inputs = append(inputs, file)
+		}
+	}
+	if len(variants) == 0 {
+		return nil, nil, fmt.Errorf("no implementations of %s found", *ifaceName)
+	}
+	return variants, inputs, nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"flag"
+	"go/token"
+	"go/types"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+var webhookTemplate = template.Must(template.New("webhook").Parse(`
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// {{.Webhook}} implements the defaulting and validating admission webhooks
+// of {{.Struct}}. Defaulting calls the ApplyDefaults method of go-gen-defaults;
+// validation calls the Validate() error method of {{.Struct}}, and on updates
+// and deletes its ValidateUpdate(old *{{.Struct}}) error and ValidateDelete()
+// error methods, each if {{.Struct}} has it.
+type {{.Webhook}} struct{}
+
+var (
+	_ admission.CustomDefaulter = {{.Webhook}}{}
+	_ admission.CustomValidator = {{.Webhook}}{}
+)
+
+// SetupWebhookWithManager registers the webhooks of {{.Struct}} with mgr.
+func (w {{.Webhook}}) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&{{.Struct}}{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+func ({{.Webhook}}) object(obj runtime.Object) (*{{.Struct}}, error) {
+	o, ok := obj.(*{{.Struct}})
+	if !ok {
+		return nil, fmt.Errorf("expected a *{{.Struct}}, got %T", obj)
+	}
+	return o, nil
+}
+
+func ({{.Webhook}}) validate(o *{{.Struct}}) error {
+	if v, ok := interface{}(o).(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// Default implements admission.CustomDefaulter.
+func (w {{.Webhook}}) Default(ctx context.Context, obj runtime.Object) error {
+	o, err := w.object(obj)
+	if err != nil {
+		return err
+	}
+	if d, ok := interface{}(o).(interface{ ApplyDefaults() []string }); ok {
+		d.ApplyDefaults()
+	}
+	return nil
+}
+
+// ValidateCreate implements admission.CustomValidator.
+func (w {{.Webhook}}) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	o, err := w.object(obj)
+	if err != nil {
+		return nil, err
+	}
+	return nil, w.validate(o)
+}
+
+// ValidateUpdate implements admission.CustomValidator.
+func (w {{.Webhook}}) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	old, err := w.object(oldObj)
+	if err != nil {
+		return nil, err
+	}
+	o, err := w.object(newObj)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.validate(o); err != nil {
+		return nil, err
+	}
+	if v, ok := interface{}(o).(interface{ ValidateUpdate(old *{{.Struct}}) error }); ok {
+		return nil, v.ValidateUpdate(old)
+	}
+	return nil, nil
+}
+
+// ValidateDelete implements admission.CustomValidator.
+func (w {{.Webhook}}) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	o, err := w.object(obj)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := interface{}(o).(interface{ ValidateDelete() error }); ok {
+		return nil, v.ValidateDelete()
+	}
+	return nil, nil
+}
+`))
+
+// checkHooks fails if the struct has one of the methods the webhook calls
+// with a signature other than the one the webhook expects, which it would
+// silently not call.
+func checkHooks(p structutil.PrinterWriter, info *structutil.StructInfo) {
+	pkg := info.Package.GetTypes()
+	if pkg == nil {
+		p.Fatalf("no type information for %s", info.Name)
+	}
+	obj := pkg.Scope().Lookup(info.Name)
+	if obj == nil {
+		p.Fatalf("type %s not found", info.Name)
+	}
+	ptr := types.NewPointer(obj.Type())
+	errorType := types.Universe.Lookup("error").Type()
+	signature := func(params []types.Type, result types.Type) *types.Signature {
+		vars := make([]*types.Var, len(params))
+		for i, t := range params {
+			vars[i] = types.NewParam(token.NoPos, pkg, "", t)
+		}
+		return types.NewSignature(nil, types.NewTuple(vars...), types.NewTuple(types.NewParam(token.NoPos, pkg, "", result)), false)
+	}
+	hooks := []struct {
+		name string
+		want *types.Signature
+	}{
+		{"ApplyDefaults", signature(nil, types.NewSlice(types.Typ[types.String]))},
+		{"Validate", signature(nil, errorType)},
+		{"ValidateUpdate", signature([]types.Type{ptr}, errorType)},
+		{"ValidateDelete", signature(nil, errorType)},
+	}
+	qualifier := structutil.Qualifier(pkg.Path())
+	methods := types.NewMethodSet(ptr)
+	for _, hook := range hooks {
+		sel := methods.Lookup(pkg, hook.name)
+		if sel == nil {
+			continue
+		}
+		if got := sel.Type().(*types.Signature); !types.Identical(got, hook.want) {
+			p.Fatalf("%s.%s has signature %s; the webhook only calls %s%s", info.Name, hook.name,
+				types.TypeString(got, qualifier), hook.name, strings.TrimPrefix(types.TypeString(hook.want, qualifier), "func"))
+		}
+	}
+}
+
+func generateWebhook(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	checkHooks(p, info)
+
+	p.Printf("// Code generated by \"go-gen-webhook %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+
+	webhookTemplate.Execute(p, map[string]interface{}{
+		"Struct":  info.Name,
+		"Webhook": info.Name + "Webhook",
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-webhook",
+	FileSuffix:  "webhook",
+	GoFmtOutput: true,
+}, generateWebhook)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
@@ -0,0 +1,26 @@
+package example
+
+import "time"
+
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-wither -type=ServerConfig
+//go:generate go run github.com/jakoblorz/go-gentoolkit/cmd/go-gen-wither -type=ClientConfig -clone
+
+type ServerConfig struct {
+	Addr         string
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+type ClientConfig struct {
+	BaseURL string
+	Headers map[string]string
+}
+
+func (c *ClientConfig) Clone() *ClientConfig {
+	c2 := *c
+	c2.Headers = make(map[string]string, len(c.Headers))
+	for k, v := range c.Headers {
+		c2.Headers[k] = v
+	}
+	return &c2
+}
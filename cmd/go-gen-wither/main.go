@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+var deepCopy = flag.Bool("clone", false, "copy the receiver with its Clone() *T method instead of by value, so reference fields are not shared")
+
+var witherTemplate = template.Must(template.New("wither").Parse(`func ({{.Receiver}} {{.Struct}}) {{.Method}}(param {{.Type}}) {{.Struct}} {
+{{- if .Clone}}
+	clone := {{.Receiver}}.Clone()
+	clone.{{.Field}} = param
+	return *clone
+{{- else}}
+	{{.Receiver}}.{{.Field}} = param
+	return {{.Receiver}}
+{{- end}}
+}`))
+
+func generateWither(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-wither %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+	p.Printf("\n\n")
+	for _, field := range info.Fields {
+		p.Printf("\n")
+		p.Annotate(field)
+		witherTemplate.Execute(p, map[string]interface{}{
+			"Receiver": strings.ToLower(info.Name[0:1]),
+			"Struct":   info.Name,
+			"Field":    field.Name,
+			"Method":   info.Naming().MethodName("With", field.Name),
+			"Type":     field.Type,
+			"Clone":    *deepCopy,
+		})
+	}
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-wither",
+	FileSuffix:  "wither",
+	GoFmtOutput: true,
+}, generateWither)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
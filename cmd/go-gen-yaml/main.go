@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"go/types"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+type yamlField struct {
+	Name    string
+	Type    string
+	Key     string
+	NonZero string // Set for omitempty fields.
+	Tag     string // YAML tag of scalar fields; empty if encoded by yaml.v3.
+	Format  string // Expression formatting a scalar field.
+	Parse   string // Statements parsing s into a scalar field.
+	Err     string // Expression wrapping err from parsing the scalar.
+}
+
+var yamlTemplate = template.Must(template.New("yaml").Parse(`
+// MarshalYAML implements yaml.Marshaler.
+func ({{.Receiver}} {{.Struct}}) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+{{- range .Fields}}
+	{{if .NonZero}}if {{.NonZero}} {{end}}{
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: {{printf "%q" .Key}}}
+{{- if .Tag}}
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: {{printf "%q" .Tag}}, Value: {{.Format}}}
+{{- else}}
+		value := new(yaml.Node)
+		if err := value.Encode({{$.Receiver}}.{{.Name}}); err != nil {
+			return nil, err
+		}
+{{- end}}
+		node.Content = append(node.Content, key, value)
+	}
+{{- end}}
+	return node, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. Errors carry the line and
+// column of the offending node; unknown keys are ignored.
+func ({{.Receiver}} *{{.Struct}}) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d, column %d: cannot unmarshal into {{.Struct}}: not a mapping", node.Line, node.Column)
+	}
+	content := node.Content
+	for len(content) >= 2 {
+		key, value := content[0], content[1]
+		content = content[2:]
+		switch key.Value {
+{{- range .Fields}}
+		case {{printf "%q" .Key}}:
+{{- if .Parse}}
+			var out {{.Type}}
+			err := func(s string) error {
+				if value.Kind != yaml.ScalarNode {
+					return errors.New("not a scalar")
+				}
+				{{.Parse}}return nil
+			}(value.Value)
+			if err != nil {
+				return {{.Err}}
+			}
+			{{$.Receiver}}.{{.Name}} = out
+{{- else}}
+			if err := value.Decode(&{{$.Receiver}}.{{.Name}}); err != nil {
+				return err
+			}
+{{- end}}
+{{- end}}
+		}
+	}
+	return nil
+}
+`))
+
+// scalarTag returns the YAML tag for values of the scalar type t.
+func scalarTag(t types.Type) string {
+	if _, known := structutil.LookupKnownType(t); known || structutil.IsDuration(t) {
+		// Known types such as decimals are strings to keep their precision.
+		return "!!str"
+	}
+	info := t.Underlying().(*types.Basic).Info()
+	switch {
+	case info&types.IsBoolean != 0:
+		return "!!bool"
+	case info&types.IsInteger != 0:
+		return "!!int"
+	case info&types.IsFloat != 0:
+		return "!!float"
+	}
+	return "!!str"
+}
+
+func generateYAML(info *structutil.StructInfo, p structutil.PrinterWriter) {
+	p.Printf("// Code generated by \"go-gen-yaml %s\"; DO NOT EDIT.\n", structutil.JoinArgs(os.Args[1:]))
+	p.Printf("\n")
+	p.Printf("package %s\n", info.Package.GetName())
+	p.Printf("\n")
+	p.Printf("import \"gopkg.in/yaml.v3\"\n")
+
+	receiver := strings.ToLower(info.Name[0:1])
+	var fields []yamlField
+	for _, field := range info.Fields {
+		if field.GoType == nil {
+			p.Fatalf("%s: no type information for %s", field.Pos, field.Name)
+		}
+		key, ok := info.EffectiveName(field, "yaml")
+		if !ok {
+			continue
+		}
+		if _, cased := info.TagCasing("yaml"); !cased && strings.Split(field.Tag("yaml"), ",")[0] == "" {
+			// yaml.v3 lowercases untagged field names.
+			key = strings.ToLower(field.Name)
+		}
+		f := yamlField{Name: field.Name, Key: key, Err: info.WrapError("err", "line %d, column %d: "+key, "value.Line", "value.Column")}
+		if tag := field.TagValue("yaml"); tag != nil && tag.HasOption("omitempty") {
+			f.NonZero = field.NonZeroTest(receiver + "." + field.Name)
+		}
+		if format, err := structutil.FormatCode(receiver+"."+field.Name, field.GoType); err == nil {
+			parse, err := structutil.ParseCode("s", "out", field.GoType, info.Package.GetPath())
+			if err != nil {
+				p.Fatalf("%s: %s: %s", field.Pos, field.Name, err)
+			}
+			f.Type = field.Type
+			f.Tag, f.Format, f.Parse = scalarTag(field.GoType), format, parse
+		}
+		fields = append(fields, f)
+	}
+
+	yamlTemplate.Execute(p, map[string]interface{}{
+		"Receiver": receiver,
+		"Struct":   info.Name,
+		"Fields":   fields,
+	})
+}
+
+var generator = structutil.NewForFieldsGenerator(&structutil.GenerateForFieldsConfig{
+	ToolName:    "go-gen-yaml",
+	FileSuffix:  "yaml",
+	GoFmtOutput: true,
+	SkipEmbedFS: true,
+}, generateYAML)
+
+func init() {
+	generator.Init()
+}
+
+func main() {
+	generator.OpinionatedPreRun()
+	flag.Parse()
+
+	generator.Run()
+}
@@ -0,0 +1,207 @@
+// Package constutil parses const declarations, including iota sequences,
+// into a model enum-style generators and documentation tools can consume.
+package constutil
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// Const is a single named constant.
+type Const struct {
+	Name    string
+	Type    string         // Type name relative to the declaring package.
+	Value   constant.Value // Evaluated value.
+	Expr    string         // Source expression; empty for implicit repetition.
+	Iota    int            // Value of iota in the constant's spec.
+	Doc     string
+	Comment string
+	Pos     token.Position
+
+	Directives []*structutil.Directive
+}
+
+// Directive returns the constant's first directive with the given name, or
+// nil.
+func (c *Const) Directive(name string) *structutil.Directive {
+	for _, d := range c.Directives {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// Group is a const declaration, either a single const or a parenthesized
+// block.
+type Group struct {
+	Type     string // Type shared by all constants; empty if they differ.
+	UsesIota bool
+	Consts   []Const
+	Doc      string
+	Pos      token.Position
+}
+
+// Names returns the names of the constants in the group.
+func (g *Group) Names() []string {
+	names := make([]string, len(g.Consts))
+	for i, c := range g.Consts {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// OfType returns the constants of the group declared with the named type.
+func (g *Group) OfType(typeName string) []Const {
+	var consts []Const
+	for _, c := range g.Consts {
+		if c.Type == typeName {
+			consts = append(consts, c)
+		}
+	}
+	return consts
+}
+
+// Package holds the const declarations of a package.
+type Package struct {
+	Name   string
+	Path   string
+	Groups []*Group
+}
+
+// OfType returns the constants of the package declared with the named type,
+// in source order.
+func (p *Package) OfType(typeName string) []Const {
+	var consts []Const
+	for _, g := range p.Groups {
+		consts = append(consts, g.OfType(typeName)...)
+	}
+	return consts
+}
+
+// ParsePackage loads the single package matched by patterns and parses its
+// const declarations in source order.
+func ParsePackage(patterns ...string) (*Package, error) {
+	cfg := &packages.Config{
+		Mode:  packages.LoadSyntax,
+		Tests: false,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%d packages found", len(pkgs))
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return nil, pkg.Errors[0]
+	}
+
+	p := &Package{Name: pkg.Name, Path: pkg.PkgPath}
+	for _, file := range pkg.Syntax {
+		groups, err := ParseFile(file, pkg.Fset, pkg.Types, pkg.TypesInfo)
+		if err != nil {
+			return nil, err
+		}
+		p.Groups = append(p.Groups, groups...)
+	}
+	return p, nil
+}
+
+// ParseFile parses the top-level const declarations of a type-checked file.
+func ParseFile(file *ast.File, fset *token.FileSet, pkg *types.Package, info *types.Info) ([]*Group, error) {
+	qualifier := types.RelativeTo(pkg)
+
+	var groups []*Group
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.CONST {
+			continue
+		}
+		group := &Group{
+			Doc: text(gd.Doc),
+			Pos: fset.Position(gd.Pos()),
+		}
+		for i, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			doc := vs.Doc
+			if !gd.Lparen.IsValid() {
+				doc = gd.Doc
+			}
+			directives, err := structutil.ParseDirectives(doc)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fset.Position(vs.Pos()), err)
+			}
+			for j, name := range vs.Names {
+				obj, ok := info.Defs[name].(*types.Const)
+				if !ok || name.Name == "_" {
+					continue
+				}
+				c := Const{
+					Name:    name.Name,
+					Type:    types.TypeString(obj.Type(), qualifier),
+					Value:   obj.Val(),
+					Iota:    i,
+					Doc:     text(doc),
+					Comment: text(vs.Comment),
+					Pos:     fset.Position(name.Pos()),
+
+					Directives: directives,
+				}
+				if j < len(vs.Values) {
+					c.Expr = source(fset, vs.Values[j])
+					if usesIota(vs.Values[j]) {
+						group.UsesIota = true
+					}
+				}
+				group.Consts = append(group.Consts, c)
+			}
+		}
+		if len(group.Consts) == 0 {
+			continue
+		}
+		group.Type = group.Consts[0].Type
+		for _, c := range group.Consts[1:] {
+			if c.Type != group.Type {
+				group.Type = ""
+				break
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+func usesIota(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == "iota" {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func text(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	return strings.TrimSpace(cg.Text())
+}
+
+func source(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fset, node)
+	return buf.String()
+}
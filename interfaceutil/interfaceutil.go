@@ -0,0 +1,363 @@
+// Package interfaceutil parses interface declarations into a model that
+// decorator, client and mock generators can consume.
+package interfaceutil
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/jakoblorz/go-gentoolkit/structutil"
+)
+
+// Param is a parameter or result of a method.
+type Param struct {
+	Name   string // Declared name, or p<i>/r<i> if unnamed or blank.
+	Type   string // Type relative to the declaring package; []T if variadic.
+	GoType types.Type
+}
+
+// Method is a method of an interface, including embedded ones.
+type Method struct {
+	Name     string
+	Params   []*Param
+	Results  []*Param
+	Variadic bool
+	Doc      string
+	Pos      token.Position
+
+	Directives []*structutil.Directive
+}
+
+// Directive returns the method's first directive with the given name, or
+// nil.
+func (m *Method) Directive(name string) *structutil.Directive {
+	return lookup(m.Directives, name)
+}
+
+// HasContext reports whether the first parameter is a context.Context.
+func (m *Method) HasContext() bool {
+	return len(m.Params) > 0 && isNamed(m.Params[0].GoType, "context", "Context")
+}
+
+// ReturnsError reports whether the last result is an error.
+func (m *Method) ReturnsError() bool {
+	return len(m.Results) > 0 && types.Identical(m.Results[len(m.Results)-1].GoType, types.Universe.Lookup("error").Type())
+}
+
+// ContextParam returns the name of the context.Context parameter; it panics
+// if the method has none.
+func (m *Method) ContextParam() string {
+	if !m.HasContext() {
+		panic(fmt.Sprintf("method %s has no context parameter", m.Name))
+	}
+	return m.Params[0].Name
+}
+
+// ErrorResult returns the name of the error result; it panics if the method
+// does not return one.
+func (m *Method) ErrorResult() string {
+	if !m.ReturnsError() {
+		panic(fmt.Sprintf("method %s does not return an error", m.Name))
+	}
+	return m.Results[len(m.Results)-1].Name
+}
+
+// ParamList returns the parameter list without parentheses, for example
+// "ctx context.Context, ids ...int64".
+func (m *Method) ParamList() string {
+	list := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		typ := p.Type
+		if m.Variadic && i == len(m.Params)-1 {
+			typ = "..." + strings.TrimPrefix(typ, "[]")
+		}
+		list[i] = p.Name + " " + typ
+	}
+	return strings.Join(list, ", ")
+}
+
+// Args returns the parameter names as call arguments, for example
+// "ctx, ids...".
+func (m *Method) Args() string {
+	names := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		names[i] = p.Name
+	}
+	args := strings.Join(names, ", ")
+	if m.Variadic {
+		args += "..."
+	}
+	return args
+}
+
+// ResultList returns the result types as written after a parameter list:
+// empty, a single type, or a parenthesized list.
+func (m *Method) ResultList() string {
+	switch len(m.Results) {
+	case 0:
+		return ""
+	case 1:
+		return m.Results[0].Type
+	}
+	list := make([]string, len(m.Results))
+	for i, r := range m.Results {
+		list[i] = r.Type
+	}
+	return "(" + strings.Join(list, ", ") + ")"
+}
+
+// ResultNames returns the result names as a comma-separated list.
+func (m *Method) ResultNames() string {
+	names := make([]string, len(m.Results))
+	for i, r := range m.Results {
+		names[i] = r.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// Signature returns the parameter and result lists, for example
+// "(ctx context.Context, id int64) (*User, error)".
+func (m *Method) Signature() string {
+	if results := m.ResultList(); results != "" {
+		return "(" + m.ParamList() + ") " + results
+	}
+	return "(" + m.ParamList() + ")"
+}
+
+// Local returns name, suffixed with underscores until it differs from the
+// names of all parameters and results, for locals of generated method
+// bodies.
+func (m *Method) Local(name string) string {
+	for m.declares(name) {
+		name += "_"
+	}
+	return name
+}
+
+func (m *Method) declares(name string) bool {
+	for _, p := range m.Params {
+		if p.Name == name {
+			return true
+		}
+	}
+	for _, r := range m.Results {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Interface is a named interface type.
+type Interface struct {
+	Name    string
+	Methods []*Method // Declared methods in source order, then embedded ones.
+	Doc     string
+	Pos     token.Position
+
+	Directives []*structutil.Directive
+}
+
+// Directive returns the interface's first directive with the given name, or
+// nil.
+func (i *Interface) Directive(name string) *structutil.Directive {
+	return lookup(i.Directives, name)
+}
+
+// Method returns the named method, or nil.
+func (i *Interface) Method(name string) *Method {
+	for _, m := range i.Methods {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// CheckContext returns an error naming the first method that does not take
+// a context.Context as its first parameter, for generators run with -ctx.
+func (i *Interface) CheckContext() error {
+	for _, m := range i.Methods {
+		if !m.HasContext() {
+			return fmt.Errorf("%s: method %s.%s must take a context.Context as its first parameter", m.Pos, i.Name, m.Name)
+		}
+	}
+	return nil
+}
+
+// Package holds the interface declarations of a package.
+type Package struct {
+	Name       string
+	Path       string
+	Interfaces []*Interface
+}
+
+// Lookup returns the named interface, or nil.
+func (p *Package) Lookup(name string) *Interface {
+	for _, i := range p.Interfaces {
+		if i.Name == name {
+			return i
+		}
+	}
+	return nil
+}
+
+// ParsePackage loads the single package matched by patterns and parses its
+// interface declarations in source order.
+func ParsePackage(patterns ...string) (*Package, error) {
+	pkg, err := structutil.LoadPackage(patterns)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkg.Errors) > 0 {
+		return nil, pkg.Errors[0]
+	}
+
+	p := &Package{Name: pkg.Name, Path: pkg.PkgPath}
+	for _, file := range pkg.Syntax {
+		ifaces, err := ParseFile(file, pkg.Fset, pkg.Types, pkg.TypesInfo)
+		if err != nil {
+			return nil, err
+		}
+		p.Interfaces = append(p.Interfaces, ifaces...)
+	}
+	return p, nil
+}
+
+// ParseFile parses the top-level interface declarations of a type-checked
+// file.
+func ParseFile(file *ast.File, fset *token.FileSet, pkg *types.Package, info *types.Info) ([]*Interface, error) {
+	var ifaces []*Interface
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			it, ok := ts.Type.(*ast.InterfaceType)
+			if !ok {
+				continue
+			}
+			obj, ok := info.Defs[ts.Name].(*types.TypeName)
+			if !ok {
+				continue
+			}
+			doc := ts.Doc
+			if doc == nil && !gd.Lparen.IsValid() {
+				doc = gd.Doc
+			}
+			iface, err := parseInterface(obj, it, doc, fset, pkg)
+			if err != nil {
+				return nil, err
+			}
+			ifaces = append(ifaces, iface)
+		}
+	}
+	return ifaces, nil
+}
+
+func parseInterface(obj *types.TypeName, it *ast.InterfaceType, doc *ast.CommentGroup, fset *token.FileSet, pkg *types.Package) (*Interface, error) {
+	directives, err := structutil.ParseDirectives(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fset.Position(obj.Pos()), err)
+	}
+	iface := &Interface{
+		Name:       obj.Name(),
+		Doc:        doc.Text(),
+		Pos:        fset.Position(obj.Pos()),
+		Directives: directives,
+	}
+
+	docs := make(map[string]*ast.CommentGroup)
+	var order []string
+	for _, field := range it.Methods.List {
+		for _, name := range field.Names {
+			docs[name.Name] = field.Doc
+			order = append(order, name.Name)
+		}
+	}
+
+	t := obj.Type().Underlying().(*types.Interface)
+	methods := make(map[string]*types.Func, t.NumMethods())
+	for i := 0; i < t.NumMethods(); i++ {
+		m := t.Method(i)
+		methods[m.Name()] = m
+		if _, ok := docs[m.Name()]; !ok {
+			order = append(order, m.Name())
+		}
+	}
+
+	qualifier := structutil.Qualifier(pkg.Path())
+	for _, name := range order {
+		fn := methods[name]
+		directives, err := structutil.ParseDirectives(docs[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fset.Position(fn.Pos()), err)
+		}
+		sig := fn.Type().(*types.Signature)
+		m := &Method{
+			Name:       name,
+			Variadic:   sig.Variadic(),
+			Doc:        docs[name].Text(),
+			Pos:        fset.Position(fn.Pos()),
+			Directives: directives,
+		}
+		m.Params = params(sig.Params(), "p", qualifier)
+		m.Results = params(sig.Results(), "r", qualifier)
+		if m.HasContext() && (sig.Params().At(0).Name() == "" || sig.Params().At(0).Name() == "_") {
+			m.Params[0].Name = "ctx"
+		}
+		// Generated names must not shadow declared ones.
+		for _, r := range m.Results {
+			for isParam(m.Params, r.Name) {
+				r.Name += "_"
+			}
+		}
+		iface.Methods = append(iface.Methods, m)
+	}
+	return iface, nil
+}
+
+func params(tuple *types.Tuple, prefix string, qualifier types.Qualifier) []*Param {
+	list := make([]*Param, tuple.Len())
+	for i := range list {
+		v := tuple.At(i)
+		name := v.Name()
+		if name == "" || name == "_" {
+			name = fmt.Sprintf("%s%d", prefix, i)
+		}
+		list[i] = &Param{Name: name, Type: types.TypeString(v.Type(), qualifier), GoType: v.Type()}
+	}
+	return list
+}
+
+func isParam(params []*Param, name string) bool {
+	for _, p := range params {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isNamed(t types.Type, pkgPath, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkgPath && obj.Name() == name
+}
+
+func lookup(directives []*structutil.Directive, name string) *structutil.Directive {
+	for _, d := range directives {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package structutil
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// API diff modes, for Options.APIDiff.
+const (
+	// APIDiffWarn reports changes to the exported API of outputs as
+	// warnings.
+	APIDiffWarn = "warn"
+	// APIDiffFail also fails the run on incompatible changes.
+	APIDiffFail = "fail"
+)
+
+// apiSurface maps the exported declarations of generated files, such as
+// "method (*T).GetName", to their types as written.
+type apiSurface map[string]string
+
+// addAPI adds the exported declarations of src to api.
+func addAPI(api apiSurface, filename string, src []byte) error {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return err
+	}
+	node := func(n ast.Node) string {
+		var buf bytes.Buffer
+		printer.Fprint(&buf, fset, n)
+		return buf.String()
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			key := "func " + d.Name.Name
+			if d.Recv != nil {
+				if !receiverExported(d.Recv) {
+					continue
+				}
+				key = fmt.Sprintf("method (%s).%s", node(d.Recv.List[0].Type), d.Name.Name)
+			}
+			api[key] = node(unnamed(d.Type))
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if !s.Name.IsExported() {
+						continue
+					}
+					st, ok := s.Type.(*ast.StructType)
+					if !ok {
+						api["type "+s.Name.Name] = node(s.Type)
+						continue
+					}
+					// Adding fields to a struct is compatible.
+					api["type "+s.Name.Name] = "struct"
+					for _, field := range st.Fields.List {
+						for _, name := range field.Names {
+							if name.IsExported() {
+								api[fmt.Sprintf("field %s.%s", s.Name.Name, name.Name)] = node(field.Type)
+							}
+						}
+					}
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.IsExported() {
+							typ := ""
+							if s.Type != nil {
+								typ = node(s.Type)
+							}
+							api[strings.ToLower(d.Tok.String())+" "+name.Name] = typ
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// unnamed returns a copy of ftype without parameter and result names,
+// which do not affect compatibility.
+func unnamed(ftype *ast.FuncType) *ast.FuncType {
+	strip := func(fields *ast.FieldList) *ast.FieldList {
+		if fields == nil {
+			return nil
+		}
+		list := &ast.FieldList{}
+		for _, field := range fields.List {
+			n := len(field.Names)
+			if n == 0 {
+				n = 1
+			}
+			for i := 0; i < n; i++ {
+				list.List = append(list.List, &ast.Field{Type: field.Type})
+			}
+		}
+		return list
+	}
+	return &ast.FuncType{Params: strip(ftype.Params), Results: strip(ftype.Results)}
+}
+
+// diffAPI returns the incompatible changes from old to new, removed or
+// changed declarations, and the compatible ones, added declarations.
+func diffAPI(oldAPI, newAPI apiSurface) (incompatible, compatible []string) {
+	for key, typ := range oldAPI {
+		newTyp, ok := newAPI[key]
+		switch {
+		case !ok:
+			incompatible = append(incompatible, "removed "+key)
+		case newTyp != typ:
+			incompatible = append(incompatible, fmt.Sprintf("changed %s from %s to %s", key, typ, newTyp))
+		}
+	}
+	for key := range newAPI {
+		if _, ok := oldAPI[key]; !ok {
+			compatible = append(compatible, "added "+key)
+		}
+	}
+	sort.Strings(incompatible)
+	sort.Strings(compatible)
+	return incompatible, compatible
+}
+
+// checkAPI compares the exported API of the new files of the output
+// outputName with that of the generated files they replace and records
+// the changes as warnings, or fails the run on incompatible ones in
+// APIDiffFail mode.
+func (g *GenerateForFields) checkAPI(outputName string, files []outputFile) {
+	names, _ := filepath.Glob(strings.TrimSuffix(outputName, filepath.Ext(outputName)) + "_[0-9]*.go")
+	names = append(names, outputName)
+	oldAPI, newAPI := make(apiSurface), make(apiSurface)
+	var version string
+	found := false
+	for _, name := range names {
+		src, err := g.readFile(name)
+		if err != nil {
+			continue
+		}
+		h, ok := ParseHeader(src)
+		if !ok {
+			continue
+		}
+		if err := addAPI(oldAPI, name, src); err != nil {
+			g.fatalf("reading API of %s: %s", name, err)
+		}
+		found, version = true, h.Version
+	}
+	if !found {
+		return
+	}
+	for _, f := range files {
+		if err := addAPI(newAPI, f.name, f.src); err != nil {
+			g.fatalf("reading API of %s: %s", f.name, err)
+		}
+	}
+
+	incompatible, compatible := diffAPI(oldAPI, newAPI)
+	if version == "" {
+		version = "an unstamped version"
+	}
+	if len(incompatible) > 0 && g.opts.APIDiff == APIDiffFail {
+		g.fatalf("%s: incompatible API changes since %s:\n\t%s", outputName, version, strings.Join(incompatible, "\n\t"))
+	}
+	for _, change := range incompatible {
+		g.result.Warnings = append(g.result.Warnings, fmt.Sprintf("%s: incompatible API change since %s: %s", outputName, version, change))
+	}
+	for _, change := range compatible {
+		g.result.Warnings = append(g.result.Warnings, fmt.Sprintf("%s: compatible API change since %s: %s", outputName, version, change))
+	}
+}
@@ -0,0 +1,47 @@
+package structutil
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// Implements declares that the methods generated for the current type
+// satisfy the named interfaces. A compile-time assertion is emitted for each
+// of them, so interface drift turns into a build error.
+func (p *shadowPrinter) Implements(ifaces ...string) {
+	p.implement(p.structName, ifaces)
+}
+
+func (g *GenerateForFields) implement(structName string, ifaces []string) {
+	if g.implements[structName] == nil {
+		g.implements[structName] = make(map[string]bool)
+	}
+	for _, iface := range ifaces {
+		g.implements[structName][iface] = true
+	}
+}
+
+// assertions returns the interface assertions declared for structName.
+func (g *GenerateForFields) assertions(structName string) []byte {
+	ifaces := make([]string, 0, len(g.ifaces)+len(g.implements[structName]))
+	ifaces = append(ifaces, g.ifaces...)
+	for iface := range g.implements[structName] {
+		ifaces = append(ifaces, iface)
+	}
+	if len(ifaces) == 0 {
+		return nil
+	}
+	sort.Strings(ifaces)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "\nvar (\n")
+	for i, iface := range ifaces {
+		if i > 0 && ifaces[i-1] == iface {
+			continue
+		}
+		fmt.Fprintf(&buf, "\t_ %s = (*%s)(nil)\n", iface, structName)
+	}
+	fmt.Fprintf(&buf, ")\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,30 @@
+package structutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImplementsAssertions(t *testing.T) {
+	dir := writePackage(t, "package p\n\ntype A struct{ X int }\n")
+	g := NewForFieldsGenerator(&GenerateForFieldsConfig{
+		ToolName:   "go-gen-test",
+		FileSuffix: "test_out",
+		Implements: []string{"fmt.Stringer"},
+	}, func(info *StructInfo, p PrinterWriter) {
+		p.Printf("// Code generated by \"go-gen-test\"; DO NOT EDIT.\n\npackage p\n\nimport \"fmt\"\n")
+		p.Printf("\nfunc (a *A) String() string { return fmt.Sprint(a.X) }\n")
+		p.Printf("\nfunc (a *A) Error() string { return a.String() }\n")
+		p.Implements("error", "fmt.Stringer")
+		p.Implements("error")
+	})
+	res, err := g.Generate(Options{Dir: dir, Types: []string{"A"}, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(res.Files[0].Src)
+	want := "var (\n\t_ error = (*A)(nil)\n\t_ fmt.Stringer = (*A)(nil)\n)\n"
+	if !strings.HasSuffix(src, want) {
+		t.Errorf("output does not end with the assertions\n%s\ngot\n%s", want, src)
+	}
+}
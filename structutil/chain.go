@@ -0,0 +1,144 @@
+package structutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const goGenerate = "//go:generate "
+
+// GoGenerate appends a //go:generate directive running command to the
+// output of the current type, so that go generate runs the next generator
+// of a pipeline on the result, for example validators after builders.
+func (p *shadowPrinter) GoGenerate(command string) {
+	p.chain(p.structName, command)
+}
+
+func (g *GenerateForFields) chain(structName, command string) {
+	for _, c := range g.chains[structName] {
+		if c == command {
+			return
+		}
+	}
+	g.chains[structName] = append(g.chains[structName], command)
+}
+
+// directives returns the //go:generate lines chained to the output of key.
+// It exits if they would make go generate runs in dir cycle between
+// generators.
+func (g *GenerateForFields) directives(dir, key string) []byte {
+	commands := append(append([]string(nil), g.then...), g.chains[key]...)
+	if len(commands) == 0 {
+		return nil
+	}
+	graph, err := generateGraph(dir)
+	if err != nil {
+		g.fatalf("reading go:generate directives: %s", err)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("\n")
+	for _, command := range commands {
+		next := commandTool(command)
+		graph[g.toolName] = appendUnique(graph[g.toolName], next)
+		if cycle := findCycle(graph, g.toolName); cycle != nil {
+			g.fatalf("go:generate %q would run generators in a cycle: %s", command, strings.Join(cycle, " -> "))
+		}
+		fmt.Fprintf(&buf, "%s%s\n", goGenerate, command)
+	}
+	return buf.Bytes()
+}
+
+// generateGraph maps the tools that produced the toolkit-generated files in
+// dir to the tools their //go:generate directives run.
+func generateGraph(dir string) (map[string][]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	graph := make(map[string][]string)
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		h, ok := ParseHeader(src)
+		if !ok || h.Version == "" {
+			continue
+		}
+		s := bufio.NewScanner(bytes.NewReader(src))
+		for s.Scan() {
+			if line := s.Text(); strings.HasPrefix(line, goGenerate) {
+				graph[h.Tool] = appendUnique(graph[h.Tool], commandTool(strings.TrimPrefix(line, goGenerate)))
+			}
+		}
+	}
+	return graph, nil
+}
+
+// commandTool returns the name of the generator a go:generate command runs:
+// the base name of the program, or of the package for "go run".
+func commandTool(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	if fields[0] == "go" && len(fields) > 1 && fields[1] == "run" {
+		for _, f := range fields[2:] {
+			if !strings.HasPrefix(f, "-") {
+				return path.Base(strings.SplitN(f, "@", 2)[0])
+			}
+		}
+	}
+	return path.Base(filepath.ToSlash(fields[0]))
+}
+
+// findCycle returns a path from start back to itself in graph, or nil.
+func findCycle(graph map[string][]string, start string) []string {
+	visited := make(map[string]bool)
+	var walk func(node string, trail []string) []string
+	walk = func(node string, trail []string) []string {
+		next := append([]string(nil), graph[node]...)
+		sort.Strings(next)
+		for _, n := range next {
+			if n == start {
+				return append(trail, n)
+			}
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+			if cycle := walk(n, append(trail, n)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return walk(start, []string{start})
+}
+
+// stringList is a flag.Value collecting repeated string flags.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,127 @@
+package structutil
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/imports"
+)
+
+type outputFile struct {
+	name string
+	src  []byte
+}
+
+// splitOutput splits generated source into files holding at most
+// maxMethods function declarations each, named <output>_1.go, <output>_2.go
+// and so on. Other declarations go to the first file. Every file repeats the
+// header and package clause and keeps only the imports it uses.
+func splitOutput(outputName string, src []byte, maxMethods int) ([]outputFile, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, outputName, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		importDecls []byte
+		others      [][]byte
+		funcs       [][]byte
+	)
+	for _, decl := range file.Decls {
+		text := declSource(fset, src, decl)
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				importDecls = append(append(importDecls, text...), '\n')
+				continue
+			}
+			others = append(others, text)
+		case *ast.FuncDecl:
+			funcs = append(funcs, text)
+		}
+	}
+	if len(funcs) <= maxMethods {
+		return []outputFile{{name: outputName, src: src}}, nil
+	}
+
+	header := src[:fset.Position(file.Package).Offset]
+	base := strings.TrimSuffix(outputName, filepath.Ext(outputName))
+
+	var files []outputFile
+	for i := 0; len(funcs) > 0; i++ {
+		n := maxMethods
+		if n > len(funcs) {
+			n = len(funcs)
+		}
+		decls := funcs[:n]
+		funcs = funcs[n:]
+		if i == 0 {
+			decls = append(others, decls...)
+		}
+
+		var buf bytes.Buffer
+		buf.Write(header)
+		fmt.Fprintf(&buf, "package %s\n\n", file.Name.Name)
+		buf.Write(importDecls)
+		for _, decl := range decls {
+			buf.WriteString("\n")
+			buf.Write(decl)
+			buf.WriteString("\n")
+		}
+
+		name := fmt.Sprintf("%s_%d.go", base, i+1)
+		chunk, err := imports.Process(name, buf.Bytes(), nil)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, outputFile{name: name, src: chunk})
+	}
+	return files, nil
+}
+
+// declSource returns the source text of decl including its doc comment.
+func declSource(fset *token.FileSet, src []byte, decl ast.Decl) []byte {
+	start := decl.Pos()
+	switch d := decl.(type) {
+	case *ast.GenDecl:
+		if d.Doc != nil {
+			start = d.Doc.Pos()
+		}
+	case *ast.FuncDecl:
+		if d.Doc != nil {
+			start = d.Doc.Pos()
+		}
+	}
+	return src[fset.Position(start).Offset:fset.Position(decl.End()).Offset]
+}
+
+// staleChunks returns previously generated files of outputName, whole or
+// split, that are not part of files.
+func staleChunks(outputName string, files []outputFile) []string {
+	base := strings.TrimSuffix(outputName, filepath.Ext(outputName))
+	matches, err := filepath.Glob(base + "_[0-9]*.go")
+	if err != nil {
+		return nil
+	}
+	matches = append(matches, outputName)
+	current := make(map[string]bool, len(files))
+	for _, f := range files {
+		current[f.name] = true
+	}
+	var stale []string
+	for _, name := range matches {
+		if current[name] {
+			continue
+		}
+		if h, ok, err := ReadHeader(name); err == nil && ok && h.Version != "" {
+			stale = append(stale, name)
+		}
+	}
+	return stale
+}
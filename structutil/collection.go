@@ -0,0 +1,157 @@
+package structutil
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// ElementInfo describes the element type of a defined slice type, or the
+// key or value type of a defined map type.
+type ElementInfo struct {
+	Type   string     // As written in the declaration, for example *User.
+	GoType types.Type // Type-checked element type; nil if unavailable.
+}
+
+// IsPointer reports whether the element type is a pointer.
+func (e *ElementInfo) IsPointer() bool {
+	if e.GoType == nil {
+		return strings.HasPrefix(e.Type, "*")
+	}
+	_, ok := e.GoType.(*types.Pointer)
+	return ok
+}
+
+// Base returns the element type without its pointer, for example User for
+// []*User.
+func (e *ElementInfo) Base() string {
+	return strings.TrimPrefix(e.Type, "*")
+}
+
+// IsSlice reports whether the type is a defined slice type rather than a
+// struct. Its Elem is set and it has no Fields.
+func (s *StructInfo) IsSlice() bool {
+	return s.Elem != nil && s.Key == nil
+}
+
+// IsMap reports whether the type is a defined map type rather than a
+// struct. Its Key and Elem are set and it has no Fields.
+func (s *StructInfo) IsMap() bool {
+	return s.Key != nil
+}
+
+// collection holds the element types of a defined slice or map type.
+type collection struct {
+	key  *ElementInfo // nil for slices.
+	elem *ElementInfo
+}
+
+// parseCollections returns the defined slice and map types of file by
+// name.
+func parseCollections(file *ast.File, fileSet *token.FileSet, defs map[*ast.Ident]types.Object) map[string]collection {
+	colls := make(map[string]collection)
+	for _, ts := range collectionSpecs(file) {
+		var goType types.Type
+		if obj, ok := defs[ts.Name]; ok && obj != nil {
+			goType = obj.Type().Underlying()
+		}
+		var c collection
+		switch t := ts.Type.(type) {
+		case *ast.ArrayType:
+			c.elem = elementInfo(fileSet, t.Elt)
+			if s, ok := goType.(*types.Slice); ok {
+				c.elem.GoType = s.Elem()
+			}
+		case *ast.MapType:
+			c.key = elementInfo(fileSet, t.Key)
+			c.elem = elementInfo(fileSet, t.Value)
+			if m, ok := goType.(*types.Map); ok {
+				c.key.GoType = m.Key()
+				c.elem.GoType = m.Elem()
+			}
+		}
+		colls[ts.Name.Name] = c
+	}
+	return colls
+}
+
+func elementInfo(fileSet *token.FileSet, expr ast.Expr) *ElementInfo {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fileSet, expr)
+	return &ElementInfo{Type: buf.String()}
+}
+
+// fileCollectionNames returns the top-level defined slice and map types of
+// f in source order.
+func fileCollectionNames(f *ast.File) []string {
+	var names []string
+	for _, ts := range collectionSpecs(f) {
+		names = append(names, ts.Name.Name)
+	}
+	return names
+}
+
+func collectionSpecs(f *ast.File) []*ast.TypeSpec {
+	var specs []*ast.TypeSpec
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			// Aliases have no methods of their own.
+			if ts.Assign.IsValid() {
+				continue
+			}
+			switch t := ts.Type.(type) {
+			case *ast.ArrayType:
+				if t.Len == nil {
+					specs = append(specs, ts)
+				}
+			case *ast.MapType:
+				specs = append(specs, ts)
+			}
+		}
+	}
+	return specs
+}
+
+// declaresCollection reports whether the scanned files of the package
+// declare the named slice or map type.
+func (p *Package) declaresCollection(typeName string) bool {
+	if p.scanned != nil {
+		for _, sc := range p.scanned {
+			if sc.info.Name == typeName && (sc.info.IsSlice() || sc.info.IsMap()) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, file := range p.files {
+		for _, name := range fileCollectionNames(file.file) {
+			if name == typeName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// collectionInfo returns the named slice or map type and the name of the
+// file declaring it, or nil.
+func (g *GenerateForFields) collectionInfo(typeName string) (*StructInfo, string) {
+	for _, file := range g.pkg.files {
+		c, ok := parseCollections(file.file, file.fileSet, g.pkg.defs)[typeName]
+		if !ok {
+			continue
+		}
+		info := g.newStructInfo(file, file.file, typeName, nil)
+		info.Key, info.Elem = c.key, c.elem
+		return info, file.fileSet.File(file.file.Pos()).Name()
+	}
+	return nil, ""
+}
@@ -0,0 +1,153 @@
+package structutil
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+const directivePrefix = "//gentoolkit:"
+
+// Directive is a "//gentoolkit:<name> key=value ..." comment. Values
+// containing spaces are written as Go string literals: msg="not found".
+type Directive struct {
+	Name string
+	Args map[string]string
+	Keys []string // Argument keys in source order.
+}
+
+// Get returns the value of the key argument.
+func (d *Directive) Get(key string) string {
+	return d.Args[key]
+}
+
+// Has reports whether the directive has a key argument.
+func (d *Directive) Has(key string) bool {
+	_, ok := d.Args[key]
+	return ok
+}
+
+// String returns the directive as a comment line.
+func (d *Directive) String() string {
+	var b strings.Builder
+	b.WriteString(directivePrefix + d.Name)
+	for _, key := range d.Keys {
+		value := d.Args[key]
+		if strings.ContainsAny(value, " \t\"") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, value)
+	}
+	return b.String()
+}
+
+// ParseDirectives returns the toolkit directives of a comment group.
+func ParseDirectives(doc *ast.CommentGroup) ([]*Directive, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	var directives []*Directive
+	for _, c := range doc.List {
+		if !strings.HasPrefix(c.Text, directivePrefix) {
+			continue
+		}
+		d, err := ParseDirective(c.Text)
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, d)
+	}
+	return directives, nil
+}
+
+// LookupDirective returns the first directive of a comment group with the
+// given name, or nil.
+func LookupDirective(doc *ast.CommentGroup, name string) (*Directive, error) {
+	directives, err := ParseDirectives(doc)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range directives {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return nil, nil
+}
+
+// ParseDirective parses a single directive comment line.
+func ParseDirective(line string) (*Directive, error) {
+	rest := strings.TrimPrefix(line, directivePrefix)
+	if rest == line {
+		return nil, fmt.Errorf("%q is not a directive", line)
+	}
+	d := &Directive{Args: make(map[string]string)}
+
+	i := strings.IndexAny(rest, " \t")
+	if i < 0 {
+		d.Name = rest
+		return d, nil
+	}
+	d.Name, rest = rest[:i], rest[i:]
+
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			return d, nil
+		}
+		var key, value string
+		j := strings.IndexAny(rest, "= \t")
+		if j < 0 || rest[j] != '=' {
+			// A bare key is a boolean flag.
+			if j < 0 {
+				j = len(rest)
+			}
+			key, rest = rest[:j], rest[j:]
+			value = "true"
+		} else {
+			key, rest = rest[:j], rest[j+1:]
+			if strings.HasPrefix(rest, `"`) {
+				lit, err := strconv.QuotedPrefix(rest)
+				if err != nil {
+					return nil, fmt.Errorf("directive %s: argument %s: %w", d.Name, key, err)
+				}
+				value, _ = strconv.Unquote(lit)
+				rest = rest[len(lit):]
+			} else {
+				k := strings.IndexAny(rest, " \t")
+				if k < 0 {
+					k = len(rest)
+				}
+				value, rest = rest[:k], rest[k:]
+			}
+		}
+		if _, ok := d.Args[key]; !ok {
+			d.Keys = append(d.Keys, key)
+		}
+		d.Args[key] = value
+	}
+}
+
+// typeDoc returns the doc comment of the named type declared in file, or
+// nil.
+func typeDoc(file *ast.File, typeName string) *ast.CommentGroup {
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if ts.Name.Name != typeName {
+				continue
+			}
+			if ts.Doc == nil && !gd.Lparen.IsValid() {
+				return gd.Doc
+			}
+			return ts.Doc
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package structutil
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// EmbedTagKey is the struct tag controlling how generators configured with
+// SkipEmbedFS treat an embedded file system field: embed:"keep" passes the
+// field to the generator and embed:"skip" drops it without a warning.
+const EmbedTagKey = "embed"
+
+// IsEmbedFS reports whether the field holds files embedded at compile time:
+// its type is embed.FS or a pointer to it, or its doc carries a go:embed
+// directive. Such fields have nothing to serialize or copy field by field.
+func (f *StructFieldInfo) IsEmbedFS() bool {
+	if f.GoEmbed {
+		return true
+	}
+	if f.GoType == nil {
+		return strings.TrimPrefix(f.Type, "*") == "embed.FS"
+	}
+	t := f.GoType
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "embed" && named.Obj().Name() == "FS"
+}
+
+// hasGoEmbed reports whether the comment group carries a go:embed
+// directive.
+func hasGoEmbed(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.HasPrefix(c.Text, "//go:embed ") {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutEmbedFS returns info without the fields IsEmbedFS reports, unless
+// they are tagged embed:"keep", and a warning for each field dropped
+// without an embed:"skip" tag.
+func withoutEmbedFS(info *StructInfo) (*StructInfo, []string) {
+	var fields []StructFieldInfo
+	var warnings []string
+	for _, f := range info.Fields {
+		if !f.IsEmbedFS() {
+			fields = append(fields, f)
+			continue
+		}
+		switch f.Tag(EmbedTagKey) {
+		case "keep":
+			fields = append(fields, f)
+		case "skip":
+		default:
+			warnings = append(warnings, fmt.Sprintf("%s: skipping embedded files field %s.%s; tag it embed:\"skip\" to silence this or embed:\"keep\" to generate code for it", f.Pos, info.Name, f.Name))
+		}
+	}
+	if len(fields) == len(info.Fields) {
+		return info, warnings
+	}
+	filtered := *info
+	filtered.Fields = fields
+	return &filtered, warnings
+}
@@ -0,0 +1,199 @@
+package structutil
+
+import (
+	"fmt"
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Options configure a single run of Generate. They mirror the flags
+// registered by Init.
+type Options struct {
+	// Dir is the directory patterns and relative paths are resolved in;
+	// default the working directory.
+	Dir string
+	// Patterns select the package, as a directory, a list of files or
+	// package patterns such as ./...; default ".".
+	Patterns []string
+	// Types are the names of the structs to generate for. Generators for
+	// whole packages use all structs if it is empty.
+	Types []string
+
+	Output        string
+	OutputDir     string
+	VerifyVersion bool
+	SourceMap     bool
+	MaxMethods    int
+	ScanGenerated bool
+	Exclude       []string
+	Then          []string
+	// Stream stages each output in a temporary file as soon as it is
+	// generated. GenerateForFieldsConfig.StreamOutput only sets the default
+	// of the -stream flag.
+	Stream bool
+	// Naming is the registered naming strategy; default DefaultNaming.
+	Naming string
+	// Context makes generated methods that may block take a
+	// context.Context as their first parameter; see StructInfo.ContextFirst.
+	Context bool
+	// Wrap is the function generated code wraps errors with, such as
+	// github.com/pkg/errors.Wrapf; fmt.Errorf if empty. See
+	// StructInfo.WrapError.
+	Wrap string
+	// LintCompat rewrites the outputs to pass linters run on generated
+	// code: no named results, exhaustive literals of the package's structs
+	// and doc comments on exported declarations.
+	LintCompat bool
+	// APIDiff, if set to APIDiffWarn or APIDiffFail, compares the exported
+	// API of each output with the generated file it replaces.
+	APIDiff string
+	// Summary precedes the package clause of every output with a doc
+	// comment listing what it declares and the directives of its structs.
+	Summary bool
+	// Args are the command-line arguments hashed into the output stamps.
+	Args []string
+	// DryRun returns the outputs without writing them.
+	DryRun bool
+	// Overlay holds the contents of files to use instead of those on
+	// disk, such as unsaved editor buffers, by path. Relative paths are
+	// resolved against Dir.
+	Overlay map[string][]byte
+	// PreFilter, if set, reports whether a parsed source file is scanned
+	// for types; the types of the files it rejects cannot be generated for.
+	PreFilter func(file *ast.File) bool
+}
+
+// Result is the outcome of a run of Generate.
+type Result struct {
+	Files []GeneratedFile
+	// Outdated lists the output files found by VerifyVersion that were not
+	// stamped by the current toolkit version, and Warnings explains why.
+	// Nothing is written if there are any.
+	Outdated []string
+	Warnings []string
+}
+
+// GeneratedFile is an output file of a run, including source maps. Src is
+// only set for dry runs; other runs write it and keep just the name.
+type GeneratedFile struct {
+	Name string
+	Src  []byte
+}
+
+// runError carries the failure of a run from fatalf to Generate.
+type runError struct {
+	err error
+}
+
+// Generate runs the generator with opts and returns the files it produced.
+// Unlike Run it reads no flags, returns failures instead of exiting and
+// keeps the state of the run to itself, so a service can call it from
+// several goroutines at once. The Progress of the generator must then be
+// safe for concurrent use.
+func (g *GenerateForFields) Generate(opts Options) (res *Result, err error) {
+	r, err := g.newRun(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			failure, ok := e.(runError)
+			if !ok {
+				panic(e)
+			}
+			res, err = nil, failure.err
+		}
+	}()
+	r.run()
+	return r.result, nil
+}
+
+// newRun returns a copy of g set up for a run with opts.
+func (g *GenerateForFields) newRun(opts Options) (*GenerateForFields, error) {
+	if len(opts.Patterns) == 0 {
+		// Default: process whole package in the directory.
+		opts.Patterns = []string{"."}
+	}
+	if opts.Naming == "" {
+		opts.Naming = DefaultNaming
+	}
+	strategy, err := namingStrategy(opts.Naming)
+	if err != nil {
+		return nil, err
+	}
+	switch opts.APIDiff {
+	case "", APIDiffWarn, APIDiffFail:
+	default:
+		return nil, fmt.Errorf("unknown API diff mode %q; want %s or %s", opts.APIDiff, APIDiffWarn, APIDiffFail)
+	}
+	if opts.Wrap != "" {
+		if _, _, err := splitWrap(opts.Wrap); err != nil {
+			return nil, err
+		}
+	}
+	for _, pattern := range opts.Exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("exclude %q: %w", pattern, err)
+		}
+	}
+
+	if opts.Overlay != nil {
+		overlay := make(map[string][]byte, len(opts.Overlay))
+		for name, src := range opts.Overlay {
+			if opts.Dir != "" && !filepath.IsAbs(name) {
+				name = filepath.Join(opts.Dir, name)
+			}
+			abs, err := filepath.Abs(name)
+			if err != nil {
+				return nil, err
+			}
+			overlay[abs] = src
+		}
+		opts.Overlay = overlay
+	}
+
+	r := *g
+	r.opts = opts
+	r.strategy = strategy
+	r.exclude = globList(opts.Exclude)
+	r.then = stringList(opts.Then)
+	r.result = &Result{}
+	r.txn = &transaction{stream: opts.Stream}
+	r.written = nil
+	r.reset(nil)
+	return &r, nil
+}
+
+// readFile returns the contents of the named file, from the overlay if it
+// has them.
+func (g *GenerateForFields) readFile(name string) ([]byte, error) {
+	if g.opts.Overlay != nil {
+		if abs, err := filepath.Abs(name); err == nil {
+			if src, ok := g.opts.Overlay[abs]; ok {
+				return src, nil
+			}
+		}
+	}
+	return ioutil.ReadFile(name)
+}
+
+// resolve returns name relative to the directory of the run.
+func (g *GenerateForFields) resolve(name string) string {
+	if g.opts.Dir == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(g.opts.Dir, name)
+}
+
+// workDir returns the absolute directory of the run, or "" if it cannot be
+// determined.
+func (g *GenerateForFields) workDir() string {
+	if g.opts.Dir != "" {
+		dir, _ := filepath.Abs(g.opts.Dir)
+		return dir
+	}
+	wd, _ := os.Getwd()
+	return wd
+}
@@ -1,14 +1,14 @@
 package structutil
 
 import (
+	"bufio"
 	"bytes"
 	"flag"
 	"fmt"
 	"go/ast"
-	"go/format"
+	"go/parser"
 	"go/printer"
 	"io"
-	"io/ioutil"
 	"regexp"
 
 	"go/token"
@@ -16,11 +16,13 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"text/template"
 
 	"github.com/fatih/structtag"
+	"golang.org/x/mod/semver"
 	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
 )
 
 // Heavily influenced by https://gitee.com/dwdcth/accessor
@@ -28,13 +30,23 @@ import (
 type PrinterWriter interface {
 	io.Writer
 	Printf(format string, args ...interface{})
+	Annotate(field StructFieldInfo)
+	Implements(ifaces ...string)
+	GoGenerate(command string)
+	Helper(name, body string)
+	Fatalf(format string, args ...interface{})
 }
 
 type shadowPrinter struct {
 	io.Writer
 
 	structName string
+	sourceMap  bool
 	printf     func(structName string, format string, args ...interface{})
+	implement  func(structName string, ifaces []string)
+	chain      func(structName, command string)
+	helper     func(structName, name, body string)
+	fatalf     func(format string, args ...interface{})
 }
 
 func (p *shadowPrinter) Printf(format string, args ...interface{}) {
@@ -46,39 +58,175 @@ type StructInfo struct {
 	File    *File
 	Name    string
 	Fields  []StructFieldInfo
+	Doc     string
+
+	// Elem is the element type of a defined slice type or the value type
+	// of a defined map type, whose key type is Key, for generators
+	// configured with Collections; both are nil for structs.
+	Elem *ElementInfo
+	Key  *ElementInfo
+
+	Directives []*Directive
+
+	gen *GenerateForFields
+}
+
+// ContextFirst reports whether the run asks for methods that may block to
+// take ctx context.Context as their first parameter, passing it on to
+// whatever they call that accepts one.
+func (s *StructInfo) ContextFirst() bool {
+	return s.gen != nil && s.gen.opts.Context
+}
+
+// Siblings returns the other structs of the package in source order, so a
+// generator for one type can look up the types it refers to. Files produced
+// by a generator are skipped.
+func (s *StructInfo) Siblings() []*StructInfo {
+	if s.gen == nil {
+		return nil
+	}
+	var siblings []*StructInfo
+	for _, info := range s.gen.allStructs() {
+		if info.Name != s.Name {
+			siblings = append(siblings, info)
+		}
+	}
+	return siblings
+}
+
+// Sibling returns the named struct of the package, or nil.
+func (s *StructInfo) Sibling(name string) *StructInfo {
+	for _, info := range s.Siblings() {
+		if info.Name == name {
+			return info
+		}
+	}
+	return nil
+}
+
+// Directive returns the struct's first directive with the given name, or
+// nil.
+func (s *StructInfo) Directive(name string) *Directive {
+	for _, d := range s.Directives {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
 }
 
 type GenerateForFields struct {
 	toolName    string
 	fileSuffix  string
 	gofmtOutput bool
+	stream      bool
+	syntaxOnly  bool
+	collections bool
+	skipEmbed   bool
+	strictTypes bool
+	postProcess func(filename string, src []byte) ([]byte, error)
+	progress    Progress
+	ifaces      []string
 
 	genFunc func(info *StructInfo, p PrinterWriter)
+	pkgFunc PackageGenFunc
 
-	typeNames *string
-	output    *string
+	analyzer TwoPhaseGenerator
+	plans    map[string]Plan
 
-	buf      map[string]*bytes.Buffer // Accumulated output.
-	pkg      *Package                 // Package we are scanning.
-	walkMark map[string]bool
+	flags         *flag.FlagSet
+	typeNames     *string
+	output        *string
+	verifyVersion *bool
+	sourceMap     *bool
+	maxMethods    *int
+	streamOutput  *bool
+	outputDir     *string
+	scanGenerated *bool
+	naming        *string
+	context       *bool
+	wrap          *string
+	lintCompat    *bool
+	apiDiff       *string
+	then          stringList
+	profile       *string
+	exclude       globList
+	serveMode     *bool
+	summary       *bool
+
+	// The fields below hold the state of a run; Generate works on a copy
+	// of the generator.
+	opts       Options
+	strategy   NamingStrategy
+	result     *Result
+	buf        map[string]*bytes.Buffer // Accumulated output.
+	implements map[string]map[string]bool
+	chains     map[string][]string      // go:generate commands per output.
+	helpers    map[string][]helper      // Shared helpers per output.
+	summaries  map[string][]*StructInfo // Structs of each output, for Summary.
+	pkg        *Package                 // Package we are scanning.
+	structs    []*StructInfo            // All structs of pkg; built on first use.
+	multi      bool                     // Whether the patterns may match several packages.
+	txn        *transaction             // Output staged until every type succeeded.
+	written    []string                 // Output files staged in txn.
 }
 
 type GenerateForFieldsConfig struct {
 	ToolName    string
 	FileSuffix  string
 	GoFmtOutput bool
+	// StreamOutput writes each type's output to its file as soon as it is
+	// generated and releases its buffer, bounding memory on large packages.
+	StreamOutput bool
+	// Progress is notified as packages are loaded, types are generated and
+	// files are written. It may be nil.
+	Progress Progress
+	// Implements lists interfaces the generated methods of every type
+	// satisfy; a compile-time assertion is emitted for each.
+	Implements []string
+	// SyntaxOnly skips type checking and parses the files of the package
+	// one at a time, keeping only the extracted structs, to bound memory on
+	// very large packages. Field GoTypes are nil.
+	SyntaxOnly bool
+	// Collections accepts defined slice and map types such as `type
+	// UserList []User` or `type Index map[string]User` for -type, with
+	// their element types in StructInfo.Key and Elem.
+	Collections bool
+	// PostProcess, if set, rewrites each output after it is formatted and
+	// before it is split or written, for example to add a license header
+	// or run another formatter.
+	PostProcess func(filename string, src []byte) ([]byte, error)
+	// SkipEmbedFS drops fields holding embedded files, such as embed.FS,
+	// from the StructInfo passed to the generator with a warning, for
+	// serializers and cloners that would generate nonsense for them. See
+	// EmbedTagKey for the per-field override.
+	SkipEmbedFS bool
+	// StrictTypes fails the run if the package does not load or type-check
+	// cleanly, for generators whose output is silently wrong when a field
+	// type cannot be resolved. Errors in stale outputs of the generator
+	// itself are ignored so that they can be regenerated.
+	StrictTypes bool
 }
 
 func NewForFieldsGenerator(c *GenerateForFieldsConfig, generator func(info *StructInfo, p PrinterWriter)) *GenerateForFields {
+	progress := c.Progress
+	if progress == nil {
+		progress = NopProgress{}
+	}
 	return &GenerateForFields{
 		toolName:    c.ToolName,
 		fileSuffix:  c.FileSuffix,
 		gofmtOutput: c.GoFmtOutput,
+		stream:      c.StreamOutput,
+		syntaxOnly:  c.SyntaxOnly,
+		collections: c.Collections,
+		skipEmbed:   c.SkipEmbedFS,
+		strictTypes: c.StrictTypes,
+		postProcess: c.PostProcess,
+		progress:    progress,
+		ifaces:      c.Implements,
 
 		genFunc: generator,
-
-		buf:      make(map[string]*bytes.Buffer),
-		walkMark: make(map[string]bool),
 	}
 }
 
@@ -90,69 +238,410 @@ func (g *GenerateForFields) OpinionatedPreRun() {
 }
 
 func (g *GenerateForFields) Usage(w io.Writer) {
+	typeFlag := "-type T"
+	if g.pkgFunc != nil {
+		typeFlag = "[-type T]"
+	}
 	fmt.Fprintf(w, "Usage of %s:\n", g.toolName)
-	fmt.Fprintf(w, "\t%s [flags] -type T [directory]\n", g.toolName)
-	fmt.Fprintf(w, "\t%s [flags] -type T files... # Must be a single package\n", g.toolName)
+	fmt.Fprintf(w, "\t%s [flags] %s [directory]\n", g.toolName, typeFlag)
+	fmt.Fprintf(w, "\t%s [flags] %s files... # Must be a single package\n", g.toolName, typeFlag)
+	fmt.Fprintf(w, "\t%s [flags] %s packages... # For example ./...\n", g.toolName, typeFlag)
 	fmt.Fprintf(w, "Flags:\n")
-	flag.PrintDefaults()
+	if g.flags == nil {
+		return
+	}
+	g.flags.PrintDefaults()
 }
 
 func (g *GenerateForFields) Init() {
-	g.typeNames = flag.String("type", "", "comma-separated list of type names; must be set")
-	g.output = flag.String("output", "", fmt.Sprintf("output file name; default srcdir/<type>_%s.go", g.fileSuffix))
+	g.InitFlags(flag.CommandLine)
+}
+
+// InitFlags registers the flags of the generator on fs instead of the
+// command line, so that several generators can share a binary. Run reads
+// the arguments left after fs is parsed.
+func (g *GenerateForFields) InitFlags(fs *flag.FlagSet) {
+	g.flags = fs
+	if g.pkgFunc != nil {
+		g.typeNames = fs.String("type", "", "comma-separated list of type names; default all structs of the package")
+		g.output = fs.String("output", "", fmt.Sprintf("output file name; default srcdir/<package>_%s.go", g.fileSuffix))
+	} else {
+		g.typeNames = fs.String("type", "", "comma-separated list of type names; must be set")
+		g.output = fs.String("output", "", fmt.Sprintf("output file name; default srcdir/<type>_%s.go", g.fileSuffix))
+	}
+	g.verifyVersion = fs.Bool("verify-version", false, "warn about output files stamped by an older toolkit version instead of generating")
+	g.sourceMap = fs.Bool("sourcemap", false, "annotate generated declarations with their source fields and write a <output>.map.json sidecar")
+	g.maxMethods = fs.Int("max-methods", 0, "split output into <type>_<suffix>_N.go files of at most this many methods each; 0 means no limit")
+	g.outputDir = fs.String("output-dir", "", "write outputs to a tree under this directory mirroring the source tree instead of alongside the sources")
+	g.scanGenerated = fs.Bool("scan-generated", false, "also look for types in files with a generated-code header")
+	fs.Var(&g.exclude, "exclude", "skip packages and files whose path or base name matches this glob; may be repeated")
+	fs.Var(&g.then, "then", "append a //go:generate directive running this command to every output; may be repeated")
+	g.profile = fs.String("profile", "", "write CPU and heap profiles of the run to <prefix>.cpu.pprof and <prefix>.mem.pprof")
+	g.naming = fs.String("naming", DefaultNaming, "naming strategy for generated methods and files; see RegisterNamingStrategy")
+	g.context = fs.Bool("ctx", false, "make generated methods that may block take ctx context.Context as their first parameter")
+	g.lintCompat = fs.Bool("lint-compat", false, "rewrite outputs for linters run on generated code: no named results, exhaustive struct literals and comments on exported declarations")
+	g.apiDiff = fs.String("apidiff", "", "compare the exported API of each output with the file it replaces: warn lists the changes, fail also fails on incompatible ones")
+	g.wrap = fs.String("wrap", "", "function wrapping errors returned by generated code, such as github.com/pkg/errors.Wrapf, called with the error, a format and its arguments; default fmt.Errorf with %w")
+	g.streamOutput = fs.Bool("stream", g.stream, "stage each type's output in a temporary file as soon as it is generated and release its buffer")
+	g.summary = fs.Bool("summary", false, "precede the package clause of every output with a doc comment listing its declarations and the directives they were generated from")
+	g.serveMode = fs.Bool("serve", false, "answer generation requests read as JSON lines from stdin instead of generating once; used by gentoolkit serve")
 }
 
 func (g *GenerateForFields) Run() {
-	if len(*g.typeNames) == 0 {
-		flag.Usage()
+	if len(*g.typeNames) == 0 && g.pkgFunc == nil && !*g.serveMode {
+		g.flags.Usage()
 		os.Exit(2)
 	}
 
-	types := strings.Split(*g.typeNames, ",")
+	opts := Options{
+		// We accept either one directory, a list of files or package
+		// patterns such as ./...
+		Patterns:      g.flags.Args(),
+		Output:        *g.output,
+		OutputDir:     *g.outputDir,
+		VerifyVersion: *g.verifyVersion,
+		SourceMap:     *g.sourceMap,
+		MaxMethods:    *g.maxMethods,
+		ScanGenerated: *g.scanGenerated,
+		Stream:        *g.streamOutput,
+		Naming:        *g.naming,
+		Context:       *g.context,
+		Wrap:          *g.wrap,
+		LintCompat:    *g.lintCompat,
+		APIDiff:       *g.apiDiff,
+		Summary:       *g.summary,
+		Exclude:       g.exclude,
+		Then:          g.then,
+		Args:          os.Args[1:],
+	}
+	if *g.typeNames != "" {
+		opts.Types = strings.Split(*g.typeNames, ",")
+	}
 
-	// We accept either one directory or a list of files. Which do we have?
-	args := flag.Args()
-	if len(args) == 0 {
-		// Default: process whole package in current directory.
-		args = []string{"."}
+	if *g.profile != "" {
+		stop, err := startProfile(*g.profile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() {
+			if err := stop(); err != nil {
+				log.Fatal(err)
+			}
+		}()
 	}
 
-	// Parse the package once.
-	var dir string
-	if len(args) == 1 && isDirectory(args[0]) {
-		dir = args[0]
-	} else {
-		dir = filepath.Dir(args[0])
+	if *g.serveMode {
+		opts.Args = withoutServe(opts.Args)
+		if err := g.serve(opts, os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	res, err := g.Generate(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, warning := range res.Warnings {
+		log.Printf("warning: %s", warning)
+	}
+	if len(res.Outdated) > 0 {
+		os.Exit(1)
+	}
+}
+
+// run generates the outputs for the options of g.
+func (g *GenerateForFields) run() {
+	mode := packages.LoadSyntax
+	if g.syntaxOnly {
+		mode = packages.NeedName | packages.NeedFiles
+	}
+	pkgs, err := loadPackages(g.opts.Dir, g.opts.Patterns, mode, g.opts.Overlay)
+	if err != nil {
+		g.fatalf("%s", err)
+	}
+	g.multi = len(pkgs) > 1
+	for _, pattern := range g.opts.Patterns {
+		if strings.Contains(pattern, "...") {
+			g.multi = true
+		}
+	}
+	if len(pkgs) != 1 && !g.multi {
+		g.fatalf("error: %d packages found", len(pkgs))
+	}
+
+	found := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) == 0 {
+			continue
+		}
+		if g.multi && g.skipPackage(filepath.Dir(pkg.GoFiles[0])) {
+			continue
+		}
+		if g.syntaxOnly {
+			g.scanPackage(pkg)
+		} else {
+			g.addPackage(pkg)
+		}
+		g.progress.PackageLoaded(g.pkg)
+
+		var dir string
+		pkgTypes := g.opts.Types
+		if !g.multi {
+			patterns := make([]string, len(g.opts.Patterns))
+			for i, pattern := range g.opts.Patterns {
+				patterns[i] = g.resolve(pattern)
+			}
+			dir, err = SourceDir(patterns)
+			if err != nil {
+				g.fatalf("%s", err)
+			}
+		} else {
+			dir = filepath.Dir(pkg.GoFiles[0])
+			pkgTypes = nil
+			for _, typeName := range g.opts.Types {
+				if g.pkg.declares(typeName) || g.collections && g.pkg.declaresCollection(typeName) {
+					pkgTypes = append(pkgTypes, typeName)
+				}
+			}
+			if len(pkgTypes) == 0 && (len(g.opts.Types) > 0 || g.pkgFunc == nil) {
+				continue
+			}
+		}
+		for _, typeName := range pkgTypes {
+			found[typeName] = true
+		}
+
+		if g.opts.VerifyVersion {
+			g.verifyPackage(dir, pkgTypes)
+			continue
+		}
+		g.generatePackage(dir, pkgTypes)
+	}
+
+	for _, typeName := range g.opts.Types {
+		if !found[typeName] {
+			g.fatalf("type %s not found", typeName)
+		}
+	}
+	if len(g.result.Outdated) == 0 {
+		g.commit()
+	}
+}
+
+// verifyPackage records the outputs for the current package that are not
+// stamped by the current toolkit version.
+func (g *GenerateForFields) verifyPackage(dir string, types []string) {
+	outputNames := []string{g.packageOutputName(dir)}
+	if g.pkgFunc == nil {
+		outputNames = outputNames[:0]
+		for _, typeName := range types {
+			outputNames = append(outputNames, g.outputName(dir, typeName))
+		}
+	}
+	for _, outputName := range outputNames {
+		g.verify(outputName)
+	}
+}
+
+// generatePackage generates and stages the outputs for the named types of
+// the current package, whose sources are in dir.
+func (g *GenerateForFields) generatePackage(dir string, types []string) {
+	if g.pkgFunc != nil {
+		g.runPackage(dir, types)
+		return
+	}
+
+	if g.analyzer != nil {
+		g.analyze(types)
 	}
-	g.parsePackage(args)
 
 	// Print the header and package clause.
 	// Run generate for each type.
-	for i, typeName := range types {
-		g.generate(typeName)
-		// AccessWrite to file.
-		outputName := *g.output
-		if outputName == "" {
-			baseName := fmt.Sprintf("%s_%s.go", toSnakeCase(types[i]), g.fileSuffix)
-			outputName = filepath.Join(dir, strings.ToLower(baseName))
-		}
-
-		var (
-			src = g.buf[typeName].Bytes()
-			err error
-		)
-		if g.gofmtOutput {
-			src, err = format.Source(src)
+	for _, typeName := range types {
+		g.progress.TypeStarted(typeName)
+		srcFile := g.generate(typeName)
+		g.write(typeName, g.outputName(dir, typeName), g.assertions(typeName), srcFile)
+		g.progress.TypeFinished(typeName)
+	}
+}
+
+// write stamps and formats the output accumulated under key, followed by
+// trailer, and stages it for outputName and its chunks. inputs are the
+// source files the output was generated from.
+func (g *GenerateForFields) write(key, outputName string, trailer []byte, inputs ...string) {
+	hash, err := inputHash(g.opts.Args, g.readFile, inputs...)
+	if err != nil {
+		g.fatalf("hashing input: %s", err)
+	}
+	src := append(rewriteHeader(g.buf[key].Bytes(), g.opts.Args), g.helperDecls(key)...)
+	if g.opts.Wrap != "" && g.gofmtOutput {
+		src = importWrap(src, g.opts.Wrap)
+	}
+	var typeID string
+	if g.pkgFunc == nil {
+		typeID = g.pkg.path + "." + key
+	}
+	src = stamp(append(src, trailer...), hash, typeID)
+	if g.gofmtOutput {
+		src, err = imports.Process(outputName, src, nil)
+		if err != nil {
+			g.fatalf("formatting output: %s", err)
+		}
+	}
+	if g.opts.LintCompat {
+		src, err = lintCompat(outputName, src, g.pkg.types, g.toolName)
+		if err != nil {
+			g.fatalf("rewriting output for linters: %s", err)
+		}
+	}
+	if g.opts.Summary {
+		src, err = summarize(g.toolName, src, g.summaries[key])
+		if err != nil {
+			g.fatalf("summarizing output: %s", err)
+		}
+	}
+	if g.postProcess != nil {
+		src, err = g.postProcess(outputName, src)
+		if err != nil {
+			g.fatalf("post-processing output: %s", err)
+		}
+	}
+
+	files := []outputFile{{name: outputName, src: src}}
+	if g.opts.MaxMethods > 0 {
+		files, err = splitOutput(outputName, src, g.opts.MaxMethods)
+		if err != nil {
+			g.fatalf("splitting output: %s", err)
+		}
+	}
+	if chained := g.directives(filepath.Dir(outputName), key); chained != nil {
+		files[0].src = append(files[0].src, chained...)
+	}
+	if g.opts.APIDiff != "" {
+		g.checkAPI(outputName, files)
+	}
+	if !g.opts.DryRun {
+		for _, name := range staleChunks(outputName, files) {
+			g.txn.remove(name)
+		}
+	}
+
+	if g.opts.Stream {
+		delete(g.buf, key)
+	}
+
+	for _, f := range files {
+		g.stage(f.name, f.src, "writing output")
+		if g.opts.SourceMap {
+			data, err := encodeSourceMap(f.name, f.src)
 			if err != nil {
-				log.Fatalf("formatting output: %s", err)
+				g.fatalf("writing source map: %s", err)
 			}
+			g.stage(f.name+".map.json", data, "writing source map")
 		}
+		g.written = append(g.written, f.name)
+	}
+}
 
-		err = ioutil.WriteFile(outputName, src, 0644)
-		if err != nil {
-			log.Fatalf("writing output: %s", err)
+// stage adds src, to be written to name, to the result of the run and,
+// unless it is a dry run, to its transaction. Only dry runs keep src in the
+// result, so that streamed runs do not hold every output until they end.
+// what describes the file in errors.
+func (g *GenerateForFields) stage(name string, src []byte, what string) {
+	if g.opts.DryRun {
+		g.result.Files = append(g.result.Files, GeneratedFile{Name: name, Src: src})
+		return
+	}
+	g.result.Files = append(g.result.Files, GeneratedFile{Name: name})
+	if err := g.txn.write(name, src); err != nil {
+		g.fatalf("%s: %s", what, err)
+	}
+}
+
+// commit writes the staged output of the run, or restores the previous
+// files and fails if that fails.
+func (g *GenerateForFields) commit() {
+	if err := g.txn.commit(); err != nil {
+		g.fatalf("writing output: %s", err)
+	}
+	for _, name := range g.written {
+		g.progress.FileWritten(name)
+	}
+	g.written = nil
+}
+
+// streamFile writes src to the named file through a buffered writer.
+func streamFile(name string, src []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if _, err := w.Write(src); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (g *GenerateForFields) outputName(dir, typeName string) string {
+	return g.resolveOutput(dir, g.strategy.FileName(typeName, g.fileSuffix))
+}
+
+// resolveOutput returns the output file for the package in dir, baseName
+// unless -output is set. In multi-package runs a relative -output is taken
+// relative to each package directory. With -output-dir the file is placed
+// in a tree under it that mirrors the source tree.
+func (g *GenerateForFields) resolveOutput(dir, baseName string) string {
+	name := filepath.Join(dir, baseName)
+	if g.opts.Output != "" {
+		name = g.opts.Output
+		if g.multi && !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		} else {
+			name = g.resolve(name)
+		}
+	}
+	if g.opts.OutputDir == "" {
+		return name
+	}
+	if abs, err := filepath.Abs(name); err == nil {
+		if rel, err := filepath.Rel(g.workDir(), abs); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
 		}
 	}
+	return filepath.Join(g.resolve(g.opts.OutputDir), name)
+}
+
+// verify records the named output file as outdated, with a warning, if it
+// was not stamped by the current toolkit version.
+func (g *GenerateForFields) verify(outputName string) {
+	h, ok, err := ReadHeader(outputName)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		g.fatalf("reading output: %s", err)
+	}
+	if !ok {
+		return
+	}
+	var warning string
+	switch {
+	case h.Version == "":
+		warning = fmt.Sprintf("%s has no toolkit version stamp; regenerate with %s", outputName, Version)
+	case semver.Compare(h.Version, Version) < 0:
+		warning = fmt.Sprintf("%s was generated by toolkit %s; current version is %s", outputName, h.Version, Version)
+	default:
+		return
+	}
+	g.result.Outdated = append(g.result.Outdated, outputName)
+	g.result.Warnings = append(g.result.Warnings, warning)
 }
 
 func (g *GenerateForFields) printf(structName, format string, args ...interface{}) {
@@ -183,12 +672,12 @@ func toSnakeCase(str string) string {
 }
 
 // isDirectory reports whether the named file is a directory.
-func isDirectory(name string) bool {
+func isDirectory(name string) (bool, error) {
 	info, err := os.Stat(name)
 	if err != nil {
-		log.Fatal(err)
+		return false, err
 	}
-	return info.IsDir()
+	return info.IsDir(), nil
 }
 
 // File holds a single parsed file and associated data.
@@ -203,158 +692,430 @@ type File struct {
 
 type Package struct {
 	name  string
+	path  string
+	types *types.Package // Nil for syntax-only runs.
 	defs  map[*ast.Ident]types.Object
 	files []*File
+
+	// scanned holds the structs of syntax-only runs, whose files are not
+	// retained.
+	scanned []scannedStruct
+}
+
+type scannedStruct struct {
+	info    *StructInfo
+	srcFile string
+}
+
+// declares reports whether the scanned files of the package declare the
+// named struct type.
+func (p *Package) declares(typeName string) bool {
+	for _, name := range p.structNames() {
+		if name == typeName {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *Package) GetName() string {
 	return p.name
 }
 
-// parsePackage analyzes the single package constructed from the patterns and tags.
-// parsePackage exits if there is an error.
-func (g *GenerateForFields) parsePackage(patterns []string) {
-	cfg := &packages.Config{
-		Mode:  packages.LoadSyntax,
-		Tests: false,
-	}
-	pkgs, err := packages.Load(cfg, patterns...)
-	if err != nil {
-		log.Fatal(err)
-	}
-	if len(pkgs) != 1 {
-		log.Fatalf("error: %d packages found", len(pkgs))
-	}
-	g.addPackage(pkgs[0])
+func (p *Package) GetPath() string {
+	return p.path
+}
+
+// GetTypes returns the type-checked package, or nil for syntax-only runs.
+func (p *Package) GetTypes() *types.Package {
+	return p.types
 }
 
 // addPackage adds a type checked Package and its syntax files to the generator.
 func (g *GenerateForFields) addPackage(pkg *packages.Package) {
+	if g.strictTypes {
+		g.checkTypes(pkg)
+	}
 	g.pkg = &Package{
 		name:  pkg.Name,
+		path:  pkg.PkgPath,
+		types: pkg.Types,
 		defs:  pkg.TypesInfo.Defs,
-		files: make([]*File, len(pkg.Syntax)),
+	}
+	// Type names are only unique within a package.
+	g.structs = nil
+	g.buf = make(map[string]*bytes.Buffer)
+	g.implements = make(map[string]map[string]bool)
+	g.chains = make(map[string][]string)
+	g.helpers = make(map[string][]helper)
+	g.summaries = make(map[string][]*StructInfo)
+	if g.plans != nil {
+		g.plans = make(map[string]Plan)
 	}
 
-	for i, file := range pkg.Syntax {
-		g.pkg.files[i] = &File{
+	for _, file := range pkg.Syntax {
+		if g.skipFile(pkg.Fset.File(file.Pos()).Name()) {
+			continue
+		}
+		if g.opts.PreFilter != nil && !g.opts.PreFilter(file) {
+			continue
+		}
+		g.pkg.files = append(g.pkg.files, &File{
 			file:    file,
 			pkg:     g.pkg,
 			fileSet: pkg.Fset,
+		})
+	}
+}
+
+// checkTypes fails the run with the first error of pkg, including type
+// errors, that is not located in an output of the generator.
+func (g *GenerateForFields) checkTypes(pkg *packages.Package) {
+	for _, e := range pkg.Errors {
+		if name := errorFile(e.Pos); name != "" {
+			if h, ok, err := ReadHeader(name); err == nil && ok && h.Tool == g.toolName {
+				continue
+			}
+		}
+		g.fatalf("package %s does not type-check: %s", pkg.PkgPath, e)
+	}
+}
+
+// errorFile returns the file name of a packages.Error position of the form
+// file:line:col, or "" if it has none.
+func errorFile(pos string) string {
+	for i := 0; i < 2; i++ {
+		j := strings.LastIndex(pos, ":")
+		if j < 0 {
+			break
 		}
+		if _, err := strconv.Atoi(pos[j+1:]); err != nil {
+			break
+		}
+		pos = pos[:j]
+	}
+	if pos == "-" {
+		return ""
+	}
+	return pos
+}
+
+// reset makes pkg the current package and drops the per-package state.
+func (g *GenerateForFields) reset(pkg *Package) {
+	g.pkg = pkg
+	// Type names are only unique within a package.
+	g.structs = nil
+	g.buf = make(map[string]*bytes.Buffer)
+	g.implements = make(map[string]map[string]bool)
+	g.chains = make(map[string][]string)
+	g.helpers = make(map[string][]helper)
+	g.summaries = make(map[string][]*StructInfo)
+	if g.plans != nil {
+		g.plans = make(map[string]Plan)
+	}
+}
+
+// scanPackage parses the files of pkg one at a time without type checking
+// and keeps only their structs.
+func (g *GenerateForFields) scanPackage(pkg *packages.Package) {
+	g.reset(&Package{name: pkg.Name, path: pkg.PkgPath, scanned: []scannedStruct{}})
+	fset := token.NewFileSet()
+	for _, name := range pkg.GoFiles {
+		if g.skipFile(name) {
+			continue
+		}
+		src, err := g.readFile(name)
+		if err != nil {
+			g.fatalf("reading %s: %s", name, err)
+		}
+		f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+		if err != nil {
+			g.fatalf("parsing %s: %s", name, err)
+		}
+		if g.opts.PreFilter != nil && !g.opts.PreFilter(f) {
+			continue
+		}
+		structs, err := parseStruct(f, fset, nil)
+		if err != nil {
+			g.fatalf("failed to parse struct: %s", err)
+		}
+		file := &File{pkg: g.pkg, fileSet: fset}
+		for _, s := range structs {
+			g.pkg.scanned = append(g.pkg.scanned, scannedStruct{
+				info:    g.newStructInfo(file, f, s.name, s.fields),
+				srcFile: name,
+			})
+		}
+		if !g.collections {
+			continue
+		}
+		colls := parseCollections(f, fset, nil)
+		for _, typeName := range fileCollectionNames(f) {
+			info := g.newStructInfo(file, f, typeName, nil)
+			info.Key, info.Elem = colls[typeName].key, colls[typeName].elem
+			g.pkg.scanned = append(g.pkg.scanned, scannedStruct{info: info, srcFile: name})
+		}
+	}
+}
+
+// skipPackage reports whether the package in dir is excluded from a
+// multi-package run.
+func (g *GenerateForFields) skipPackage(dir string) bool {
+	rel := dir
+	if r, err := filepath.Rel(g.workDir(), dir); err == nil {
+		rel = r
+	}
+	return ignoredPath(rel) || g.exclude.match(g.workDir(), dir)
+}
+
+// skipFile reports whether the named source file is excluded from scanning:
+// files matching -exclude and, unless -scan-generated is set, files with a
+// generated-code header.
+func (g *GenerateForFields) skipFile(name string) bool {
+	if g.exclude.match(g.workDir(), name) {
+		return true
+	}
+	if g.opts.ScanGenerated {
+		return false
+	}
+	src, err := g.readFile(name)
+	if err != nil {
+		return false
+	}
+	_, generated := ParseHeader(src)
+	return generated
+}
+
+// generate produces the output for the named type and returns the name of
+// the file declaring it.
+func (g *GenerateForFields) generate(typeName string) string {
+	info, srcFile := g.structInfo(typeName)
+	if g.skipEmbed {
+		var warnings []string
+		info, warnings = withoutEmbedFS(info)
+		g.result.Warnings = append(g.result.Warnings, warnings...)
 	}
+	g.summaries[typeName] = []*StructInfo{info}
+	p := &shadowPrinter{
+		Writer:     g.writer(typeName),
+		structName: typeName,
+		sourceMap:  g.opts.SourceMap,
+		printf:     g.printf,
+		implement:  g.implement,
+		chain:      g.chain,
+		helper:     g.helper,
+		fatalf:     g.fatalf,
+	}
+	if g.analyzer != nil {
+		// The plans belong to the run, not to the generator it copies.
+		g.analyzer.Emit(g.plans[typeName], p)
+	} else {
+		g.genFunc(info, p)
+	}
+	return srcFile
 }
 
-// generate produces the String method for the named type.
-func (g *GenerateForFields) generate(typeName string) {
+// structInfo returns the named struct and the name of the file declaring
+// it. It exits if the type is not found.
+func (g *GenerateForFields) structInfo(typeName string) (*StructInfo, string) {
+	if g.pkg.scanned != nil {
+		for _, sc := range g.pkg.scanned {
+			if sc.info.Name == typeName {
+				return sc.info, sc.srcFile
+			}
+		}
+		g.fatalf("type %s not found", typeName)
+	}
 	for _, file := range g.pkg.files { //按包来的，读取包下的所有文件
 		// Set the state for this run of the walker.
 		file.typeName = typeName
 		if file.file != nil {
 
-			structInfo, err := parseStruct(file.file, file.fileSet)
+			structs, err := parseStruct(file.file, file.fileSet, g.pkg.defs)
 			if err != nil {
-				fmt.Println("failed to parse struct:" + err.Error())
-				return
+				g.fatalf("failed to parse struct: %s", err)
 			}
 
-			for stName, info := range structInfo {
-				g.genFunc(&StructInfo{
-					Fields:  info,
-					File:    file,
-					Name:    stName,
-					Package: g.pkg,
-				}, &shadowPrinter{
-					Writer:     g.writer(stName),
-					structName: stName,
-					printf:     g.printf,
-				})
+			for _, s := range structs {
+				if s.name == typeName {
+					return g.newStructInfo(file, file.file, typeName, s.fields), file.fileSet.File(file.file.Pos()).Name()
+				}
 			}
+		}
+	}
+	if g.collections {
+		if info, srcFile := g.collectionInfo(typeName); info != nil {
+			return info, srcFile
+		}
+	}
+	g.fatalf("type %s not found", typeName)
+	return nil, ""
+}
 
+// newStructInfo returns the StructInfo of the named struct declared in the
+// syntax tree f of file.
+func (g *GenerateForFields) newStructInfo(file *File, f *ast.File, typeName string, fields []StructFieldInfo) *StructInfo {
+	doc := typeDoc(f, typeName)
+	directives, err := ParseDirectives(doc)
+	if err != nil {
+		g.fatalf("type %s: %s", typeName, err)
+	}
+	for _, d := range directives {
+		if d.Name != "tags" {
+			continue
 		}
+		if err := validateTagsDirective(d); err != nil {
+			g.fatalf("type %s: %s", typeName, err)
+		}
+	}
+	return &StructInfo{
+		Fields:     fields,
+		File:       file,
+		Name:       typeName,
+		Package:    g.pkg,
+		Doc:        doc.Text(),
+		Directives: directives,
+		gen:        g,
 	}
 }
 
 type StructFieldInfo struct {
-	Name string
-	Type string
-	Tags *structtag.Tags
+	Name   string
+	Type   string
+	Tags   *structtag.Tags
+	Pos    token.Position
+	GoType types.Type // Type-checked field type; nil if unavailable.
+	// Embedded reports whether the field is embedded; Name is then the
+	// name of its type.
+	Embedded bool
+	// GoEmbed reports whether the field's doc carries a go:embed directive.
+	GoEmbed bool
+	// Doc is the doc comment of the field, or its line comment if it has
+	// none, without directives.
+	Doc string
 }
-type StructFieldInfoArr = []StructFieldInfo
-
-func parseStruct(file *ast.File, fileSet *token.FileSet) (structMap map[string]StructFieldInfoArr, err error) {
-	structMap = make(map[string]StructFieldInfoArr)
 
-	collectStructs := func(x ast.Node) bool {
-		ts, ok := x.(*ast.TypeSpec)
-		if !ok || ts.Type == nil {
-			return true
+// embeddedName returns the identifier naming an embedded field of type
+// expr, such as T of *pkg.T.
+func embeddedName(expr ast.Expr) *ast.Ident {
+	for {
+		switch x := expr.(type) {
+		case *ast.StarExpr:
+			expr = x.X
+		case *ast.SelectorExpr:
+			return x.Sel
+		case *ast.Ident:
+			return x
+		default:
+			return ast.NewIdent(types.ExprString(expr))
 		}
+	}
+}
+
+// IsSlice reports whether the field's underlying type is a slice.
+func (f *StructFieldInfo) IsSlice() bool {
+	if f.GoType == nil {
+		return strings.HasPrefix(f.Type, "[]")
+	}
+	_, ok := f.GoType.Underlying().(*types.Slice)
+	return ok
+}
 
-		structName := ts.Name.Name
+// IsMap reports whether the field's underlying type is a map.
+func (f *StructFieldInfo) IsMap() bool {
+	if f.GoType == nil {
+		return strings.HasPrefix(f.Type, "map[")
+	}
+	_, ok := f.GoType.Underlying().(*types.Map)
+	return ok
+}
 
-		s, ok := ts.Type.(*ast.StructType)
-		if !ok {
-			return true
+// Tag returns the value of the key tag, or "" if the field has none.
+func (f *StructFieldInfo) Tag(key string) string {
+	if f.Tags == nil {
+		return ""
+	}
+	tag, err := f.Tags.Get(key)
+	if err != nil {
+		return ""
+	}
+	return tag.Value()
+}
+
+type StructFieldInfoArr = []StructFieldInfo
+
+// parsedStruct is a struct declaration of a file.
+type parsedStruct struct {
+	name   string
+	fields []StructFieldInfo
+}
+
+// parseStruct returns the top-level struct declarations of file in source
+// order, as fileStructNames lists them. Structs declared in functions are
+// left out, as generated code cannot refer to them.
+func parseStruct(file *ast.File, fileSet *token.FileSet, defs map[*ast.Ident]types.Object) ([]parsedStruct, error) {
+	var structs []parsedStruct
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
 		}
-		fileInfos := make([]StructFieldInfo, 0)
-		for _, field := range s.Fields.List {
-			name := field.Names[0].Name
-			info := StructFieldInfo{Name: name}
-			var typeNameBuf bytes.Buffer
-			err := printer.Fprint(&typeNameBuf, fileSet, field.Type)
-			if err != nil {
-				fmt.Println("error:", err)
-				return true
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			s, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
 			}
-			info.Type = typeNameBuf.String()
-			if field.Tag != nil { // 有tag
-				tag := field.Tag.Value
-				tag = strings.Trim(tag, "`")
-				tags, err := structtag.Parse(tag)
-				if err == nil {
-					info.Tags = tags
-				}
+			fields, err := parseFields(s, fileSet, defs)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", ts.Name.Name, err)
 			}
-			fileInfos = append(fileInfos, info)
+			structs = append(structs, parsedStruct{name: ts.Name.Name, fields: fields})
 		}
-		structMap[structName] = fileInfos
-		return false
 	}
+	return structs, nil
+}
 
-	ast.Inspect(file, collectStructs)
-
-	return structMap, nil
-}
-
-func genSetter(structName, fieldName, typeName string) string {
-	tpl := `func ({{.Receiver}} *{{.Struct}}) Set{{.Field}}(param {{.Type}}) {
-	{{.Receiver}}.{{.Field}} = param
-}`
-	t := template.New("setter")
-	t = template.Must(t.Parse(tpl))
-	res := bytes.NewBufferString("")
-	t.Execute(res, map[string]string{
-		"Receiver": strings.ToLower(structName[0:1]),
-		"Struct":   structName,
-		"Field":    fieldName,
-		"Type":     typeName,
-	})
-	return res.String()
-}
-
-func genGetter(structName, fieldName, typeName string) string {
-	tpl := `func ({{.Receiver}} *{{.Struct}}) Get{{.Field}}() {{.Type}} {
-	return {{.Receiver}}.{{.Field}}
-}`
-	t := template.New("getter")
-	t = template.Must(t.Parse(tpl))
-	res := bytes.NewBufferString("")
-	t.Execute(res, map[string]string{
-		"Receiver": strings.ToLower(structName[0:1]),
-		"Struct":   structName,
-		"Field":    fieldName,
-		"Type":     typeName,
-	})
-	return res.String()
+// parseFields returns the fields of s, one per name.
+func parseFields(s *ast.StructType, fileSet *token.FileSet, defs map[*ast.Ident]types.Object) ([]StructFieldInfo, error) {
+	fields := make([]StructFieldInfo, 0)
+	for _, field := range s.Fields.List {
+		var typeNameBuf bytes.Buffer
+		if err := printer.Fprint(&typeNameBuf, fileSet, field.Type); err != nil {
+			return nil, err
+		}
+		var tags *structtag.Tags
+		if field.Tag != nil { // 有tag
+			tag := field.Tag.Value
+			tag = strings.Trim(tag, "`")
+			if t, err := structtag.Parse(tag); err == nil {
+				tags = t
+			}
+		}
+		doc := field.Doc.Text()
+		if doc == "" {
+			doc = field.Comment.Text()
+		}
+		names := field.Names
+		embedded := len(names) == 0
+		if embedded {
+			names = []*ast.Ident{embeddedName(field.Type)}
+		}
+		for _, ident := range names {
+			info := StructFieldInfo{
+				Name:     ident.Name,
+				Type:     typeNameBuf.String(),
+				Tags:     tags,
+				Pos:      fileSet.Position(field.Pos()),
+				Embedded: embedded,
+				GoEmbed:  hasGoEmbed(field.Doc),
+				Doc:      doc,
+			}
+			if obj, ok := defs[ident]; ok && obj != nil {
+				info.GoType = obj.Type()
+			}
+			fields = append(fields, info)
+		}
+	}
+	return fields, nil
 }
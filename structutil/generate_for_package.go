@@ -0,0 +1,110 @@
+package structutil
+
+import (
+	"go/ast"
+)
+
+// PackageGenFunc generates code for a whole package at once, for
+// registries, unions and conversions between sibling types. infos holds the
+// selected structs in source order.
+type PackageGenFunc func(pkg *Package, infos []*StructInfo, p PrinterWriter)
+
+// NewForPackageGenerator returns a generator calling generator once with
+// the structs named by -type, or all structs of the package if it is not
+// set, and writing a single srcdir/<package>_<suffix>.go file. Assertions
+// requested through Implements are not emitted, as the output has no
+// single receiver type.
+func NewForPackageGenerator(c *GenerateForFieldsConfig, generator PackageGenFunc) *GenerateForFields {
+	g := NewForFieldsGenerator(c, nil)
+	g.pkgFunc = generator
+	return g
+}
+
+// runPackage generates the package output for the named types, or all
+// structs if types is empty.
+func (g *GenerateForFields) runPackage(dir string, types []string) {
+	if len(types) == 0 {
+		types = g.pkg.structNames()
+	}
+
+	var (
+		infos  []*StructInfo
+		inputs []string
+		seen   = make(map[string]bool)
+	)
+	for _, typeName := range types {
+		info, srcFile := g.structInfo(typeName)
+		infos = append(infos, info)
+		if !seen[srcFile] {
+			seen[srcFile] = true
+			inputs = append(inputs, srcFile)
+		}
+	}
+
+	key := g.pkg.name
+	g.summaries[key] = infos
+	g.pkgFunc(g.pkg, infos, &shadowPrinter{
+		Writer:     g.writer(key),
+		structName: key,
+		sourceMap:  g.opts.SourceMap,
+		printf:     g.printf,
+		implement:  func(string, []string) {},
+		chain:      g.chain,
+		helper:     g.helper,
+		fatalf:     g.fatalf,
+	})
+	g.write(key, g.packageOutputName(dir), nil, inputs...)
+}
+
+func (g *GenerateForFields) packageOutputName(dir string) string {
+	return g.resolveOutput(dir, g.strategy.FileName(g.pkg.name, g.fileSuffix))
+}
+
+// allStructs returns the structs of the scanned files of the package.
+func (g *GenerateForFields) allStructs() []*StructInfo {
+	if g.structs == nil {
+		for _, typeName := range g.pkg.structNames() {
+			info, _ := g.structInfo(typeName)
+			g.structs = append(g.structs, info)
+		}
+	}
+	return g.structs
+}
+
+// structNames returns the top-level struct types of the scanned files of the
+// package in source order.
+func (p *Package) structNames() []string {
+	if p.scanned != nil {
+		var names []string
+		for _, sc := range p.scanned {
+			if !sc.info.IsSlice() && !sc.info.IsMap() {
+				names = append(names, sc.info.Name)
+			}
+		}
+		return names
+	}
+	var names []string
+	for _, file := range p.files {
+		names = append(names, fileStructNames(file.file)...)
+	}
+	return names
+}
+
+// fileStructNames returns the top-level struct types of f in source order.
+func fileStructNames(f *ast.File) []string {
+	var names []string
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok {
+				if _, ok := ts.Type.(*ast.StructType); ok {
+					names = append(names, ts.Name.Name)
+				}
+			}
+		}
+	}
+	return names
+}
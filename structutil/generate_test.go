@@ -0,0 +1,65 @@
+package structutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateRollsBackOnFailure(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/p\n\ngo 1.17\n")
+		writeFile(t, filepath.Join(dir, "p.go"), "package p\n\ntype A struct{ X int }\n\ntype B struct{ Y int }\n")
+		writeFile(t, filepath.Join(dir, "b_test_out.go"), "package p\n")
+
+		g := NewForFieldsGenerator(&GenerateForFieldsConfig{
+			ToolName:   "go-gen-test",
+			FileSuffix: "test_out",
+		}, func(info *StructInfo, p PrinterWriter) {
+			p.Printf("// Code generated by \"go-gen-test\"; DO NOT EDIT.\n\npackage p\n")
+		})
+		_, err := g.Generate(Options{Dir: dir, Types: []string{"A", "B", "Missing"}, Stream: stream})
+		if err == nil || !strings.Contains(err.Error(), "Missing") {
+			t.Fatalf("stream=%v: Generate error = %v, want type Missing not found", stream, err)
+		}
+
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		if got, want := strings.Join(names, " "), "b_test_out.go go.mod p.go"; got != want {
+			t.Errorf("stream=%v: files after failed run = %s, want %s", stream, got, want)
+		}
+		if got := readFile(t, filepath.Join(dir, "b_test_out.go")); got != "package p\n" {
+			t.Errorf("stream=%v: existing output changed to %q", stream, got)
+		}
+	}
+}
+
+func TestGenerateReturnsGeneratorFailure(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/p\n\ngo 1.17\n")
+	writeFile(t, filepath.Join(dir, "p.go"), "package p\n\ntype A struct{ X int }\n")
+
+	g := NewForFieldsGenerator(&GenerateForFieldsConfig{
+		ToolName:   "go-gen-test",
+		FileSuffix: "test_out",
+	}, func(info *StructInfo, p PrinterWriter) {
+		p.Printf("// Code generated by \"go-gen-test\"; DO NOT EDIT.\n\npackage p\n")
+		p.Fatalf("%s: unsupported field %s", info.Fields[0].Pos, info.Fields[0].Name)
+	})
+	_, err := g.Generate(Options{Dir: dir, Types: []string{"A"}})
+	if err == nil || !strings.Contains(err.Error(), "unsupported field X") {
+		t.Fatalf("Generate error = %v, want unsupported field X", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a_test_out.go")); !os.IsNotExist(err) {
+		t.Errorf("output written despite the failure")
+	}
+}
@@ -0,0 +1,245 @@
+package structutil
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/imports"
+)
+
+// Version is the toolkit version recorded in the stamp of every generated file.
+const Version = "v0.1.0"
+
+const stampKeyword = "gentoolkit:stamp "
+
+// generatedHeader matches the generated-code header of Go sources and of
+// the SQL, Markdown, Mermaid and Graphviz files some generators write, in
+// the comment syntax of each.
+var generatedHeader = regexp.MustCompile(`^(//|--|%%|<!--) Code generated by "(.*)"; DO NOT EDIT\.(?: -->)?$`)
+
+// StampedExtensions lists the extensions of the files toolkit generators
+// write with a generated-code header and stamp.
+var StampedExtensions = []string{".go", ".sql", ".md", ".mmd", ".dot"}
+
+// matchHeader returns the comment marker and the recorded invocation of a
+// generated-code header line.
+func matchHeader(line string) (marker, invocation string, ok bool) {
+	m := generatedHeader.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// stampLine returns the toolkit stamp with the given fields as a comment
+// with marker.
+func stampLine(marker, fields string) string {
+	if marker == "<!--" {
+		return marker + " " + stampKeyword + fields + " -->"
+	}
+	return marker + " " + stampKeyword + fields
+}
+
+// stampFields returns the fields of a toolkit stamp line in any of the
+// comment syntaxes of generatedHeader.
+func stampFields(line string) (string, bool) {
+	for _, marker := range []string{"//", "--", "%%", "<!--"} {
+		prefix := marker + " " + stampKeyword
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSuffix(strings.TrimPrefix(line, prefix), " -->"), true
+		}
+	}
+	return "", false
+}
+
+// JoinArgs joins args into a command line, quoting the arguments that
+// SplitArgs would not return intact, such as those containing spaces.
+func JoinArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if arg == "" || strings.IndexFunc(arg, func(r rune) bool { return r == ' ' || r == '"' || !unicode.IsPrint(r) }) >= 0 {
+			quoted[i] = strconv.Quote(arg)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// SplitArgs splits a command line written by JoinArgs into its arguments.
+// Arguments starting with a double quote are unquoted as Go strings.
+func SplitArgs(s string) ([]string, error) {
+	var args []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return args, nil
+		}
+		if s[0] != '"' {
+			i := strings.IndexAny(s, " \t")
+			if i < 0 {
+				i = len(s)
+			}
+			args = append(args, s[:i])
+			s = s[i:]
+			continue
+		}
+		end := 1
+		for end < len(s) && s[end] != '"' {
+			if s[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(s) {
+			return nil, fmt.Errorf("unterminated quoted argument %s", s)
+		}
+		arg, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %w", s[:end+1], err)
+		}
+		args = append(args, arg)
+		s = s[end+1:]
+	}
+}
+
+// splitInvocation splits the invocation of a header into its words. Headers
+// written before arguments were quoted are split at spaces.
+func splitInvocation(invocation string) []string {
+	words, err := SplitArgs(invocation)
+	if err != nil {
+		return strings.Fields(invocation)
+	}
+	return words
+}
+
+// Header describes the generated-code header and toolkit stamp of a file.
+type Header struct {
+	Tool      string
+	Args      []string
+	Version   string // Empty if the file carries no stamp.
+	InputHash string
+	// Type identifies the type the file was generated for as
+	// importpath.Name, so that renamed types can be detected. It is empty
+	// for outputs covering a whole package.
+	Type string
+}
+
+// ParseHeader extracts the generated-code header from src. It reports false
+// if src was not produced by a toolkit generator.
+func ParseHeader(src []byte) (*Header, bool) {
+	var h *Header
+	s := bufio.NewScanner(bytes.NewReader(src))
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		if _, invocation, ok := matchHeader(line); ok && h == nil {
+			words := splitInvocation(invocation)
+			if len(words) == 0 {
+				continue
+			}
+			h = &Header{Tool: words[0], Args: words[1:]}
+			continue
+		}
+		if h == nil {
+			continue
+		}
+		if fields, ok := stampFields(line); ok {
+			for _, kv := range strings.Fields(fields) {
+				i := strings.Index(kv, "=")
+				if i < 0 {
+					continue
+				}
+				switch kv[:i] {
+				case "version":
+					h.Version = kv[i+1:]
+				case "input":
+					h.InputHash = kv[i+1:]
+				case "type":
+					h.Type = kv[i+1:]
+				}
+			}
+		}
+	}
+	return h, h != nil
+}
+
+// ReadHeader reads the generated-code header of the named file.
+func ReadHeader(filename string) (*Header, bool, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, false, err
+	}
+	h, ok := ParseHeader(src)
+	return h, ok, nil
+}
+
+// inputHash hashes the invocation and the contents of the given source
+// files, as returned by read.
+func inputHash(args []string, read func(string) ([]byte, error), filenames ...string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintln(h, strings.Join(args, " "))
+	for _, name := range filenames {
+		src, err := read(name)
+		if err != nil {
+			return "", err
+		}
+		h.Write(src)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// stamp inserts the toolkit stamp directly after the generated-code header
+// line, in its comment syntax, or at the top of src if there is none.
+// typeID is recorded unless it is empty.
+func stamp(src []byte, hash, typeID string) []byte {
+	fields := fmt.Sprintf("version=%s input=%s", Version, hash)
+	if typeID != "" {
+		fields += " type=" + typeID
+	}
+
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	for i, l := range lines {
+		if marker, _, ok := matchHeader(string(bytes.TrimRight(l, "\r\n"))); ok {
+			line := stampLine(marker, fields) + "\n"
+			out := make([]byte, 0, len(src)+len(line))
+			for _, l := range lines[:i+1] {
+				out = append(out, l...)
+			}
+			out = append(out, line...)
+			for _, l := range lines[i+1:] {
+				out = append(out, l...)
+			}
+			return out
+		}
+	}
+	return append([]byte(stampLine("//", fields)+"\n"), src...)
+}
+
+// FormatGenerated stamps src with the toolkit version and a hash of the
+// invocation and the given input files, and formats Go sources with
+// goimports. It is used by generators that do not run through
+// GenerateForFields; outputs other than Go sources must start with a
+// generated-code header for the stamp to follow it.
+func FormatGenerated(outputName string, src []byte, inputs ...string) ([]byte, error) {
+	hash, err := inputHash(os.Args[1:], ioutil.ReadFile, inputs...)
+	if err != nil {
+		return nil, err
+	}
+	src = stamp(src, hash, "")
+	if filepath.Ext(outputName) != ".go" {
+		return src, nil
+	}
+	return imports.Process(outputName, src, nil)
+}
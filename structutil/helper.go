@@ -0,0 +1,47 @@
+package structutil
+
+import (
+	"bytes"
+	"strings"
+)
+
+// helper is a shared function emitted at most once per output file.
+type helper struct {
+	name string
+	body string
+}
+
+// Helper requests the shared helper declaration body, such as a
+// copyStringSlice function, under name. It is emitted once at the end of
+// the output file however many fields request it. Requesting the same name
+// with a different body is an error. As helpers are declared per file,
+// names should include the type name when several types of a package
+// request the same helper.
+func (p *shadowPrinter) Helper(name, body string) {
+	p.helper(p.structName, name, body)
+}
+
+func (g *GenerateForFields) helper(structName, name, body string) {
+	body = strings.TrimSpace(body)
+	for _, h := range g.helpers[structName] {
+		if h.name != name {
+			continue
+		}
+		if h.body != body {
+			g.fatalf("type %s: helper %s requested with different bodies", structName, name)
+		}
+		return
+	}
+	g.helpers[structName] = append(g.helpers[structName], helper{name: name, body: body})
+}
+
+// helperDecls returns the helpers requested for the output of key.
+func (g *GenerateForFields) helperDecls(key string) []byte {
+	var buf bytes.Buffer
+	for _, h := range g.helpers[key] {
+		buf.WriteString("\n")
+		buf.WriteString(h.body)
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,49 @@
+package structutil
+
+import (
+	"strings"
+	"testing"
+)
+
+const copyIntsHelper = `func copyInts(s []int) []int { return append([]int(nil), s...) }`
+
+func TestHelperEmittedOnce(t *testing.T) {
+	dir := writePackage(t, "package p\n\ntype A struct {\n\tX []int\n\tY []int\n}\n")
+	g := NewForFieldsGenerator(&GenerateForFieldsConfig{
+		ToolName:   "go-gen-test",
+		FileSuffix: "test_out",
+	}, func(info *StructInfo, p PrinterWriter) {
+		p.Printf("// Code generated by \"go-gen-test\"; DO NOT EDIT.\n\npackage p\n")
+		for _, field := range info.Fields {
+			p.Printf("\nfunc (a *A) Copy%s() []int { return copyInts(a.%s) }\n", field.Name, field.Name)
+			p.Helper("copyInts", copyIntsHelper)
+		}
+	})
+	res, err := g.Generate(Options{Dir: dir, Types: []string{"A"}, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(res.Files[0].Src)
+	if n := strings.Count(src, "func copyInts("); n != 1 {
+		t.Errorf("helper declared %d times in\n%s", n, src)
+	}
+	if strings.Index(src, "func copyInts(") < strings.Index(src, "func (a *A) CopyY()") {
+		t.Errorf("helper not declared after the methods in\n%s", src)
+	}
+}
+
+func TestHelperConflictingBodies(t *testing.T) {
+	dir := writePackage(t, "package p\n\ntype A struct{ X []int }\n")
+	g := NewForFieldsGenerator(&GenerateForFieldsConfig{
+		ToolName:   "go-gen-test",
+		FileSuffix: "test_out",
+	}, func(info *StructInfo, p PrinterWriter) {
+		p.Printf("// Code generated by \"go-gen-test\"; DO NOT EDIT.\n\npackage p\n")
+		p.Helper("copyInts", copyIntsHelper)
+		p.Helper("copyInts", `func copyInts(s []int) []int { return s }`)
+	})
+	_, err := g.Generate(Options{Dir: dir, Types: []string{"A"}, DryRun: true})
+	if err == nil || !strings.Contains(err.Error(), "helper copyInts requested with different bodies") {
+		t.Fatalf("Generate error = %v, want conflicting helper bodies", err)
+	}
+}
@@ -0,0 +1,57 @@
+package structutil
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// IgnoredDir reports whether a directory with the given base name is
+// skipped when scanning for sources: vendor, testdata and names starting
+// with "." or "_", as the go command does.
+func IgnoredDir(name string) bool {
+	return name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// ignoredPath reports whether any directory of path is ignored.
+func ignoredPath(path string) bool {
+	for _, elem := range strings.Split(filepath.ToSlash(filepath.Clean(path)), "/") {
+		if elem != "." && elem != ".." && IgnoredDir(elem) {
+			return true
+		}
+	}
+	return false
+}
+
+// globList is a flag.Value collecting repeated glob patterns.
+type globList []string
+
+func (l *globList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *globList) Set(s string) error {
+	if _, err := filepath.Match(s, ""); err != nil {
+		return err
+	}
+	*l = append(*l, s)
+	return nil
+}
+
+// match reports whether path, or its base name, matches one of the
+// patterns. Absolute paths are matched relative to wd.
+func (l globList) match(wd, path string) bool {
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(wd, path); err == nil {
+			path = rel
+		}
+	}
+	for _, pattern := range l {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package structutil
+
+import (
+	"fmt"
+	"go/types"
+	"strconv"
+
+	"github.com/jakoblorz/go-gentoolkit/typemap"
+)
+
+// KnownType describes how generated code converts a type from outside the
+// generated package, such as a decimal, to and from text. Generators use it
+// instead of the type's structure, which would treat a decimal as the
+// integers it is made of.
+type KnownType struct {
+	// Literal is a format for a Go expression of the type from the quoted
+	// string %s, such as decimal.RequireFromString(%s).
+	Literal string
+	// Parse is a format for a Go expression returning the type and an
+	// error from the string expression %s, such as decimal.NewFromString(%s).
+	Parse string
+	// Format is a format for a Go string expression of the value %s, such
+	// as %s.String().
+	Format string
+	// Valid, if set, checks a value written in a struct tag.
+	Valid func(value string) error
+}
+
+// knownTypes maps type names, as returned by typemap.Name, to known types.
+var knownTypes = map[string]KnownType{
+	"github.com/shopspring/decimal.Decimal": {
+		Literal: "decimal.RequireFromString(%s)",
+		Parse:   "decimal.NewFromString(%s)",
+		Format:  "%s.String()",
+		Valid: func(value string) error {
+			_, err := strconv.ParseFloat(value, 64)
+			return err
+		},
+	},
+}
+
+// RegisterKnownType registers how generated code handles the type named
+// name, a name as returned by typemap.Name such as
+// github.com/shopspring/decimal.Decimal. Register its external types with
+// typemap.Register.
+func RegisterKnownType(name string, k KnownType) {
+	knownTypes[name] = k
+}
+
+// LookupKnownType returns the known type t, if it is one.
+func LookupKnownType(t types.Type) (KnownType, bool) {
+	k, ok := knownTypes[typemap.Name(t)]
+	return k, ok
+}
+
+// literal returns the Go expression of the known type k for value.
+func (k KnownType) literal(value string) (string, error) {
+	if k.Valid != nil {
+		if err := k.Valid(value); err != nil {
+			return "", fmt.Errorf("invalid value %q: %w", value, err)
+		}
+	}
+	return fmt.Sprintf(k.Literal, strconv.Quote(value)), nil
+}
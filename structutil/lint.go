@@ -0,0 +1,276 @@
+package structutil
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// lintCompat rewrites a generated file to satisfy linters teams run on
+// generated code too: functions get unnamed results (nonamedreturns),
+// non-empty keyed literals of the structs of pkg list every field
+// (exhaustruct), and exported declarations get doc comments (revive's
+// exported rule). pkg may be nil, for syntax-only runs.
+func lintCompat(filename string, src []byte, pkg *types.Package, toolName string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if n.Body != nil {
+				unnameResults(n.Type, n.Body)
+			}
+		case *ast.FuncLit:
+			unnameResults(n.Type, n.Body)
+		}
+		return true
+	})
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, f); err != nil {
+		return nil, err
+	}
+
+	// The remaining changes are insertions into the formatted source.
+	src = buf.Bytes()
+	fset = token.NewFileSet()
+	if f, err = parser.ParseFile(fset, filename, src, parser.ParseComments); err != nil {
+		return nil, err
+	}
+	inserts := make(map[int]string)
+	if pkg != nil {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if lit, ok := n.(*ast.CompositeLit); ok {
+				if text := completeLiteral(fset, lit, pkg); text != "" {
+					inserts[fset.Position(lit.Rbrace).Offset] = text
+				}
+			}
+			return true
+		})
+	}
+	documentExported(fset, f, toolName, inserts)
+	if len(inserts) == 0 {
+		return src, nil
+	}
+	offsets := make([]int, 0, len(inserts))
+	for offset := range inserts {
+		offsets = append(offsets, offset)
+	}
+	sort.Ints(offsets)
+	var out bytes.Buffer
+	last := 0
+	for _, offset := range offsets {
+		out.Write(src[last:offset])
+		out.WriteString(inserts[offset])
+		last = offset
+	}
+	out.Write(src[last:])
+	return format.Source(out.Bytes())
+}
+
+// unnameResults declares the named results of a function as variables at
+// the top of its body and makes its naked returns return them. Functions
+// deferring calls are left alone, as those may set the results after a
+// return.
+func unnameResults(ftype *ast.FuncType, body *ast.BlockStmt) {
+	if ftype.Results == nil || defers(body) {
+		return
+	}
+	var names []*ast.Ident
+	var decls []ast.Stmt
+	var fields []*ast.Field
+	for _, field := range ftype.Results.List {
+		if len(field.Names) == 0 {
+			fields = append(fields, field)
+			continue
+		}
+		for _, name := range field.Names {
+			if name.Name == "_" {
+				name = ast.NewIdent(fmt.Sprintf("result%d", len(names)))
+			}
+			names = append(names, name)
+			fields = append(fields, &ast.Field{Type: field.Type})
+		}
+		decls = append(decls, &ast.DeclStmt{Decl: &ast.GenDecl{
+			Tok:   token.VAR,
+			Specs: []ast.Spec{&ast.ValueSpec{Names: renamed(field.Names, names[len(names)-len(field.Names):]), Type: field.Type}},
+		}})
+	}
+	if len(names) == 0 {
+		return
+	}
+	ftype.Results.List = fields
+	body.List = append(decls, body.List...)
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Its returns are its own.
+			return false
+		case *ast.ReturnStmt:
+			if len(n.Results) == 0 {
+				for _, name := range names {
+					n.Results = append(n.Results, ast.NewIdent(name.Name))
+				}
+			}
+		}
+		return true
+	})
+}
+
+// defers reports whether body defers a call, outside function literals.
+func defers(body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.DeferStmt:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// renamed returns the identifiers declaring names; blank names were given
+// new ones.
+func renamed(orig, names []*ast.Ident) []*ast.Ident {
+	idents := make([]*ast.Ident, len(orig))
+	for i := range orig {
+		idents[i] = ast.NewIdent(names[i].Name)
+	}
+	return idents
+}
+
+// completeLiteral returns the text to insert before the closing brace of
+// a keyed literal of a struct of pkg to set the fields it leaves out to
+// their zero values, or "".
+func completeLiteral(fset *token.FileSet, lit *ast.CompositeLit, pkg *types.Package) string {
+	ident, ok := lit.Type.(*ast.Ident)
+	if !ok || len(lit.Elts) == 0 {
+		return ""
+	}
+	obj, ok := pkg.Scope().Lookup(ident.Name).(*types.TypeName)
+	if !ok {
+		return ""
+	}
+	s, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return ""
+	}
+	set := make(map[string]bool)
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			// Positional literals list every field already.
+			return ""
+		}
+		if key, ok := kv.Key.(*ast.Ident); ok {
+			set[key.Name] = true
+		}
+	}
+	var missing []string
+	for i := 0; i < s.NumFields(); i++ {
+		if field := s.Field(i); !set[field.Name()] {
+			missing = append(missing, field.Name()+": "+zeroExpr(field.Type(), Qualifier(pkg.Path())))
+		}
+	}
+	if len(missing) == 0 {
+		return ""
+	}
+	if fset.Position(lit.Elts[len(lit.Elts)-1].End()).Line == fset.Position(lit.Rbrace).Line {
+		return ", " + strings.Join(missing, ", ")
+	}
+	// The last element ends with a comma and a newline.
+	return strings.Join(missing, ",\n") + ",\n"
+}
+
+// zeroExpr returns a Go expression of the zero value of t.
+func zeroExpr(t types.Type, q types.Qualifier) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+		return "nil"
+	case *types.Struct, *types.Array:
+		return types.TypeString(t, q) + "{}"
+	}
+	return "nil"
+}
+
+// documentExported adds to inserts a doc comment for each exported
+// declaration of f that has none. Methods of unexported types are left
+// alone.
+func documentExported(fset *token.FileSet, f *ast.File, toolName string, inserts map[int]string) {
+	docs := make(map[int]string) // By offset of the declaration.
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil || !d.Name.IsExported() || d.Recv != nil && !receiverExported(d.Recv) {
+				continue
+			}
+			docs[fset.Position(d.Pos()).Offset] = d.Name.Name
+		case *ast.GenDecl:
+			if d.Doc != nil || d.Tok == token.IMPORT {
+				continue
+			}
+			if name := undocumented(d); name != "" {
+				docs[fset.Position(d.Pos()).Offset] = name
+			}
+		}
+	}
+	for offset, name := range docs {
+		inserts[offset] = fmt.Sprintf("// %s is generated by %s.\n", name, toolName)
+	}
+}
+
+// undocumented returns the first exported name the declaration d leaves
+// undocumented, or "". A doc comment on a spec of a group documents it.
+func undocumented(d *ast.GenDecl) string {
+	for _, spec := range d.Specs {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			if s.Doc == nil && s.Name.IsExported() {
+				return s.Name.Name
+			}
+		case *ast.ValueSpec:
+			if s.Doc != nil {
+				continue
+			}
+			for _, name := range s.Names {
+				if name.IsExported() {
+					return name.Name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// receiverExported reports whether the base type of the receiver is
+// exported.
+func receiverExported(recv *ast.FieldList) bool {
+	if len(recv.List) == 0 {
+		return false
+	}
+	t := recv.List[0].Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	ident, ok := t.(*ast.Ident)
+	return ok && ident.IsExported()
+}
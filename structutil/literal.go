@@ -0,0 +1,218 @@
+package structutil
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GoLiteral converts value, a string as written in a struct tag, into a Go
+// expression of type t. Durations are parsed with time.ParseDuration, known
+// types are built by their KnownType.Literal, slices are comma-separated, and
+// values of named types may name one of the type's constants. pkgPath is the
+// path of the package the expression is used in.
+func GoLiteral(value string, t types.Type, pkgPath string) (string, error) {
+	if IsDuration(t) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return "", err
+		}
+		return DurationLiteral(d), nil
+	}
+	if k, ok := LookupKnownType(t); ok {
+		return k.literal(value)
+	}
+	if named, ok := t.(*types.Named); ok {
+		obj := named.Obj()
+		if token.IsIdentifier(value) && obj.Pkg() != nil {
+			if c, ok := obj.Pkg().Scope().Lookup(value).(*types.Const); ok && types.Identical(c.Type(), t) {
+				if obj.Pkg().Path() == pkgPath {
+					return value, nil
+				}
+				return obj.Pkg().Name() + "." + value, nil
+			}
+		}
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return basicLiteral(value, u)
+	case *types.Slice:
+		if value == "" {
+			return types.TypeString(t, Qualifier(pkgPath)) + "{}", nil
+		}
+		var elems []string
+		for _, v := range strings.Split(value, ",") {
+			elem, err := GoLiteral(strings.TrimSpace(v), u.Elem(), pkgPath)
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, elem)
+		}
+		return fmt.Sprintf("%s{%s}", types.TypeString(t, Qualifier(pkgPath)), strings.Join(elems, ", ")), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+func basicLiteral(value string, t *types.Basic) (string, error) {
+	info := t.Info()
+	switch {
+	case info&types.IsBoolean != 0:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(b), nil
+	case info&types.IsString != 0:
+		return strconv.Quote(value), nil
+	case info&types.IsInteger != 0:
+		var err error
+		if info&types.IsUnsigned != 0 {
+			_, err = strconv.ParseUint(value, 0, 64)
+		} else {
+			_, err = strconv.ParseInt(value, 0, 64)
+		}
+		if err != nil {
+			return "", err
+		}
+		return value, nil
+	case info&types.IsFloat != 0:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", err
+		}
+		return value, nil
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// DurationLiteral returns a Go expression for d in the largest unit that
+// represents it exactly, such as "90 * time.Second".
+func DurationLiteral(d time.Duration) string {
+	units := []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+		{time.Microsecond, "time.Microsecond"},
+	}
+	if d == 0 {
+		return "0"
+	}
+	for _, u := range units {
+		if d%u.d == 0 {
+			return fmt.Sprintf("%d * %s", d/u.d, u.name)
+		}
+	}
+	return fmt.Sprintf("%d * time.Nanosecond", d)
+}
+
+// Qualifier qualifies types of packages other than pkgPath with their package
+// name, as written in a file of the package pkgPath.
+func Qualifier(pkgPath string) types.Qualifier {
+	return func(p *types.Package) string {
+		if p.Path() == pkgPath {
+			return ""
+		}
+		return p.Name()
+	}
+}
+
+// ParseCode returns Go statements that parse the string variable src into
+// dst, a value of type t, at run time. The statements return the parse
+// error from the enclosing function, which must return a single error.
+func ParseCode(src, dst string, t types.Type, pkgPath string) (string, error) {
+	typeName := types.TypeString(t, Qualifier(pkgPath))
+	if IsDuration(t) {
+		return fmt.Sprintf("v, err := time.ParseDuration(%s)\nif err != nil {\nreturn err\n}\n%s = v\n", src, dst), nil
+	}
+	if k, ok := LookupKnownType(t); ok {
+		return fmt.Sprintf("v, err := %s\nif err != nil {\nreturn err\n}\n%s = v\n", fmt.Sprintf(k.Parse, src), dst), nil
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		var parse string
+		info := u.Info()
+		switch {
+		case info&types.IsString != 0:
+			return fmt.Sprintf("%s = %s(%s)\n", dst, typeName, src), nil
+		case info&types.IsBoolean != 0:
+			parse = fmt.Sprintf("strconv.ParseBool(%s)", src)
+		case info&types.IsInteger != 0 && info&types.IsUnsigned != 0:
+			parse = fmt.Sprintf("strconv.ParseUint(%s, 0, %d)", src, bitSize(u))
+		case info&types.IsInteger != 0:
+			parse = fmt.Sprintf("strconv.ParseInt(%s, 0, %d)", src, bitSize(u))
+		case info&types.IsFloat != 0:
+			parse = fmt.Sprintf("strconv.ParseFloat(%s, %d)", src, bitSize(u))
+		default:
+			return "", fmt.Errorf("unsupported type %s", t)
+		}
+		return fmt.Sprintf("v, err := %s\nif err != nil {\nreturn err\n}\n%s = %s(v)\n", parse, dst, typeName), nil
+	case *types.Slice:
+		elem, err := ParseCode("s", "e", u.Elem(), pkgPath)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("var vs %s\nfor _, s := range strings.Split(%s, \",\") {\nvar e %s\n%svs = append(vs, e)\n}\n%s = vs\n",
+			typeName, src, types.TypeString(u.Elem(), Qualifier(pkgPath)), elem, dst), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+func bitSize(t *types.Basic) int {
+	switch t.Kind() {
+	case types.Int8, types.Uint8:
+		return 8
+	case types.Int16, types.Uint16:
+		return 16
+	case types.Int32, types.Uint32, types.Float32:
+		return 32
+	case types.Int64, types.Uint64, types.Float64:
+		return 64
+	}
+	// int, uint and uintptr: the size of int.
+	return 0
+}
+
+// FormatCode returns a Go string expression formatting expr, a value of the
+// scalar type t, the way ParseCode parses it back.
+func FormatCode(expr string, t types.Type) (string, error) {
+	if IsDuration(t) {
+		return expr + ".String()", nil
+	}
+	if k, ok := LookupKnownType(t); ok {
+		return fmt.Sprintf(k.Format, expr), nil
+	}
+	if u, ok := t.Underlying().(*types.Basic); ok {
+		info := u.Info()
+		switch {
+		case info&types.IsString != 0:
+			return fmt.Sprintf("string(%s)", expr), nil
+		case info&types.IsBoolean != 0:
+			return fmt.Sprintf("strconv.FormatBool(bool(%s))", expr), nil
+		case info&types.IsInteger != 0 && info&types.IsUnsigned != 0:
+			return fmt.Sprintf("strconv.FormatUint(uint64(%s), 10)", expr), nil
+		case info&types.IsInteger != 0:
+			return fmt.Sprintf("strconv.FormatInt(int64(%s), 10)", expr), nil
+		case info&types.IsFloat != 0:
+			return fmt.Sprintf("strconv.FormatFloat(float64(%s), 'g', -1, %d)", expr, bitSize(u)), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// IsDuration reports whether t is time.Duration.
+func IsDuration(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Duration"
+}
@@ -0,0 +1,148 @@
+package structutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// LoadPackage loads the single package matched by patterns with syntax and
+// type information.
+func LoadPackage(patterns []string) (*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode:  packages.LoadSyntax,
+		Tests: false,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("error: %d packages found", len(pkgs))
+	}
+	return pkgs[0], nil
+}
+
+// LoadPackages loads all packages matched by patterns, such as ./..., with
+// syntax and type information.
+func LoadPackages(patterns []string) ([]*packages.Package, error) {
+	return loadPackages("", patterns, packages.LoadSyntax, nil)
+}
+
+// loadPackages loads the packages matched by patterns in dir, or the
+// working directory if dir is empty, with the files of overlay replacing
+// those on disk.
+func loadPackages(dir string, patterns []string, mode packages.LoadMode, overlay map[string][]byte) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode:    mode,
+		Dir:     dir,
+		Tests:   false,
+		Overlay: overlay,
+	}
+	return packages.Load(cfg, patterns...)
+}
+
+// SourceDir returns the directory of the package given by args, either a
+// single directory or a list of files.
+func SourceDir(args []string) (string, error) {
+	if len(args) == 1 {
+		dir, err := isDirectory(args[0])
+		if err != nil {
+			return "", err
+		}
+		if dir {
+			return args[0], nil
+		}
+	}
+	return filepath.Dir(args[0]), nil
+}
+
+// WriteGenerated stamps and formats src as FormatGenerated does and writes
+// it to outputName.
+func WriteGenerated(outputName string, src []byte, inputs ...string) error {
+	src, err := FormatGenerated(outputName, src, inputs...)
+	if err != nil {
+		return fmt.Errorf("formatting output: %w", err)
+	}
+	if err := ioutil.WriteFile(outputName, src, 0644); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
+
+// LoadStructs loads the single package matched by patterns and returns the
+// named structs as PackageStructs does. It serves generators whose output
+// is not Go source and that do not run through GenerateForFields.
+func LoadStructs(patterns []string, names []string) (*Package, []*StructInfo, error) {
+	pkg, err := LoadPackage(patterns)
+	if err != nil {
+		return nil, nil, err
+	}
+	return PackageStructs(pkg, names)
+}
+
+// PackageStructs returns the named structs of pkg, loaded with syntax and
+// type information, in the given order, or all its structs in source order
+// if names is empty.
+func PackageStructs(pkg *packages.Package, names []string) (*Package, []*StructInfo, error) {
+	g, err := NewForFieldsGenerator(&GenerateForFieldsConfig{}, nil).newRun(Options{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return g.packageStructs(pkg, names)
+}
+
+// ParsePackage loads the single package matched by patterns, "." if there
+// are none, and returns its structs named by opts.Types, or all of them in
+// declaration order, with their fields, tags, docs, directives and types.
+// It serves tools that want the model generators receive without running
+// one: opts.Dir, Exclude, ScanGenerated, Overlay and PreFilter apply as for
+// Generate, the other options are ignored. Unlike LoadStructs it fails on
+// type errors of the package.
+func ParsePackage(patterns []string, opts Options) ([]*StructInfo, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	g, err := NewForFieldsGenerator(&GenerateForFieldsConfig{}, nil).newRun(opts)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := loadPackages(opts.Dir, patterns, packages.LoadSyntax, g.opts.Overlay)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("error: %d packages found", len(pkgs))
+	}
+	if len(pkgs[0].Errors) > 0 {
+		return nil, pkgs[0].Errors[0]
+	}
+	_, infos, err := g.packageStructs(pkgs[0], opts.Types)
+	return infos, err
+}
+
+// packageStructs returns the named structs of pkg as PackageStructs does,
+// with the options of the run g.
+func (g *GenerateForFields) packageStructs(pkg *packages.Package, names []string) (p *Package, infos []*StructInfo, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			failure, ok := e.(runError)
+			if !ok {
+				panic(e)
+			}
+			p, infos, err = nil, nil, failure.err
+		}
+	}()
+
+	g.addPackage(pkg)
+	if len(names) == 0 {
+		names = g.pkg.structNames()
+	}
+	for _, name := range names {
+		info, _ := g.structInfo(name)
+		infos = append(infos, info)
+	}
+	return g.pkg, infos, nil
+}
@@ -0,0 +1,66 @@
+package structutil
+
+import (
+	"fmt"
+
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+// TagCasing returns the casing that a //gentoolkit:tags directive on the
+// struct, such as
+//
+//	//gentoolkit:tags json=snake db=snake
+//
+// gives for fields without an explicit key tag.
+func (s *StructInfo) TagCasing(key string) (tagutil.Casing, bool) {
+	d := s.Directive("tags")
+	if d == nil || !d.Has(key) {
+		return "", false
+	}
+	c, err := tagutil.ParseCasing(d.Get(key))
+	if err != nil {
+		return "", false
+	}
+	return c, true
+}
+
+// EffectiveName returns the name of field under the key tag: the name of an
+// explicit tag, else the field name in the casing of the struct's
+// //gentoolkit:tags directive, else the field name itself. It reports false
+// if the field is excluded with "-".
+func (s *StructInfo) EffectiveName(field StructFieldInfo, key string) (string, bool) {
+	if field.Tags != nil {
+		if tag, err := field.Tags.Get(key); err == nil && tag.Name != "" {
+			if tag.Name == "-" {
+				return "", false
+			}
+			return tag.Name, true
+		}
+	}
+	if c, ok := s.TagCasing(key); ok {
+		return c.Apply(field.Name), true
+	}
+	return field.Name, true
+}
+
+// validateTagsDirective checks the casings of a //gentoolkit:tags directive.
+func validateTagsDirective(d *Directive) error {
+	if d == nil {
+		return nil
+	}
+	for _, key := range d.Keys {
+		if _, err := tagutil.ParseCasing(d.Args[key]); err != nil {
+			return fmt.Errorf("gentoolkit:tags %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// TableName returns the SQL table name of the struct: the name argument of
+// a //gentoolkit:table directive, else tagutil.TableName of the struct name.
+func (s *StructInfo) TableName() string {
+	if d := s.Directive("table"); d != nil && d.Get("name") != "" {
+		return d.Get("name")
+	}
+	return tagutil.TableName(s.Name)
+}
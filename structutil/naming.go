@@ -0,0 +1,109 @@
+package structutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jakoblorz/go-gentoolkit/tagutil"
+)
+
+// NamingStrategy decides the names of generated identifiers and files. A
+// custom strategy registered with RegisterNamingStrategy and selected with
+// -naming is applied by every generator built on this package, so an
+// organization can enforce its house style without forking templates.
+type NamingStrategy interface {
+	// MethodName returns the name of a method generated for a field, for
+	// example MethodName("Get", "Name") for a getter.
+	MethodName(prefix, fieldName string) string
+	// FileName returns the base name of the output file of a generator
+	// with the given suffix for typeName.
+	FileName(typeName, suffix string) string
+	// ConstName returns the name of a constant of typeName for value.
+	ConstName(typeName, value string) string
+	// ColumnName returns the SQL column name of a field.
+	ColumnName(fieldName string) string
+}
+
+// DefaultNaming is the name of the built-in naming strategy.
+const DefaultNaming = "default"
+
+type defaultNaming struct{}
+
+func (defaultNaming) MethodName(prefix, fieldName string) string {
+	return prefix + fieldName
+}
+
+func (defaultNaming) FileName(typeName, suffix string) string {
+	return strings.ToLower(fmt.Sprintf("%s_%s.go", toSnakeCase(typeName), suffix))
+}
+
+func (defaultNaming) ConstName(typeName, value string) string {
+	return typeName + tagutil.Pascal.Apply(value)
+}
+
+func (defaultNaming) ColumnName(fieldName string) string {
+	return tagutil.Snake.Apply(fieldName)
+}
+
+var (
+	namingMu         sync.RWMutex
+	namingStrategies                = map[string]NamingStrategy{DefaultNaming: defaultNaming{}}
+	naming           NamingStrategy = defaultNaming{}
+)
+
+// RegisterNamingStrategy makes a naming strategy available under name. It
+// is meant to be called from init functions of custom generator binaries.
+func RegisterNamingStrategy(name string, s NamingStrategy) {
+	namingMu.Lock()
+	defer namingMu.Unlock()
+	namingStrategies[name] = s
+}
+
+// UseNamingStrategy selects the registered strategy returned by Naming. It
+// serves generators that do not run through GenerateForFields; runs of
+// Generate take their strategy from Options.Naming and leave it alone.
+func UseNamingStrategy(name string) error {
+	s, err := namingStrategy(name)
+	if err != nil {
+		return err
+	}
+	namingMu.Lock()
+	defer namingMu.Unlock()
+	naming = s
+	return nil
+}
+
+// namingStrategy returns the strategy registered under name.
+func namingStrategy(name string) (NamingStrategy, error) {
+	namingMu.RLock()
+	defer namingMu.RUnlock()
+	s, ok := namingStrategies[name]
+	if !ok {
+		names := make([]string, 0, len(namingStrategies))
+		for name := range namingStrategies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown naming strategy %q; registered: %s", name, strings.Join(names, ", "))
+	}
+	return s, nil
+}
+
+// Naming returns the strategy selected with UseNamingStrategy. Generators
+// built on GenerateForFields must use StructInfo.Naming, the strategy of
+// the run that produced the struct.
+func Naming() NamingStrategy {
+	namingMu.RLock()
+	defer namingMu.RUnlock()
+	return naming
+}
+
+// Naming returns the naming strategy of the run that produced s.
+func (s *StructInfo) Naming() NamingStrategy {
+	if s.gen == nil || s.gen.strategy == nil {
+		return Naming()
+	}
+	return s.gen.strategy
+}
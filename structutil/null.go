@@ -0,0 +1,98 @@
+package structutil
+
+import (
+	"go/types"
+	"strings"
+)
+
+// NullStrategy is how a Go type represents the absence of a value, such as
+// SQL NULL or a missing JSON property.
+type NullStrategy int
+
+const (
+	// NotNull types always hold a value.
+	NotNull NullStrategy = iota
+	// NullPointer types are pointers that are nil when null.
+	NullPointer
+	// NullWrapper types are structs like sql.NullString that hold a value
+	// and a Valid flag.
+	NullWrapper
+	// NullOption types are option types whose Get method returns the value
+	// and whether it is present.
+	NullOption
+)
+
+// Nullability returns how t represents null and the type of its values
+// when not null. Slices, maps and interfaces are NotNull: their nil is an
+// empty value rather than a missing one.
+func Nullability(t types.Type) (NullStrategy, types.Type) {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		return NullPointer, p.Elem()
+	}
+	if elem := wrapperValue(t); elem != nil {
+		return NullWrapper, elem
+	}
+	if elem := optionValue(t); elem != nil {
+		return NullOption, elem
+	}
+	return NotNull, t
+}
+
+// wrapperValue returns the type of the value field of t, a struct of a
+// value and a Valid bool, or nil if t is none.
+func wrapperValue(t types.Type) types.Type {
+	s, ok := t.Underlying().(*types.Struct)
+	if !ok || s.NumFields() != 2 {
+		return nil
+	}
+	var value types.Type
+	valid := false
+	for i := 0; i < 2; i++ {
+		f := s.Field(i)
+		if b, ok := f.Type().(*types.Basic); ok && f.Name() == "Valid" && b.Kind() == types.Bool {
+			valid = true
+		} else {
+			value = f.Type()
+		}
+	}
+	if !valid {
+		return nil
+	}
+	return value
+}
+
+// optionValue returns T if t has a method Get() (T, bool), or nil.
+func optionValue(t types.Type) types.Type {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, nil, "Get")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return nil
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 0 || sig.Results().Len() != 2 {
+		return nil
+	}
+	if b, ok := sig.Results().At(1).Type().(*types.Basic); !ok || b.Kind() != types.Bool {
+		return nil
+	}
+	return sig.Results().At(0).Type()
+}
+
+// NullStrategy returns how the field's type represents null. Without type
+// information, only pointers are recognized.
+func (f *StructFieldInfo) NullStrategy() NullStrategy {
+	if f.GoType == nil {
+		if strings.HasPrefix(f.Type, "*") {
+			return NullPointer
+		}
+		return NotNull
+	}
+	s, _ := Nullability(f.GoType)
+	return s
+}
+
+// IsNullable reports whether the field can be null: nullable columns in
+// SQL and optional properties in JSON and TypeScript.
+func (f *StructFieldInfo) IsNullable() bool {
+	return f.NullStrategy() != NotNull
+}
@@ -0,0 +1,88 @@
+package structutil
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Stage is one generator of a Pipeline. Gen prints declarations only; the
+// pipeline prints the header and package clause once per output file.
+type Stage struct {
+	Name string
+	// DependsOn names the stages whose output this stage relies on, for
+	// example helper functions it calls. They run first.
+	DependsOn []string
+	Gen       func(info *StructInfo, p PrinterWriter)
+}
+
+// NewPipeline returns a generator running several stages for each type in
+// a single parse and emit cycle, writing their output to one file. Stages
+// run in dependency order, ties broken by the order given. It returns an
+// error if a dependency is unknown or the dependencies form a cycle.
+func NewPipeline(c *GenerateForFieldsConfig, stages ...Stage) (*GenerateForFields, error) {
+	ordered, err := sortStages(stages)
+	if err != nil {
+		return nil, err
+	}
+	return NewForFieldsGenerator(c, func(info *StructInfo, p PrinterWriter) {
+		p.Printf("// Code generated by \"%s %s\"; DO NOT EDIT.\n", c.ToolName, JoinArgs(os.Args[1:]))
+		p.Printf("\n")
+		p.Printf("package %s\n", info.Package.GetName())
+		for _, s := range ordered {
+			s.Gen(info, p)
+		}
+	}), nil
+}
+
+// sortStages orders stages topologically.
+func sortStages(stages []Stage) ([]Stage, error) {
+	index := make(map[string]int, len(stages))
+	for i, s := range stages {
+		if _, ok := index[s.Name]; ok {
+			return nil, fmt.Errorf("duplicate stage %q", s.Name)
+		}
+		index[s.Name] = i
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(stages))
+	var (
+		ordered []Stage
+		visit   func(i int, trail []string) error
+	)
+	visit = func(i int, trail []string) error {
+		switch state[i] {
+		case visiting:
+			return fmt.Errorf("stage dependencies form a cycle: %s", strings.Join(append(trail, stages[i].Name), " -> "))
+		case done:
+			return nil
+		}
+		state[i] = visiting
+		deps := append([]string(nil), stages[i].DependsOn...)
+		sort.SliceStable(deps, func(a, b int) bool { return index[deps[a]] < index[deps[b]] })
+		for _, dep := range deps {
+			j, ok := index[dep]
+			if !ok {
+				return fmt.Errorf("stage %q depends on unknown stage %q", stages[i].Name, dep)
+			}
+			if err := visit(j, append(trail, stages[i].Name)); err != nil {
+				return err
+			}
+		}
+		state[i] = done
+		ordered = append(ordered, stages[i])
+		return nil
+	}
+	for i := range stages {
+		if err := visit(i, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
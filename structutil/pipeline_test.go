@@ -0,0 +1,58 @@
+package structutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPipelineRunsStagesInDependencyOrder(t *testing.T) {
+	dir := writePackage(t, "package p\n\ntype A struct{ X int }\n")
+	stage := func(name string, deps ...string) Stage {
+		return Stage{Name: name, DependsOn: deps, Gen: func(info *StructInfo, p PrinterWriter) {
+			p.Printf("\n// stage %s of %s\n", name, info.Name)
+		}}
+	}
+	g, err := NewPipeline(&GenerateForFieldsConfig{
+		ToolName:   "go-gen-test",
+		FileSuffix: "test_out",
+	}, stage("validate", "getters"), stage("getters"), stage("merge", "validate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := g.Generate(Options{Dir: dir, Types: []string{"A"}, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Files) != 1 {
+		t.Fatalf("Generate returned %d files, want one", len(res.Files))
+	}
+	src := string(res.Files[0].Src)
+	if n := strings.Count(src, "package p\n"); n != 1 {
+		t.Errorf("package clause printed %d times in\n%s", n, src)
+	}
+	getters, validate, merge := strings.Index(src, "stage getters"), strings.Index(src, "stage validate"), strings.Index(src, "stage merge")
+	if getters < 0 || !(getters < validate && validate < merge) {
+		t.Errorf("stages out of dependency order in\n%s", src)
+	}
+}
+
+func TestPipelineRejectsBadDependencies(t *testing.T) {
+	gen := func(*StructInfo, PrinterWriter) {}
+	tests := []struct {
+		name   string
+		stages []Stage
+		want   string
+	}{
+		{"unknown", []Stage{{Name: "a", DependsOn: []string{"b"}, Gen: gen}}, `stage "a" depends on unknown stage "b"`},
+		{"cycle", []Stage{{Name: "a", DependsOn: []string{"b"}, Gen: gen}, {Name: "b", DependsOn: []string{"a"}, Gen: gen}}, "cycle: a -> b -> a"},
+		{"duplicate", []Stage{{Name: "a", Gen: gen}, {Name: "a", Gen: gen}}, `duplicate stage "a"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPipeline(&GenerateForFieldsConfig{ToolName: "go-gen-test", FileSuffix: "test_out"}, tt.stages...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewPipeline error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package structutil
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// startProfile starts a CPU profile written to prefix.cpu.pprof and returns
+// a function that stops it and writes a heap profile to prefix.mem.pprof.
+func startProfile(prefix string) (stop func() error, err error) {
+	cpu, err := os.Create(prefix + ".cpu.pprof")
+	if err != nil {
+		return nil, fmt.Errorf("profiling: %w", err)
+	}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		cpu.Close()
+		return nil, fmt.Errorf("profiling: %w", err)
+	}
+	return func() error {
+		pprof.StopCPUProfile()
+		if err := cpu.Close(); err != nil {
+			return fmt.Errorf("profiling: %w", err)
+		}
+		mem, err := os.Create(prefix + ".mem.pprof")
+		if err != nil {
+			return fmt.Errorf("profiling: %w", err)
+		}
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(mem); err != nil {
+			mem.Close()
+			return fmt.Errorf("profiling: %w", err)
+		}
+		if err := mem.Close(); err != nil {
+			return fmt.Errorf("profiling: %w", err)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,18 @@
+package structutil
+
+// Progress receives notifications while a generator runs. Implementations
+// can embed NopProgress and override only the events they care about.
+type Progress interface {
+	PackageLoaded(pkg *Package)
+	TypeStarted(typeName string)
+	TypeFinished(typeName string)
+	FileWritten(filename string)
+}
+
+// NopProgress ignores all progress events.
+type NopProgress struct{}
+
+func (NopProgress) PackageLoaded(pkg *Package)   {}
+func (NopProgress) TypeStarted(typeName string)  {}
+func (NopProgress) TypeFinished(typeName string) {}
+func (NopProgress) FileWritten(filename string)  {}
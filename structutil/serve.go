@@ -0,0 +1,128 @@
+package structutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ServeRequest asks a generator started with -serve for one run. Its
+// fields override the options given on the command line of the generator.
+type ServeRequest struct {
+	Dir      string   `json:"dir"`
+	Patterns []string `json:"patterns,omitempty"`
+	Types    []string `json:"types,omitempty"`
+	DryRun   bool     `json:"dryRun,omitempty"`
+	// Overlay holds unsaved file contents by path, as for Options.
+	Overlay map[string]string `json:"overlay,omitempty"`
+}
+
+// ServeResponse is the outcome of a ServeRequest.
+type ServeResponse struct {
+	Files    []ServeFile `json:"files,omitempty"`
+	Outdated []string    `json:"outdated,omitempty"`
+	Warnings []string    `json:"warnings,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// ServeFile is an output file of a served run. Src is only set for dry
+// runs.
+type ServeFile struct {
+	Name string `json:"name"`
+	Src  string `json:"src,omitempty"`
+}
+
+// serve answers the ServeRequests read from r, one JSON value per line,
+// on w until r is exhausted. Each run starts from base.
+func (g *GenerateForFields) serve(base Options, r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+	for {
+		var req ServeRequest
+		if err := dec.Decode(&req); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := enc.Encode(g.serveOne(base, &req)); err != nil {
+			return err
+		}
+	}
+}
+
+func (g *GenerateForFields) serveOne(base Options, req *ServeRequest) *ServeResponse {
+	if len(req.Types) == 0 && g.pkgFunc == nil {
+		return &ServeResponse{Error: "types must be set"}
+	}
+	opts := base
+	opts.Dir = req.Dir
+	opts.Patterns = req.Patterns
+	opts.Types = req.Types
+	opts.DryRun = req.DryRun
+	opts.Overlay = nil
+	if req.Overlay != nil {
+		opts.Overlay = make(map[string][]byte, len(req.Overlay))
+		for name, src := range req.Overlay {
+			opts.Overlay[name] = []byte(src)
+		}
+	}
+	// Record the request in the headers as if the generator had been run
+	// in req.Dir, so that regen can replay it.
+	opts.Args = append([]string(nil), base.Args...)
+	if len(req.Types) > 0 {
+		opts.Args = append(opts.Args, "-type", strings.Join(req.Types, ","))
+	}
+	opts.Args = append(opts.Args, req.Patterns...)
+
+	res, err := g.Generate(opts)
+	if err != nil {
+		return &ServeResponse{Error: err.Error()}
+	}
+	resp := &ServeResponse{Outdated: res.Outdated, Warnings: res.Warnings}
+	for _, f := range res.Files {
+		sf := ServeFile{Name: f.Name}
+		if req.DryRun {
+			sf.Src = string(f.Src)
+		}
+		resp.Files = append(resp.Files, sf)
+	}
+	return resp
+}
+
+// withoutServe returns args without the -serve flag.
+func withoutServe(args []string) []string {
+	var out []string
+	for _, arg := range args {
+		switch arg {
+		case "-serve", "--serve", "-serve=true", "--serve=true":
+			continue
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+// rewriteHeader replaces the arguments in the generated-code header of src
+// with args, keeping the tool name, so that the header of every output
+// records the invocation of its run.
+func rewriteHeader(src []byte, args []string) []byte {
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	for i, line := range lines {
+		if bytes.HasPrefix(line, []byte("package ")) {
+			break
+		}
+		_, invocation, ok := matchHeader(string(bytes.TrimRight(line, "\r\n")))
+		if !ok {
+			continue
+		}
+		words := splitInvocation(invocation)
+		if len(words) == 0 {
+			continue
+		}
+		lines[i] = []byte(fmt.Sprintf("// Code generated by \"%s\"; DO NOT EDIT.\n", JoinArgs(append(words[:1:1], args...))))
+		return bytes.Join(lines, nil)
+	}
+	return src
+}
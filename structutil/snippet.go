@@ -0,0 +1,89 @@
+package structutil
+
+import (
+	"strings"
+	"text/template"
+)
+
+// Snippet is the data the snippet templates are executed with.
+type Snippet struct {
+	Receiver string // Receiver name, the lower-cased first letter of Struct.
+	Struct   string
+	Field    string
+	Method   string // Method name chosen by the naming strategy.
+	Type     string
+	// Copy is "slice" or "map" if the method copies the field or argument.
+	Copy string
+	// Elem is the element type of a pointer field a getter returns as an
+	// option; Some and None are the package-qualified functions returning
+	// a present and an absent option.
+	Elem string
+	Some string
+	None string
+}
+
+// The snippet templates are parsed once when the package is initialized.
+// go-gen-getter and go-gen-setter render every method through them, so a
+// program running these generators may replace them before Run to change
+// the emitted code.
+var (
+	GetterSnippet = template.Must(template.New("getter").Parse(`func ({{.Receiver}} *{{.Struct}}) {{.Method}}() {{.Type}} {
+{{- if .Elem}}
+	if {{.Receiver}}.{{.Field}} == nil {
+		return {{.None}}[{{.Elem}}]()
+	}
+	return {{.Some}}(*{{.Receiver}}.{{.Field}})
+{{- else if eq .Copy "slice"}}
+	if {{.Receiver}}.{{.Field}} == nil {
+		return nil
+	}
+	c := make({{.Type}}, len({{.Receiver}}.{{.Field}}))
+	copy(c, {{.Receiver}}.{{.Field}})
+	return c
+{{- else if eq .Copy "map"}}
+	if {{.Receiver}}.{{.Field}} == nil {
+		return nil
+	}
+	c := make({{.Type}}, len({{.Receiver}}.{{.Field}}))
+	for k, v := range {{.Receiver}}.{{.Field}} {
+		c[k] = v
+	}
+	return c
+{{- else}}
+	return {{.Receiver}}.{{.Field}}
+{{- end}}
+}`))
+	SetterSnippet = template.Must(template.New("setter").Parse(`func ({{.Receiver}} *{{.Struct}}) {{.Method}}(param {{.Type}}) {
+{{- if eq .Copy "slice"}}
+	if param == nil {
+		{{.Receiver}}.{{.Field}} = nil
+		return
+	}
+	{{.Receiver}}.{{.Field}} = make({{.Type}}, len(param))
+	copy({{.Receiver}}.{{.Field}}, param)
+{{- else if eq .Copy "map"}}
+	if param == nil {
+		{{.Receiver}}.{{.Field}} = nil
+		return
+	}
+	{{.Receiver}}.{{.Field}} = make({{.Type}}, len(param))
+	for k, v := range param {
+		{{.Receiver}}.{{.Field}}[k] = v
+	}
+{{- else}}
+	{{.Receiver}}.{{.Field}} = param
+{{- end}}
+}`))
+)
+
+// Snippet returns the snippet of the method of s named for field with
+// prefix, such as "Get", by the naming strategy of the run.
+func (s *StructInfo) Snippet(field StructFieldInfo, prefix string) Snippet {
+	return Snippet{
+		Receiver: strings.ToLower(s.Name[0:1]),
+		Struct:   s.Name,
+		Field:    field.Name,
+		Method:   s.Naming().MethodName(prefix, field.Name),
+		Type:     field.Type,
+	}
+}
@@ -0,0 +1,75 @@
+package structutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+var annotation = regexp.MustCompile(`^\s*// generated from field (\w+)\.(\w+) \((.+):(\d+)\)$`)
+
+// Annotate prints a comment linking the next generated declaration to the
+// source field it was generated from. It prints nothing unless source maps
+// are enabled.
+func (p *shadowPrinter) Annotate(field StructFieldInfo) {
+	if !p.sourceMap {
+		return
+	}
+	p.Printf("// generated from field %s.%s (%s:%d)\n", p.structName, field.Name, filepath.Base(field.Pos.Filename), field.Pos.Line)
+}
+
+// SourceMap relates lines of a generated file to the fields they were
+// generated from.
+type SourceMap struct {
+	Version  int                `json:"version"`
+	File     string             `json:"file"`
+	Mappings []SourceMapMapping `json:"mappings"`
+}
+
+type SourceMapMapping struct {
+	GeneratedLine int    `json:"generatedLine"`
+	Struct        string `json:"struct"`
+	Field         string `json:"field"`
+	Source        string `json:"source"`
+	SourceLine    int    `json:"sourceLine"`
+}
+
+// buildSourceMap collects the source annotations of the final generated
+// source. Each mapping points at the line following its annotation.
+func buildSourceMap(outputName string, src []byte) *SourceMap {
+	m := &SourceMap{
+		Version:  1,
+		File:     filepath.Base(outputName),
+		Mappings: make([]SourceMapMapping, 0),
+	}
+	s := bufio.NewScanner(bytes.NewReader(src))
+	for line := 1; s.Scan(); line++ {
+		match := annotation.FindStringSubmatch(s.Text())
+		if match == nil {
+			continue
+		}
+		sourceLine, _ := strconv.Atoi(match[4])
+		m.Mappings = append(m.Mappings, SourceMapMapping{
+			GeneratedLine: line + 1,
+			Struct:        match[1],
+			Field:         match[2],
+			Source:        match[3],
+			SourceLine:    sourceLine,
+		})
+	}
+	return m
+}
+
+// encodeSourceMap returns the source map sidecar of outputName, written
+// next to it as <output>.map.json.
+func encodeSourceMap(outputName string, src []byte) ([]byte, error) {
+	data, err := json.MarshalIndent(buildSourceMap(outputName, src), "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("encoding source map: %w", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,156 @@
+package structutil
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// Column is a field of a struct mapped to an SQL column. The SQL-facing
+// generators share this vocabulary:
+//
+//	ID    int64  `db:"id,pk,autoincr"`
+//	Email string `db:"email,unique" index:"users_email_idx"`
+//	Note  string `db:",null"`
+//	Cache []byte `db:"-"`
+//
+// Fields with the same index tag form a composite index in field order; an
+// index tag with the unique option makes the index unique.
+type Column struct {
+	Field         StructFieldInfo
+	Name          string
+	PrimaryKey    bool
+	AutoIncrement bool
+	Unique        bool
+	Null          bool
+	Index         string // Name of the index the column belongs to, or "".
+	UniqueIndex   bool
+}
+
+// Index is an index over columns of a table.
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// Columns returns the columns of the exported fields of the struct in field
+// order. A column is named by its db tag, else by the casing the struct's
+// //gentoolkit:tags directive gives db, else by the ColumnName of the naming
+// strategy, the snake case of the field name by default. Unknown db or index options are an error, so that all generators
+// reject what one of them would ignore.
+func (s *StructInfo) Columns() ([]Column, error) {
+	var columns []Column
+	for _, field := range s.Fields {
+		if !ast.IsExported(field.Name) {
+			continue
+		}
+		c := Column{Field: field, Name: s.Naming().ColumnName(field.Name)}
+		if casing, ok := s.TagCasing("db"); ok {
+			c.Name = casing.Apply(field.Name)
+		}
+		if tag := field.TagValue("db"); tag != nil {
+			if tag.Name == "-" {
+				continue
+			}
+			if tag.Name != "" {
+				c.Name = tag.Name
+			}
+			for _, o := range tag.Options {
+				switch o.Name {
+				case "pk":
+					c.PrimaryKey = true
+				case "autoincr":
+					c.AutoIncrement = true
+				case "unique":
+					c.Unique = true
+				case "null":
+					c.Null = true
+				default:
+					return nil, fmt.Errorf("%s: %s: unknown db option %q", field.Pos, field.Name, o.Name)
+				}
+			}
+			if c.AutoIncrement && !c.PrimaryKey {
+				return nil, fmt.Errorf("%s: %s: autoincr requires pk", field.Pos, field.Name)
+			}
+			if c.PrimaryKey && c.Null {
+				return nil, fmt.Errorf("%s: %s: a primary key cannot be null", field.Pos, field.Name)
+			}
+		}
+		if tag := field.TagValue("index"); tag != nil {
+			if tag.Name == "" {
+				return nil, fmt.Errorf("%s: %s: index tag without a name", field.Pos, field.Name)
+			}
+			c.Index = tag.Name
+			for _, o := range tag.Options {
+				if o.Name != "unique" {
+					return nil, fmt.Errorf("%s: %s: unknown index option %q", field.Pos, field.Name, o.Name)
+				}
+				c.UniqueIndex = true
+			}
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// PrimaryKey returns the primary key columns of columns.
+func PrimaryKey(columns []Column) []Column {
+	var pk []Column
+	for _, c := range columns {
+		if c.PrimaryKey {
+			pk = append(pk, c)
+		}
+	}
+	return pk
+}
+
+// Indexes returns the indexes declared by the index tags of columns, in
+// the order of their first column.
+func Indexes(columns []Column) ([]Index, error) {
+	var indexes []Index
+	byName := make(map[string]int)
+	for _, c := range columns {
+		if c.Index == "" {
+			continue
+		}
+		i, ok := byName[c.Index]
+		if !ok {
+			i = len(indexes)
+			byName[c.Index] = i
+			indexes = append(indexes, Index{Name: c.Index, Unique: c.UniqueIndex})
+		} else if indexes[i].Unique != c.UniqueIndex {
+			return nil, fmt.Errorf("%s: %s: index %s is unique for some columns only", c.Field.Pos, c.Field.Name, c.Index)
+		}
+		indexes[i].Columns = append(indexes[i].Columns, c.Name)
+	}
+	return indexes, nil
+}
+
+// ColumnNames returns the names of columns.
+func ColumnNames(columns []Column) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// String returns the column with its constraints, for messages.
+func (c Column) String() string {
+	var b strings.Builder
+	b.WriteString(c.Name)
+	if c.PrimaryKey {
+		b.WriteString(" pk")
+	}
+	if c.AutoIncrement {
+		b.WriteString(" autoincr")
+	}
+	if c.Unique {
+		b.WriteString(" unique")
+	}
+	if c.Null {
+		b.WriteString(" null")
+	}
+	return b.String()
+}
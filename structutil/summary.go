@@ -0,0 +1,109 @@
+package structutil
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+// summarize inserts a package doc comment into the generated file src,
+// listing the types and methods it declares and the structs and directives
+// they were generated from.
+func summarize(toolName string, src []byte, infos []*StructInfo) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var typeNames, methods, funcs []string
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				typeNames = append(typeNames, spec.(*ast.TypeSpec).Name.Name)
+			}
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv == nil {
+				funcs = append(funcs, d.Name.Name)
+				continue
+			}
+			var recv bytes.Buffer
+			if err := printer.Fprint(&recv, fset, d.Recv.List[0].Type); err != nil {
+				return nil, err
+			}
+			methods = append(methods, fmt.Sprintf("(%s).%s", recv.String(), d.Name.Name))
+		}
+	}
+
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name
+	}
+	var doc bytes.Buffer
+	comment := func(text string) {
+		for _, line := range wrap(text, 76) {
+			fmt.Fprintf(&doc, "// %s\n", line)
+		}
+	}
+	comment(fmt.Sprintf("Package %s contains code generated by %s for %s.", f.Name.Name, toolName, strings.Join(names, ", ")))
+	for _, list := range []struct {
+		what  string
+		names []string
+	}{{"Types", typeNames}, {"Functions", funcs}, {"Methods", methods}} {
+		if len(list.names) > 0 {
+			doc.WriteString("//\n")
+			comment(fmt.Sprintf("%s: %s.", list.what, strings.Join(list.names, ", ")))
+		}
+	}
+	for _, info := range infos {
+		if len(info.Directives) == 0 {
+			continue
+		}
+		doc.WriteString("//\n")
+		comment(fmt.Sprintf("Directives of %s:", info.Name))
+		doc.WriteString("//\n")
+		for _, d := range info.Directives {
+			fmt.Fprintf(&doc, "//\t%s\n", d)
+		}
+	}
+
+	// The comment directly precedes the package clause, or the package
+	// comment the generator wrote itself.
+	offset := fset.Position(f.Package).Offset
+	if f.Doc != nil {
+		offset = fset.Position(f.Doc.Pos()).Offset
+		doc.WriteString("//\n")
+	}
+	out := make([]byte, 0, len(src)+doc.Len())
+	out = append(out, src[:offset]...)
+	out = append(out, doc.Bytes()...)
+	return append(out, src[offset:]...), nil
+}
+
+// wrap breaks text into lines of at most width bytes at spaces.
+func wrap(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return append(lines, line)
+}
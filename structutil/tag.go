@@ -0,0 +1,86 @@
+package structutil
+
+import "strings"
+
+// TagValue is a struct tag value parsed into its name and options, so that
+// json:",omitempty", validate:"min=3" and db:"name,pk,autoincr" are read
+// the same way.
+type TagValue struct {
+	Key     string
+	Name    string // Empty for keys whose values are only options.
+	Options []Option
+}
+
+// Option is an option of a tag value. Arguments follow the name after "="
+// or ":" and are separated by spaces: min=3 has the argument 3 and
+// oneof=red green has two.
+type Option struct {
+	Name string
+	Args []string
+}
+
+// Arg returns the arguments of the option as written, or "".
+func (o *Option) Arg() string {
+	return strings.Join(o.Args, " ")
+}
+
+// optionTags are the tag keys whose values have no name, only options.
+var optionTags = map[string]bool{
+	"validate": true,
+	"binding":  true,
+}
+
+// RegisterOptionTag declares that the values of the key tag consist of
+// options only, like those of validate, rather than a name followed by
+// options.
+func RegisterOptionTag(key string) {
+	optionTags[key] = true
+}
+
+// ParseTagValue parses the value of the key tag.
+func ParseTagValue(key, value string) *TagValue {
+	t := &TagValue{Key: key}
+	parts := strings.Split(value, ",")
+	if !optionTags[key] {
+		t.Name, parts = parts[0], parts[1:]
+	}
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		o := Option{Name: part}
+		if i := strings.IndexAny(part, "=:"); i >= 0 {
+			o.Name, o.Args = part[:i], strings.Fields(part[i+1:])
+		}
+		t.Options = append(t.Options, o)
+	}
+	return t
+}
+
+// Option returns the named option, or nil.
+func (t *TagValue) Option(name string) *Option {
+	for i := range t.Options {
+		if t.Options[i].Name == name {
+			return &t.Options[i]
+		}
+	}
+	return nil
+}
+
+// HasOption reports whether the tag value has the named option.
+func (t *TagValue) HasOption(name string) bool {
+	return t.Option(name) != nil
+}
+
+// TagValue returns the parsed value of the key tag, or nil if the field has
+// none.
+func (f *StructFieldInfo) TagValue(key string) *TagValue {
+	if f.Tags == nil {
+		return nil
+	}
+	tag, err := f.Tags.Get(key)
+	if err != nil {
+		return nil
+	}
+	return ParseTagValue(key, tag.Value())
+}
@@ -0,0 +1,165 @@
+package structutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// transaction stages the files of a run and writes them only on commit, so
+// a failure for one type leaves the outputs of the others untouched. If
+// committing fails midway, the files already replaced are restored.
+type transaction struct {
+	stream bool // Write staged files to temporary files right away.
+	ops    []*fileOp
+	dirs   []string // Directories created for staged files, parents first.
+}
+
+// fileOp writes src, or the temporary file temp, to name, or removes name
+// if both are empty.
+type fileOp struct {
+	name string
+	src  []byte
+	temp string
+
+	backup  []byte // Previous contents of name.
+	existed bool
+	done    bool
+}
+
+// write stages src to be written to name.
+func (t *transaction) write(name string, src []byte) error {
+	op := &fileOp{name: name, src: src}
+	if err := t.mkdirAll(filepath.Dir(name)); err != nil {
+		return err
+	}
+	if t.stream {
+		f, err := ioutil.TempFile(filepath.Dir(name), "."+filepath.Base(name)+".*")
+		if err != nil {
+			return err
+		}
+		op.temp, op.src = f.Name(), nil
+		f.Close()
+		if err := os.Chmod(op.temp, 0644); err != nil {
+			os.Remove(op.temp)
+			return err
+		}
+		if err := streamFile(op.temp, src); err != nil {
+			os.Remove(op.temp)
+			return err
+		}
+	}
+	t.ops = append(t.ops, op)
+	return nil
+}
+
+// mkdirAll creates dir and any missing parents, recording the ones it
+// created so that rollback removes them again.
+func (t *transaction) mkdirAll(dir string) error {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); !os.IsNotExist(err) {
+			break
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		t.dirs = append(t.dirs, missing[i])
+	}
+	return nil
+}
+
+// remove stages the removal of name.
+func (t *transaction) remove(name string) {
+	t.ops = append(t.ops, &fileOp{name: name})
+}
+
+// commit applies the staged operations in order. On failure it restores the
+// files it already changed and returns the error.
+func (t *transaction) commit() error {
+	for _, op := range t.ops {
+		if err := op.apply(); err != nil {
+			t.rollback()
+			return fmt.Errorf("%s: %w", op.name, err)
+		}
+	}
+	t.ops, t.dirs = nil, nil
+	return nil
+}
+
+func (op *fileOp) apply() error {
+	backup, err := ioutil.ReadFile(op.name)
+	switch {
+	case err == nil:
+		op.backup, op.existed = backup, true
+	case !os.IsNotExist(err):
+		return err
+	}
+	switch {
+	case op.temp != "":
+		err = os.Rename(op.temp, op.name)
+	case op.src != nil:
+		err = ioutil.WriteFile(op.name, op.src, 0644)
+	default:
+		// A file that is already gone needs no removing.
+		if err = os.Remove(op.name); os.IsNotExist(err) {
+			err = nil
+		}
+	}
+	op.done = err == nil
+	return err
+}
+
+// rollback restores the files changed by applied operations, discards
+// pending temporary files and removes the directories created for them.
+func (t *transaction) rollback() {
+	if t == nil {
+		return
+	}
+	for i := len(t.ops) - 1; i >= 0; i-- {
+		op := t.ops[i]
+		if !op.done {
+			if op.temp != "" {
+				os.Remove(op.temp)
+			}
+			continue
+		}
+		var err error
+		if op.existed {
+			err = ioutil.WriteFile(op.name, op.backup, 0644)
+		} else if err = os.Remove(op.name); os.IsNotExist(err) {
+			err = nil
+		}
+		if err != nil {
+			log.Printf("restoring %s: %s", op.name, err)
+		}
+	}
+	for i := len(t.dirs) - 1; i >= 0; i-- {
+		if err := os.Remove(t.dirs[i]); err != nil && !os.IsNotExist(err) {
+			log.Printf("removing %s: %s", t.dirs[i], err)
+		}
+	}
+	t.ops, t.dirs = nil, nil
+}
+
+// fatalf discards the staged output and ends the run with the formatted
+// error, which Generate returns.
+func (g *GenerateForFields) fatalf(format string, args ...interface{}) {
+	g.txn.rollback()
+	panic(runError{fmt.Errorf(format, args...)})
+}
+
+// Fatalf ends the run with the formatted error, like the failures of the
+// toolkit itself. Generators report invalid input with it rather than with
+// log.Fatalf, so that Generate returns the error instead of exiting.
+func (p *shadowPrinter) Fatalf(format string, args ...interface{}) {
+	p.fatalf(format, args...)
+}
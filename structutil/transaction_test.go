@@ -0,0 +1,145 @@
+package structutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTransactionRollback(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		name := "buffered"
+		if stream {
+			name = "stream"
+		}
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			existing := filepath.Join(dir, "a_getter.go")
+			created := filepath.Join(dir, "b_getter.go")
+			removed := filepath.Join(dir, "a_getter_2.go")
+			blocked := filepath.Join(dir, "c_getter.go")
+			writeFile(t, existing, "old a")
+			writeFile(t, removed, "old chunk")
+			// A directory in place of an output makes writing it fail after
+			// the earlier operations were applied.
+			if err := os.Mkdir(blocked, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			txn := &transaction{stream: stream}
+			for _, name := range []string{existing, created} {
+				if err := txn.write(name, []byte("new "+filepath.Base(name))); err != nil {
+					t.Fatal(err)
+				}
+			}
+			txn.remove(removed)
+			if err := txn.write(blocked, []byte("new c")); err != nil {
+				t.Fatal(err)
+			}
+			err := txn.commit()
+			if err == nil || !strings.Contains(err.Error(), blocked) {
+				t.Fatalf("commit error = %v, want one naming %s", err, blocked)
+			}
+
+			if got := readFile(t, existing); got != "old a" {
+				t.Errorf("%s = %q after rollback, want %q", existing, got, "old a")
+			}
+			if got := readFile(t, removed); got != "old chunk" {
+				t.Errorf("%s = %q after rollback, want %q", removed, got, "old chunk")
+			}
+			if _, err := os.Stat(created); !os.IsNotExist(err) {
+				t.Errorf("%s exists after rollback", created)
+			}
+			entries, err := ioutil.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				if strings.HasPrefix(e.Name(), ".") {
+					t.Errorf("temporary file %s left behind", e.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestTransactionCommit(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "sub", "a_getter.go")
+	stale := filepath.Join(dir, "a_getter_2.go")
+	writeFile(t, stale, "stale")
+
+	txn := &transaction{stream: true}
+	if err := txn.write(name, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	txn.remove(stale)
+	if err := txn.commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, name); got != "new" {
+		t.Errorf("%s = %q, want %q", name, got, "new")
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("%s was not removed", stale)
+	}
+}
+
+func TestTransactionRollbackRemovesCreatedDirs(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		dir := t.TempDir()
+		blocked := filepath.Join(dir, "b_getter.go")
+		if err := os.Mkdir(blocked, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		txn := &transaction{stream: stream}
+		for _, name := range []string{filepath.Join(dir, "gen", "sub", "a_getter.go"), blocked} {
+			if err := txn.write(name, []byte("new")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := txn.commit(); err == nil {
+			t.Fatalf("stream=%v: commit succeeded, want an error for %s", stream, blocked)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "gen")); !os.IsNotExist(err) {
+			t.Errorf("stream=%v: created directory %s left behind", stream, filepath.Join(dir, "gen"))
+		}
+	}
+}
+
+func TestTransactionRemoveMissing(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "a_getter_2.go")
+	name := filepath.Join(dir, "a_getter.go")
+
+	txn := &transaction{}
+	txn.remove(missing)
+	if err := txn.write(name, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, name); got != "new" {
+		t.Errorf("%s = %q, want %q", name, got, "new")
+	}
+}
+
+func writeFile(t *testing.T, name, src string) {
+	t.Helper()
+	if err := ioutil.WriteFile(name, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	src, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(src)
+}
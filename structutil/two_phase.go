@@ -0,0 +1,71 @@
+package structutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Plan is what a TwoPhaseGenerator decided to emit for one struct.
+type Plan interface {
+	// Decls returns the identifiers the plan declares, as Name for
+	// top-level declarations and Type.Method for methods. Identifiers
+	// declared by more than one plan are reported as collisions.
+	Decls() []string
+}
+
+// TwoPhaseGenerator separates deciding what to generate from printing it,
+// so that every problem across all requested types is reported before any
+// output is written.
+type TwoPhaseGenerator interface {
+	Analyze(info *StructInfo) (Plan, error)
+	Emit(plan Plan, p PrinterWriter)
+}
+
+// NewTwoPhaseGenerator returns a generator that analyzes all types named by
+// -type, validates the plans against each other and only then emits and
+// writes them.
+func NewTwoPhaseGenerator(c *GenerateForFieldsConfig, generator TwoPhaseGenerator) *GenerateForFields {
+	g := NewForFieldsGenerator(c, nil)
+	g.analyzer = generator
+	g.plans = make(map[string]Plan)
+	return g
+}
+
+// analyze builds and validates the plans of the named types. It fails with
+// every problem if there are any.
+func (g *GenerateForFields) analyze(types []string) {
+	var (
+		problems []string
+		owners   = make(map[string][]string)
+	)
+	for _, typeName := range types {
+		info, _ := g.structInfo(typeName)
+		plan, err := g.analyzer.Analyze(info)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("type %s: %s", typeName, err))
+			continue
+		}
+		g.plans[typeName] = plan
+		for _, decl := range plan.Decls() {
+			if o := owners[decl]; len(o) == 0 || o[len(o)-1] != typeName {
+				owners[decl] = append(o, typeName)
+			}
+		}
+	}
+
+	decls := make([]string, 0, len(owners))
+	for decl := range owners {
+		decls = append(decls, decl)
+	}
+	sort.Strings(decls)
+	for _, decl := range decls {
+		if o := owners[decl]; len(o) > 1 {
+			problems = append(problems, fmt.Sprintf("%s is declared by the plans of %s", decl, strings.Join(o, ", ")))
+		}
+	}
+
+	if len(problems) > 0 {
+		g.fatalf("%s", strings.Join(problems, "\n"))
+	}
+}
@@ -0,0 +1,95 @@
+package structutil
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordingPlan declares a getter per field of its struct, and shared if
+// it is set.
+type recordingPlan struct {
+	name   string
+	fields []string
+	shared string
+}
+
+func (p recordingPlan) Decls() []string {
+	var decls []string
+	for _, f := range p.fields {
+		decls = append(decls, p.name+".Get"+f)
+	}
+	if p.shared != "" {
+		decls = append(decls, p.shared)
+	}
+	return decls
+}
+
+// recordingGenerator records the order of the Analyze and Emit calls.
+type recordingGenerator struct {
+	calls  []string
+	shared string // Declared by every plan.
+}
+
+func (g *recordingGenerator) Analyze(info *StructInfo) (Plan, error) {
+	g.calls = append(g.calls, "analyze "+info.Name)
+	plan := recordingPlan{name: info.Name, shared: g.shared}
+	for _, f := range info.Fields {
+		plan.fields = append(plan.fields, f.Name)
+	}
+	return plan, nil
+}
+
+func (g *recordingGenerator) Emit(plan Plan, p PrinterWriter) {
+	rp := plan.(recordingPlan)
+	g.calls = append(g.calls, "emit "+rp.name)
+	p.Printf("// Code generated by \"go-gen-test\"; DO NOT EDIT.\n\npackage p\n")
+	for _, f := range rp.fields {
+		p.Printf("\nfunc (x *%s) Get%s() int { return x.%s }\n", rp.name, f, f)
+	}
+}
+
+func writePackage(t *testing.T, src string) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/p\n\ngo 1.17\n")
+	writeFile(t, filepath.Join(dir, "p.go"), src)
+	return dir
+}
+
+func TestTwoPhaseAnalyzesAllBeforeEmitting(t *testing.T) {
+	dir := writePackage(t, "package p\n\ntype A struct{ X int }\n\ntype B struct{ Y int }\n")
+	rec := &recordingGenerator{}
+	g := NewTwoPhaseGenerator(&GenerateForFieldsConfig{
+		ToolName:   "go-gen-test",
+		FileSuffix: "test_out",
+	}, rec)
+	res, err := g.Generate(Options{Dir: dir, Types: []string{"A", "B"}, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(rec.calls, ", "), "analyze A, analyze B, emit A, emit B"; got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+	if len(res.Files) != 2 || !strings.Contains(string(res.Files[1].Src), "func (x *B) GetY() int") {
+		t.Errorf("Generate returned %d files, want the emitted plans of A and B", len(res.Files))
+	}
+}
+
+func TestTwoPhaseReportsCollisionsBeforeEmitting(t *testing.T) {
+	dir := writePackage(t, "package p\n\ntype A struct{ X int }\n\ntype B struct{ Y int }\n")
+	rec := &recordingGenerator{shared: "helper"}
+	g := NewTwoPhaseGenerator(&GenerateForFieldsConfig{
+		ToolName:   "go-gen-test",
+		FileSuffix: "test_out",
+	}, rec)
+	_, err := g.Generate(Options{Dir: dir, Types: []string{"A", "B"}, DryRun: true})
+	if err == nil || !strings.Contains(err.Error(), "helper is declared by the plans of A, B") {
+		t.Fatalf("Generate error = %v, want a collision of helper", err)
+	}
+	for _, call := range rec.calls {
+		if strings.HasPrefix(call, "emit") {
+			t.Errorf("%s after a failed analysis", call)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package structutil
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// splitWrap splits the -wrap function, such as github.com/pkg/errors.Wrapf,
+// into its import path and package-qualified name, such as errors.Wrapf.
+func splitWrap(wrap string) (string, string, error) {
+	i := strings.LastIndex(wrap, ".")
+	if i <= 0 || i < strings.LastIndex(wrap, "/") {
+		return "", "", fmt.Errorf("wrap function %q must be an import path and function name, such as github.com/pkg/errors.Wrapf", wrap)
+	}
+	importPath := wrap[:i]
+	return importPath, path.Base(importPath) + wrap[i:], nil
+}
+
+// WrapError returns a Go expression wrapping the error err with the
+// message format, a printf format of the args. By default it is
+// fmt.Errorf("<format>: %w", args..., err); with -wrap set to a function
+// such as github.com/pkg/errors.Wrapf, it is errors.Wrapf(err, "<format>",
+// args...), and the function's package is imported by every output.
+func (s *StructInfo) WrapError(err, format string, args ...string) string {
+	var wrap string
+	if s.gen != nil {
+		wrap = s.gen.opts.Wrap
+	}
+	if wrap == "" {
+		return fmt.Sprintf("fmt.Errorf(%q, %s)", format+": %w", strings.Join(append(args, err), ", "))
+	}
+	_, name, _ := splitWrap(wrap)
+	return fmt.Sprintf("%s(%s)", name, strings.Join(append([]string{err, fmt.Sprintf("%q", format)}, args...), ", "))
+}
+
+// importWrap adds the import of the -wrap function's package after the
+// package clause of src. Formatting removes it again if it is unused.
+func importWrap(src []byte, wrap string) []byte {
+	importPath, _, err := splitWrap(wrap)
+	if err != nil {
+		return src
+	}
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	for i, line := range lines {
+		if bytes.HasPrefix(line, []byte("package ")) {
+			lines[i] = append(append([]byte(nil), line...), fmt.Sprintf("\nimport %q\n", importPath)...)
+			break
+		}
+	}
+	return bytes.Join(lines, nil)
+}
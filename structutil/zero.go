@@ -0,0 +1,77 @@
+package structutil
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// ZeroTest returns a Go boolean expression reporting whether expr, a value
+// of the field's type, is the zero value. Types that are not comparable are
+// tested with reflect.
+func (f *StructFieldInfo) ZeroTest(expr string) string {
+	return f.zeroTest(expr, false)
+}
+
+// NonZeroTest returns the negation of ZeroTest.
+func (f *StructFieldInfo) NonZeroTest(expr string) string {
+	return f.zeroTest(expr, true)
+}
+
+func (f *StructFieldInfo) zeroTest(expr string, negate bool) string {
+	eq, not := "==", ""
+	if negate {
+		eq, not = "!=", "!"
+	}
+	if f.GoType == nil {
+		return fmt.Sprintf("%sreflect.ValueOf(%s).IsZero()", not, expr)
+	}
+	switch t := f.GoType.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsBoolean != 0:
+			if negate {
+				return expr
+			}
+			return "!" + expr
+		case t.Info()&types.IsString != 0:
+			return fmt.Sprintf(`%s %s ""`, expr, eq)
+		case t.Info()&types.IsNumeric != 0:
+			return fmt.Sprintf("%s %s 0", expr, eq)
+		case t.Kind() == types.UnsafePointer:
+			return fmt.Sprintf("%s %s nil", expr, eq)
+		}
+	case *types.Pointer, *types.Interface, *types.Chan, *types.Signature:
+		return fmt.Sprintf("%s %s nil", expr, eq)
+	case *types.Slice, *types.Map:
+		return fmt.Sprintf("len(%s) %s 0", expr, eq)
+	case *types.Struct, *types.Array:
+		if types.Comparable(f.GoType) {
+			return fmt.Sprintf("%s %s (%s{})", expr, eq, f.Type)
+		}
+	}
+	return fmt.Sprintf("%sreflect.ValueOf(%s).IsZero()", not, expr)
+}
+
+// ZeroValue returns a Go expression for the zero value of the field's type.
+func (f *StructFieldInfo) ZeroValue() string {
+	if f.GoType != nil {
+		switch t := f.GoType.Underlying().(type) {
+		case *types.Basic:
+			switch {
+			case t.Info()&types.IsBoolean != 0:
+				return "false"
+			case t.Info()&types.IsString != 0:
+				return `""`
+			case t.Info()&types.IsNumeric != 0:
+				return "0"
+			case t.Kind() == types.UnsafePointer:
+				return "nil"
+			}
+		case *types.Pointer, *types.Interface, *types.Chan, *types.Signature, *types.Slice, *types.Map:
+			return "nil"
+		case *types.Struct, *types.Array:
+			return f.Type + "{}"
+		}
+	}
+	return fmt.Sprintf("*new(%s)", f.Type)
+}
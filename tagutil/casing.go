@@ -0,0 +1,94 @@
+package tagutil
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Casing is a naming convention for tag names.
+type Casing string
+
+const (
+	Snake  Casing = "snake"  // user_id
+	Kebab  Casing = "kebab"  // user-id
+	Camel  Casing = "camel"  // userID
+	Pascal Casing = "pascal" // UserID
+	Lower  Casing = "lower"  // userid
+)
+
+// ParseCasing returns the Casing named s.
+func ParseCasing(s string) (Casing, error) {
+	switch c := Casing(s); c {
+	case Snake, Kebab, Camel, Pascal, Lower:
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown casing %q", s)
+}
+
+// Apply converts name to the casing.
+func (c Casing) Apply(name string) string {
+	words := splitWords(name)
+	switch c {
+	case Snake:
+		return strings.ToLower(strings.Join(words, "_"))
+	case Kebab:
+		return strings.ToLower(strings.Join(words, "-"))
+	case Lower:
+		return strings.ToLower(strings.Join(words, ""))
+	case Camel, Pascal:
+		for i, w := range words {
+			switch {
+			case i == 0 && c == Camel:
+				words[i] = strings.ToLower(w)
+			case isUpper(w):
+				// Keep initialisms such as ID or HTTP intact.
+			default:
+				words[i] = strings.ToUpper(w[:1]) + strings.ToLower(w[1:])
+			}
+		}
+		return strings.Join(words, "")
+	}
+	return name
+}
+
+// splitWords splits an identifier at underscores, dashes and case changes,
+// keeping runs of capitals such as "HTTP" in "HTTPServer" together.
+func splitWords(name string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0:
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+func isUpper(s string) bool {
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+package tagutil
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type inflection struct {
+	pattern *regexp.Regexp
+	replace string
+}
+
+func rules(pairs ...string) []inflection {
+	list := make([]inflection, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		list = append(list, inflection{regexp.MustCompile(pairs[i]), pairs[i+1]})
+	}
+	return list
+}
+
+// Rules are tried in order; the first match wins.
+var (
+	pluralRules = rules(
+		`(quiz)$`, "${1}zes",
+		`(matr|vert|ind)(ix|ex)$`, "${1}ices",
+		`(octop|vir)us$`, "${1}i",
+		`(alias|status|bus|campus)$`, "${1}es",
+		`(ss|sh|ch|x|z)$`, "${1}es",
+		`([^aeiouy]|qu)y$`, "${1}ies",
+		`(?:([^f])fe|([lr])f)$`, "${1}${2}ves",
+		`sis$`, "ses",
+		`([ti])um$`, "${1}a",
+		`(buffal|tomat|potat|her|ech)o$`, "${1}oes",
+		`s$`, "s",
+		`$`, "s",
+	)
+	singularRules = rules(
+		`(quiz)zes$`, "${1}",
+		`(matr)ices$`, "${1}ix",
+		`(vert|ind)ices$`, "${1}ex",
+		`(octop|vir)i$`, "${1}us",
+		`(alias|status|bus|campus)es$`, "${1}",
+		`(ss|sh|ch|x|z)es$`, "${1}",
+		`([^aeiouy]|qu)ies$`, "${1}y",
+		`([lr])ves$`, "${1}f",
+		`([^f])ves$`, "${1}fe",
+		`(analy|ba|diagno|parenthe|progno|synop|the)ses$`, "${1}sis",
+		`([ti])a$`, "${1}um",
+		`(buffal|tomat|potat|her|ech)oes$`, "${1}o",
+		`(ss|us)$`, "${1}",
+		`s$`, "",
+	)
+)
+
+var (
+	inflectMu    sync.RWMutex
+	irregulars   = make(map[string]string) // Singular to plural.
+	singulars    = make(map[string]string) // Plural to singular.
+	uncountables = make(map[string]bool)
+)
+
+func init() {
+	for _, pair := range [][2]string{
+		{"person", "people"},
+		{"man", "men"},
+		{"woman", "women"},
+		{"child", "children"},
+		{"tooth", "teeth"},
+		{"foot", "feet"},
+		{"mouse", "mice"},
+		{"goose", "geese"},
+		{"ox", "oxen"},
+		{"criterion", "criteria"},
+		{"leaf", "leaves"},
+		{"loaf", "loaves"},
+		{"thief", "thieves"},
+	} {
+		RegisterIrregular(pair[0], pair[1])
+	}
+	for _, word := range []string{
+		"data", "deer", "equipment", "feedback", "fish", "information",
+		"metadata", "money", "news", "rice", "series", "sheep", "species",
+	} {
+		RegisterUncountable(word)
+	}
+}
+
+// RegisterIrregular adds or overrides the plural of a word, for example
+// RegisterIrregular("cactus", "cacti"). Both forms are lower case.
+func RegisterIrregular(singular, plural string) {
+	inflectMu.Lock()
+	defer inflectMu.Unlock()
+	irregulars[singular] = plural
+	singulars[plural] = singular
+}
+
+// RegisterUncountable marks a lower-case word as having no plural form.
+func RegisterUncountable(word string) {
+	inflectMu.Lock()
+	defer inflectMu.Unlock()
+	uncountables[word] = true
+}
+
+// Pluralize returns the plural of the last word of name, keeping its case:
+// "Person" becomes "People" and "user_account" becomes "user_accounts".
+func Pluralize(name string) string {
+	return inflect(name, irregulars, pluralRules)
+}
+
+// Singularize returns the singular of the last word of name, keeping its
+// case.
+func Singularize(name string) string {
+	return inflect(name, singulars, singularRules)
+}
+
+// TableName returns the SQL table name of a struct: its snake-cased name
+// with the last word pluralized, so "Person" becomes "people" and
+// "OrderItem" becomes "order_items".
+func TableName(structName string) string {
+	return Pluralize(Snake.Apply(structName))
+}
+
+func inflect(name string, irregular map[string]string, rules []inflection) string {
+	words := splitWords(name)
+	if len(words) == 0 || !strings.HasSuffix(name, words[len(words)-1]) {
+		return name
+	}
+	word := words[len(words)-1]
+	prefix := name[:len(name)-len(word)]
+	lower := strings.ToLower(word)
+
+	inflectMu.RLock()
+	out, ok := irregular[lower]
+	if !ok && !uncountables[lower] {
+		out = lower
+		for _, r := range rules {
+			if r.pattern.MatchString(lower) {
+				out = r.pattern.ReplaceAllString(lower, r.replace)
+				break
+			}
+		}
+	} else if !ok {
+		out = lower
+	}
+	inflectMu.RUnlock()
+
+	switch {
+	case isUpper(word) && len(word) > 1 && strings.HasPrefix(out, lower):
+		// Initialisms take a lower-case suffix: IDs, URLs.
+		out = word + out[len(lower):]
+	case isUpper(word) && len(word) > 1:
+		out = strings.ToUpper(out)
+	case word != lower:
+		out = strings.ToUpper(out[:1]) + out[1:]
+	}
+	return prefix + out
+}
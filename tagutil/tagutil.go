@@ -0,0 +1,138 @@
+// Package tagutil rewrites struct tags in Go source files.
+package tagutil
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/structtag"
+)
+
+// Rule rewrites the tags of a single struct field in place.
+type Rule func(fieldName string, tags *structtag.Tags) error
+
+// Add adds a key tag named after the field in the given casing to fields
+// that do not have one yet.
+func Add(key string, casing Casing) Rule {
+	return func(fieldName string, tags *structtag.Tags) error {
+		if _, err := tags.Get(key); err == nil {
+			return nil
+		}
+		return tags.Set(&structtag.Tag{Key: key, Name: casing.Apply(fieldName)})
+	}
+}
+
+// Sync sets the dst tag to the name and options of the src tag, for fields
+// that have a src tag.
+func Sync(dst, src string) Rule {
+	return func(fieldName string, tags *structtag.Tags) error {
+		tag, err := tags.Get(src)
+		if err != nil {
+			return nil
+		}
+		options := make([]string, len(tag.Options))
+		copy(options, tag.Options)
+		return tags.Set(&structtag.Tag{Key: dst, Name: tag.Name, Options: options})
+	}
+}
+
+// Case converts the names of existing key tags to the given casing. Ignored
+// ("-") and empty names are left alone.
+func Case(key string, casing Casing) Rule {
+	return func(fieldName string, tags *structtag.Tags) error {
+		tag, err := tags.Get(key)
+		if err != nil || tag.Name == "" || tag.Name == "-" {
+			return nil
+		}
+		tag.Name = casing.Apply(tag.Name)
+		return nil
+	}
+}
+
+// Remove deletes the key tag.
+func Remove(key string) Rule {
+	return func(fieldName string, tags *structtag.Tags) error {
+		tags.Delete(key)
+		return nil
+	}
+}
+
+// RewriteFile applies rules to the named fields of the structs in file whose
+// names are in typeNames, or of all structs if typeNames is empty. Embedded
+// fields are skipped. It reports whether any tag changed.
+func RewriteFile(file *ast.File, typeNames []string, rules ...Rule) (bool, error) {
+	want := make(map[string]bool, len(typeNames))
+	for _, name := range typeNames {
+		want[name] = true
+	}
+
+	var (
+		changed bool
+		err     error
+	)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		ts, ok := n.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok || (len(want) > 0 && !want[ts.Name.Name]) {
+			return true
+		}
+		for _, field := range st.Fields.List {
+			if len(field.Names) == 0 {
+				continue
+			}
+			var c bool
+			c, err = rewriteField(field, rules)
+			if err != nil {
+				return false
+			}
+			changed = changed || c
+		}
+		return true
+	})
+	return changed, err
+}
+
+func rewriteField(field *ast.Field, rules []Rule) (bool, error) {
+	var old string
+	if field.Tag != nil {
+		v, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return false, err
+		}
+		old = v
+	}
+	tags, err := structtag.Parse(old)
+	if err != nil {
+		return false, err
+	}
+	for _, rule := range rules {
+		if err := rule(field.Names[0].Name, tags); err != nil {
+			return false, err
+		}
+	}
+
+	value := tags.String()
+	if value == old {
+		return false, nil
+	}
+	if value == "" {
+		field.Tag = nil
+		return true, nil
+	}
+	lit := "`" + value + "`"
+	if strings.Contains(value, "`") {
+		lit = strconv.Quote(value)
+	}
+	if field.Tag == nil {
+		field.Tag = &ast.BasicLit{ValuePos: field.Type.End()}
+	}
+	field.Tag.Value = lit
+	return true, nil
+}
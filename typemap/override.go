@@ -0,0 +1,56 @@
+package typemap
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// TagKey is the struct tag overriding the mappings of a field, such as
+//
+//	ID  UserID      `genmap:"ts=string,sql=uuid"`
+//	Sum decimal.Dec `genmap:"sql=numeric(12,2);avro=string"`
+//
+// Pairs are separated by commas, or by semicolons if a type contains a
+// comma. The key sql sets both Postgres and MySQL.
+const TagKey = "genmap"
+
+// Overrides are the mappings a field's genmap tag sets, by target.
+type Overrides map[Target]Mapping
+
+// ParseOverrides parses the value of a genmap tag.
+func ParseOverrides(tag string) (Overrides, error) {
+	if tag == "" {
+		return nil, nil
+	}
+	sep := ","
+	if strings.Contains(tag, ";") {
+		sep = ";"
+	}
+	o := make(Overrides)
+	for _, pair := range strings.Split(tag, sep) {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, fmt.Errorf("invalid %s pair %q; want target=type", TagKey, pair)
+		}
+		if kv[0] == "sql" {
+			o[Postgres] = Mapping{Type: kv[1]}
+			o[MySQL] = Mapping{Type: kv[1]}
+			continue
+		}
+		target, err := ParseTarget(kv[0])
+		if err != nil {
+			return nil, err
+		}
+		o[target] = Mapping{Type: kv[1]}
+	}
+	return o, nil
+}
+
+// Lookup returns the overridden mapping of target, else the mapping of t.
+func (o Overrides) Lookup(t types.Type, target Target) (Mapping, bool) {
+	if m, ok := o[target]; ok {
+		return m, true
+	}
+	return Lookup(t, target)
+}
@@ -0,0 +1,248 @@
+// Package typemap maps Go types to their representations in other languages
+// and schema formats, so that all cross-language generators agree on them.
+// The mapping covers basic types and well-known named types; generators
+// compose slices, maps, pointers and structs themselves.
+package typemap
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+// Target is a language or schema format types are mapped to.
+type Target string
+
+const (
+	JSONSchema Target = "jsonschema"
+	TypeScript Target = "ts"
+	Postgres   Target = "postgres"
+	MySQL      Target = "mysql"
+	Proto      Target = "proto"
+	Avro       Target = "avro"
+)
+
+// Targets returns the known targets in a stable order.
+func Targets() []Target {
+	return []Target{JSONSchema, TypeScript, Postgres, MySQL, Proto, Avro}
+}
+
+// ParseTarget returns the Target named s.
+func ParseTarget(s string) (Target, error) {
+	for _, t := range Targets() {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown type mapping target %q", s)
+}
+
+// Mapping is the representation of a Go type in a target.
+type Mapping struct {
+	// Type is the target's name of the type, such as bigint or string.
+	Type string
+	// Format refines Type where the target has formats or logical types,
+	// such as date-time in JSON Schema or timestamp-micros in Avro.
+	Format string
+	// Import is what a schema using the type must import, such as
+	// google/protobuf/timestamp.proto.
+	Import string
+	// Null reports whether the Go type itself can hold null, like
+	// sql.NullString.
+	Null bool
+}
+
+// registry maps Go type names, as returned by Name, to their mappings.
+var registry = make(map[string]map[Target]Mapping)
+
+// Register sets the mapping of the Go type named goType in target,
+// replacing any earlier one. goType is a name as returned by Name, such as
+// int64, []byte, time.Time or database/sql.NullString.
+func Register(goType string, target Target, m Mapping) {
+	if registry[goType] == nil {
+		registry[goType] = make(map[Target]Mapping)
+	}
+	registry[goType][target] = m
+}
+
+// Registered returns the Go type names with a mapping in target, sorted.
+func Registered(target Target) []string {
+	var names []string
+	for name, mappings := range registry {
+		if _, ok := mappings[target]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Name returns the registry name of t: the package path and name of a
+// named type, the name of a basic type's kind, []byte for byte slices, or
+// the type's string otherwise.
+func Name(t types.Type) string {
+	switch u := t.(type) {
+	case *types.Named:
+		if u.Obj().Pkg() == nil {
+			return u.Obj().Name()
+		}
+		return u.Obj().Pkg().Path() + "." + u.Obj().Name()
+	case *types.Basic:
+		return types.Typ[u.Kind()].Name()
+	case *types.Slice:
+		if b, ok := u.Elem().(*types.Basic); ok && b.Kind() == types.Uint8 {
+			return "[]byte"
+		}
+	}
+	return t.String()
+}
+
+// Lookup returns the mapping of t in target. Named types without a mapping
+// of their own map like their underlying type.
+func Lookup(t types.Type, target Target) (Mapping, bool) {
+	for {
+		if m, ok := LookupName(Name(t), target); ok {
+			return m, true
+		}
+		named, ok := t.(*types.Named)
+		if !ok {
+			return Mapping{}, false
+		}
+		t = named.Underlying()
+	}
+}
+
+// LookupName returns the mapping registered for the Go type named goType
+// in target.
+func LookupName(goType string, target Target) (Mapping, bool) {
+	m, ok := registry[goType][target]
+	return m, ok
+}
+
+func register(goType string, mappings map[Target]Mapping) {
+	for target, m := range mappings {
+		Register(goType, target, m)
+	}
+}
+
+func init() {
+	register("bool", map[Target]Mapping{
+		JSONSchema: {Type: "boolean"},
+		TypeScript: {Type: "boolean"},
+		Postgres:   {Type: "boolean"},
+		MySQL:      {Type: "boolean"},
+		Proto:      {Type: "bool"},
+		Avro:       {Type: "boolean"},
+	})
+	for _, kind := range []struct {
+		name     string
+		bits     int
+		unsigned bool
+		postgres string
+		mysql    string
+	}{
+		{"int8", 8, false, "smallint", "tinyint"},
+		{"uint8", 8, true, "smallint", "tinyint unsigned"},
+		{"int16", 16, false, "smallint", "smallint"},
+		{"uint16", 16, true, "integer", "smallint unsigned"},
+		{"int32", 32, false, "integer", "int"},
+		{"uint32", 32, true, "bigint", "int unsigned"},
+		{"int", 64, false, "bigint", "bigint"},
+		{"int64", 64, false, "bigint", "bigint"},
+		{"uint", 64, true, "bigint", "bigint unsigned"},
+		{"uint64", 64, true, "bigint", "bigint unsigned"},
+	} {
+		format, proto, avro := "int32", "int32", "int"
+		if kind.bits == 64 || kind.name == "uint32" {
+			format, avro = "int64", "long"
+		}
+		switch {
+		case kind.bits == 64 && kind.unsigned:
+			proto = "uint64"
+		case kind.bits == 64:
+			proto = "int64"
+		case kind.unsigned:
+			proto = "uint32"
+		}
+		register(kind.name, map[Target]Mapping{
+			JSONSchema: {Type: "integer", Format: format},
+			TypeScript: {Type: "number"},
+			Postgres:   {Type: kind.postgres},
+			MySQL:      {Type: kind.mysql},
+			Proto:      {Type: proto},
+			Avro:       {Type: avro},
+		})
+	}
+	register("float32", map[Target]Mapping{
+		JSONSchema: {Type: "number", Format: "float"},
+		TypeScript: {Type: "number"},
+		Postgres:   {Type: "real"},
+		MySQL:      {Type: "float"},
+		Proto:      {Type: "float"},
+		Avro:       {Type: "float"},
+	})
+	register("float64", map[Target]Mapping{
+		JSONSchema: {Type: "number", Format: "double"},
+		TypeScript: {Type: "number"},
+		Postgres:   {Type: "double precision"},
+		MySQL:      {Type: "double"},
+		Proto:      {Type: "double"},
+		Avro:       {Type: "double"},
+	})
+	register("string", map[Target]Mapping{
+		JSONSchema: {Type: "string"},
+		TypeScript: {Type: "string"},
+		Postgres:   {Type: "text"},
+		MySQL:      {Type: "text"},
+		Proto:      {Type: "string"},
+		Avro:       {Type: "string"},
+	})
+	register("[]byte", map[Target]Mapping{
+		JSONSchema: {Type: "string", Format: "byte"},
+		TypeScript: {Type: "string"},
+		Postgres:   {Type: "bytea"},
+		MySQL:      {Type: "blob"},
+		Proto:      {Type: "bytes"},
+		Avro:       {Type: "bytes"},
+	})
+	register("time.Time", map[Target]Mapping{
+		JSONSchema: {Type: "string", Format: "date-time"},
+		TypeScript: {Type: "string"},
+		Postgres:   {Type: "timestamptz"},
+		MySQL:      {Type: "datetime(6)"},
+		Proto:      {Type: "google.protobuf.Timestamp", Import: "google/protobuf/timestamp.proto"},
+		Avro:       {Type: "long", Format: "timestamp-micros"},
+	})
+	// Decimals keep their precision as numeric columns and as strings
+	// elsewhere.
+	register("github.com/shopspring/decimal.Decimal", map[Target]Mapping{
+		JSONSchema: {Type: "string", Format: "decimal"},
+		TypeScript: {Type: "string"},
+		Postgres:   {Type: "numeric"},
+		MySQL:      {Type: "decimal(65,30)"},
+		Proto:      {Type: "string"},
+		Avro:       {Type: "string"},
+	})
+	register("encoding/json.RawMessage", map[Target]Mapping{
+		TypeScript: {Type: "unknown"},
+		Postgres:   {Type: "jsonb"},
+		MySQL:      {Type: "json"},
+	})
+
+	// The nullable types of database/sql map like the types they wrap.
+	for name, wrapped := range map[string]string{
+		"NullBool":    "bool",
+		"NullByte":    "uint8",
+		"NullFloat64": "float64",
+		"NullInt16":   "int16",
+		"NullInt32":   "int32",
+		"NullInt64":   "int64",
+		"NullString":  "string",
+		"NullTime":    "time.Time",
+	} {
+		for target, m := range registry[wrapped] {
+			m.Null = true
+			Register("database/sql."+name, target, m)
+		}
+	}
+}